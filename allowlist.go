@@ -0,0 +1,39 @@
+package jsoncolor
+
+import "io"
+
+// FormatAllowlist is like Format but displays in full only the values
+// identified by the JSON Pointers (see RFC 6901) in allowed, such as
+// "/user/name", and the values nested inside them.  Every other
+// scalar value is redacted, replaced by the summary of its type
+// displayed in OutlineMode, while objects and arrays are displayed
+// as usual so that the structure of the input, including its field
+// names, remains visible.  This allows a document to be shared
+// exposing only approved fields.  Strings are not unwrapped by f's
+// UnwrapEmbeddedJSON field, which would reveal the field names of
+// redacted embedded JSON.
+func (f *Formatter) FormatAllowlist(dst io.Writer, src []byte, allowed []string) error {
+	var prefixes [][]string
+	for _, p := range allowed {
+		prefix, err := parsePointer(p)
+		if err != nil {
+			return err
+		}
+		prefixes = append(prefixes, prefix)
+	}
+
+	g := f.clone()
+	g.UnwrapEmbeddedJSON = false
+
+	fs := newFormatterState(g, dst)
+	fs.redact = func(path []string) bool {
+		for _, prefix := range prefixes {
+			if hasPathPrefix(path, prefix) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return fs.format(dst, src, false)
+}
@@ -0,0 +1,34 @@
+package jsoncolor
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestAnnotations(t *testing.T) {
+	src := `{"a":1,"bb":"long value","c":{"d":true,"e":null}}`
+	annotations := map[string]string{"": "root", "/a": "one", "/bb": "two", "/c": "obj", "/c/d": "inner", "/missing": "x"}
+
+	f := &Formatter{Indent: "  ", Annotations: annotations, CommentColor: color.New(color.FgCyan)}
+	got := formatString(t, f, src)
+	want := "{  // root\n" +
+		"  \"a\": 1,              // one\n" +
+		"  \"bb\": \"long value\",  // two\n" +
+		"  \"c\": {               // obj\n" +
+		"    \"d\": true,  // inner\n" +
+		"    \"e\": null\n" +
+		"  }\n" +
+		"}"
+	if uncolored(got) != want {
+		t.Errorf("Format with Annotations = %q, want %q", uncolored(got), want)
+	}
+	if c := colorOf(t, got, "// one"); c != (ColorSpec{Foreground: color.FgCyan}) {
+		t.Errorf("comment displayed as %+v, want it in CommentColor", c)
+	}
+
+	f = &Formatter{Annotations: annotations}
+	if got := uncolored(formatString(t, f, src)); got != src {
+		t.Errorf("compact Format with Annotations = %q, want %q", got, src)
+	}
+}
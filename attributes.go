@@ -0,0 +1,155 @@
+package jsoncolor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// SetAttributes sets the color field of f used for tokens of the
+// given kind, such as FieldColor for TokenField, to its current
+// color, or the default color if it is nil, with its attributes such
+// as bold, italic and underline replaced by attrs while preserving
+// its foreground and background colors.  For example,
+// SetAttributes(TokenString, color.Italic) displays strings in
+// italics in their usual color.  The attributes are applied to the
+// escape sequences the color writes, and calling SetAttributes again
+// for the same kind replaces them.  Assigning the color field
+// afterwards discards them.  Setting the attributes of TokenField or
+// TokenString also sets those of TokenFieldQuote or TokenStringQuote
+// respectively, so that quotes match the text they surround, while
+// setting those of the quotes affects only the quotes.  Kinds without
+// a color field of their own, such as TokenOffset and TokenBadge, are
+// unaffected.
+func (f *Formatter) SetAttributes(kind TokenKind, attrs ...color.Attribute) {
+	p := f.colorField(kind)
+	if p == nil {
+		return
+	}
+	switch kind {
+	case TokenField:
+		f.SetAttributes(TokenFieldQuote, attrs...)
+	case TokenString:
+		f.SetAttributes(TokenStringQuote, attrs...)
+	}
+	base := f.kindColor(kind)
+	if c, ok := base.(attributeColor); ok {
+		base = c.color
+	}
+	*p = attributeColor{color: base, attrs: attrs}
+}
+
+// colorField returns a pointer to the color field of f used for
+// tokens of the given kind, or nil if there is none.
+func (f *Formatter) colorField(kind TokenKind) *SprintfFuncer {
+	switch kind {
+	case TokenSpace:
+		return &f.SpaceColor
+	case TokenComma:
+		return &f.CommaColor
+	case TokenColon:
+		return &f.ColonColor
+	case TokenObject:
+		return &f.ObjectColor
+	case TokenArray:
+		return &f.ArrayColor
+	case TokenFieldQuote:
+		return &f.FieldQuoteColor
+	case TokenField:
+		return &f.FieldColor
+	case TokenStringQuote:
+		return &f.StringQuoteColor
+	case TokenString:
+		return &f.StringColor
+	case TokenTrue:
+		return &f.TrueColor
+	case TokenFalse:
+		return &f.FalseColor
+	case TokenNumber:
+		return &f.NumberColor
+	case TokenNull:
+		return &f.NullColor
+	case TokenMore:
+		return &f.MoreColor
+	case TokenEllipsis:
+		return &f.EllipsisColor
+	case TokenUnparseable:
+		return &f.UnparseableColor
+	case TokenComment:
+		return &f.CommentColor
+	case TokenTrailingSpace:
+		return &f.TrailingSpaceColor
+	case TokenVisibleWhitespace:
+		return &f.VisibleWhitespaceColor
+	case TokenUnit:
+		return &f.UnitColor
+	case TokenMarkupTag:
+		return &f.MarkupTagColor
+	case TokenMarkupAttribute:
+		return &f.MarkupAttributeColor
+	case TokenEmbedded:
+		return &f.EmbeddedColor
+	case TokenExponent:
+		return &f.ExponentColor
+	case TokenRootLabel:
+		return &f.RootLabelColor
+	case TokenReplacementChar:
+		return &f.ReplacementCharColor
+	}
+	return nil
+}
+
+// attributeColor is a color with the attributes of the escape
+// sequence color writes before text replaced by attrs.
+type attributeColor struct {
+	color SprintfFuncer
+	attrs []color.Attribute
+}
+
+func (c attributeColor) SprintfFunc() func(format string, a ...interface{}) string {
+	sprintf := c.color.SprintfFunc()
+	return func(format string, a ...interface{}) string {
+		s := sprintf(format, a...)
+		n := ansiLen(s)
+		if n == 0 || s[n-1] != 'm' {
+			return s
+		}
+		return "\x1b[" + replaceAttributes(s[2:n-1], c.attrs) + "m" + s[n:]
+	}
+}
+
+// replaceAttributes returns the SGR parameters params with those
+// other than colors replaced by attrs.
+func replaceAttributes(params string, attrs []color.Attribute) string {
+	var kept []string
+	ps := strings.Split(params, ";")
+	for i := 0; i < len(ps); i++ {
+		n, err := strconv.Atoi(ps[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case n == 38 || n == 48:
+			// extended 256 and 24-bit colors
+			end := i + 1
+			if i+1 < len(ps) && ps[i+1] == "5" {
+				end = i + 3
+			} else if i+1 < len(ps) && ps[i+1] == "2" {
+				end = i + 5
+			}
+			if end > len(ps) {
+				end = len(ps)
+			}
+			kept = append(kept, ps[i:end]...)
+			i = end - 1
+		case n >= 30 && n <= 37, n == 39, n >= 90 && n <= 97,
+			n >= 40 && n <= 47, n == 49, n >= 100 && n <= 107:
+			kept = append(kept, ps[i])
+		}
+	}
+	for _, a := range attrs {
+		kept = append(kept, strconv.Itoa(int(a)))
+	}
+	return strings.Join(kept, ";")
+}
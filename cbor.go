@@ -0,0 +1,328 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// FormatCBOR is like Format but colorizes the JSON equivalent of the
+// CBOR-encoded (see RFC 8949) src.  Byte strings are displayed as
+// base64-encoded strings as with encoding/json, map keys that are
+// not text strings are displayed as strings containing their JSON
+// encoding, undefined is displayed as null and tags are ignored
+// except for bignums, which are displayed as numbers.  The
+// non-finite floating point values NaN and Infinity result in an
+// error unless f's Tolerant field is true.
+//
+// MessagePack is not supported.  Unlike CBOR it has no standard
+// mapping to JSON for its extension types, so data using them
+// could not be displayed without application-specific knowledge.
+func (f *Formatter) FormatCBOR(dst io.Writer, src []byte) error {
+	d := &cborDecoder{data: src, tolerant: f.Tolerant}
+	err := d.item(0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return fmt.Errorf("jsoncolor: invalid CBOR, %d trailing bytes", len(d.data)-d.pos)
+	}
+	return f.Format(dst, d.buf.Bytes())
+}
+
+// maxCBORDepth is the maximum nesting depth of CBOR data accepted by
+// FormatCBOR.
+const maxCBORDepth = 10000
+
+var errCBORTruncated = errors.New("jsoncolor: invalid CBOR, unexpected end of data")
+
+// cborDecoder transcodes CBOR data to JSON.
+type cborDecoder struct {
+	data     []byte
+	pos      int
+	tolerant bool
+	buf      bytes.Buffer
+}
+
+// head reads the initial byte and argument of a data item, returning
+// its major type, additional information and argument.
+func (d *cborDecoder) head() (major, info byte, arg uint64, err error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, 0, errCBORTruncated
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info = b>>5, b&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		n := 1 << (info - 24)
+		if len(d.data)-d.pos < n {
+			return 0, 0, 0, errCBORTruncated
+		}
+		p := d.data[d.pos : d.pos+n]
+		d.pos += n
+		switch n {
+		case 1:
+			arg = uint64(p[0])
+		case 2:
+			arg = uint64(binary.BigEndian.Uint16(p))
+		case 4:
+			arg = uint64(binary.BigEndian.Uint32(p))
+		case 8:
+			arg = binary.BigEndian.Uint64(p)
+		}
+		return major, info, arg, nil
+	case info == 31:
+		return major, info, 0, nil
+	}
+	return 0, 0, 0, fmt.Errorf("jsoncolor: invalid CBOR, reserved additional information %d", info)
+}
+
+// isBreak reports whether the next byte is the break stop code,
+// consuming it if so.
+func (d *cborDecoder) isBreak() (bool, error) {
+	if d.pos >= len(d.data) {
+		return false, errCBORTruncated
+	}
+	if d.data[d.pos] == 0xff {
+		d.pos++
+		return true, nil
+	}
+	return false, nil
+}
+
+// bytes reads the contents of a byte or text string of the given
+// major type.
+func (d *cborDecoder) bytes(major, info byte, arg uint64) ([]byte, error) {
+	if info != 31 {
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errCBORTruncated
+		}
+		b := d.data[d.pos : d.pos+int(arg)]
+		d.pos += int(arg)
+		return b, nil
+	}
+	// indefinite length, concatenate definite length chunks
+	var b []byte
+	for {
+		brk, err := d.isBreak()
+		if err != nil {
+			return nil, err
+		}
+		if brk {
+			return b, nil
+		}
+		m, i, a, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || i == 31 {
+			return nil, fmt.Errorf("jsoncolor: invalid CBOR, bad indefinite length string chunk")
+		}
+		chunk, err := d.bytes(m, i, a)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, chunk...)
+	}
+}
+
+func (d *cborDecoder) writeString(s string) {
+	b, _ := json.Marshal(s)
+	d.buf.Write(b)
+}
+
+func (d *cborDecoder) writeFloat(v float64, bitSize int) error {
+	switch {
+	case math.IsNaN(v) || math.IsInf(v, 0):
+		if !d.tolerant {
+			return fmt.Errorf("jsoncolor: unsupported CBOR value %v", v)
+		}
+		switch {
+		case math.IsNaN(v):
+			d.buf.WriteString("NaN")
+		case v > 0:
+			d.buf.WriteString("Infinity")
+		default:
+			d.buf.WriteString("-Infinity")
+		}
+	default:
+		d.buf.WriteString(strconv.FormatFloat(v, 'g', -1, bitSize))
+	}
+	return nil
+}
+
+// item transcodes the next data item at the given nesting depth.
+func (d *cborDecoder) item(depth int) error {
+	if depth > maxCBORDepth {
+		return fmt.Errorf("jsoncolor: invalid CBOR, exceeded max depth")
+	}
+	major, info, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	if info == 31 && (major == 0 || major == 1 || major == 6) {
+		return fmt.Errorf("jsoncolor: invalid CBOR, indefinite length for major type %d", major)
+	}
+	switch major {
+	case 0:
+		d.buf.WriteString(strconv.FormatUint(arg, 10))
+	case 1:
+		n := new(big.Int).SetUint64(arg)
+		n.Add(n, big.NewInt(1)).Neg(n)
+		d.buf.WriteString(n.String())
+	case 2:
+		b, err := d.bytes(major, info, arg)
+		if err != nil {
+			return err
+		}
+		d.writeString(base64.StdEncoding.EncodeToString(b))
+	case 3:
+		b, err := d.bytes(major, info, arg)
+		if err != nil {
+			return err
+		}
+		d.writeString(string(b))
+	case 4, 5:
+		return d.container(major, info, arg, depth)
+	case 6:
+		return d.tag(arg, depth)
+	case 7:
+		return d.simple(info, arg)
+	}
+	return nil
+}
+
+// container transcodes an array or map.
+func (d *cborDecoder) container(major, info byte, arg uint64, depth int) error {
+	open, close := byte('['), byte(']')
+	if major == 5 {
+		open, close = '{', '}'
+	}
+	d.buf.WriteByte(open)
+	for i := uint64(0); info == 31 || i < arg; i++ {
+		if info == 31 {
+			brk, err := d.isBreak()
+			if err != nil {
+				return err
+			}
+			if brk {
+				break
+			}
+		} else if d.pos >= len(d.data) {
+			return errCBORTruncated
+		}
+		if i > 0 {
+			d.buf.WriteByte(',')
+		}
+		if major == 5 {
+			err := d.key(depth + 1)
+			if err != nil {
+				return err
+			}
+			d.buf.WriteByte(':')
+		}
+		err := d.item(depth + 1)
+		if err != nil {
+			return err
+		}
+	}
+	d.buf.WriteByte(close)
+	return nil
+}
+
+// key transcodes a map key, which is written as a string.
+func (d *cborDecoder) key(depth int) error {
+	if d.pos < len(d.data) && d.data[d.pos]>>5 == 3 {
+		return d.item(depth)
+	}
+	start := d.buf.Len()
+	err := d.item(depth)
+	if err != nil {
+		return err
+	}
+	k := string(d.buf.Bytes()[start:])
+	d.buf.Truncate(start)
+	d.writeString(k)
+	return nil
+}
+
+// tag transcodes the data item following a tag.
+func (d *cborDecoder) tag(tag uint64, depth int) error {
+	if tag != 2 && tag != 3 {
+		return d.item(depth + 1)
+	}
+	// bignum
+	major, info, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	if major != 2 {
+		return fmt.Errorf("jsoncolor: invalid CBOR, bignum content is not a byte string")
+	}
+	b, err := d.bytes(major, info, arg)
+	if err != nil {
+		return err
+	}
+	n := new(big.Int).SetBytes(b)
+	if tag == 3 {
+		n.Add(n, big.NewInt(1)).Neg(n)
+	}
+	d.buf.WriteString(n.String())
+	return nil
+}
+
+// simple transcodes a simple value or floating point number.
+func (d *cborDecoder) simple(info byte, arg uint64) error {
+	switch info {
+	case 20:
+		d.buf.WriteString("false")
+	case 21:
+		d.buf.WriteString("true")
+	case 22, 23:
+		d.buf.WriteString("null")
+	case 25:
+		return d.writeFloat(float64(halfToFloat32(uint16(arg))), 32)
+	case 26:
+		return d.writeFloat(float64(math.Float32frombits(uint32(arg))), 32)
+	case 27:
+		return d.writeFloat(math.Float64frombits(arg), 64)
+	case 31:
+		return fmt.Errorf("jsoncolor: invalid CBOR, unexpected break")
+	default:
+		return fmt.Errorf("jsoncolor: unsupported CBOR simple value %d", arg)
+	}
+	return nil
+}
+
+// halfToFloat32 converts the IEEE 754 half-precision number h to a
+// float32.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h>>15) << 31
+	exp := uint32(h>>10) & 0x1f
+	frac := uint32(h) & 0x3ff
+	switch {
+	case exp == 0x1f:
+		// infinity or NaN
+		return math.Float32frombits(sign | 0xff<<23 | frac<<13)
+	case exp == 0 && frac == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// subnormal
+		v := float32(frac) / (1 << 24)
+		if sign != 0 {
+			v = -v
+		}
+		return v
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | frac<<13)
+}
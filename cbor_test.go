@@ -0,0 +1,157 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestFormatCBOR(t *testing.T) {
+	// examples from RFC 8949 Appendix A
+	tests := []struct {
+		hex  string
+		want string
+	}{
+		// unsigned and negative integers
+		{"00", `0`},
+		{"17", `23`},
+		{"1818", `24`},
+		{"1903e8", `1000`},
+		{"1a000f4240", `1000000`},
+		{"1bffffffffffffffff", `18446744073709551615`},
+		{"20", `-1`},
+		{"3863", `-100`},
+		{"3bffffffffffffffff", `-18446744073709551616`},
+		// byte and text strings
+		{"40", `""`},
+		{"4401020304", `"AQIDBA=="`},
+		{"60", `""`},
+		{"6449455446", `"IETF"`},
+		{"62225c", `"\"\\"`},
+		{"63e6b0b4", `"水"`},
+		// arrays and maps
+		{"80", `[]`},
+		{"83010203", `[1,2,3]`},
+		{"8301820203820405", `[1,[2,3],[4,5]]`},
+		{"a0", `{}`},
+		{"a201020304", `{"1":2,"3":4}`},
+		{"a26161016162820203", `{"a":1,"b":[2,3]}`},
+		{"826161a161626163", `["a",{"b":"c"}]`},
+		// indefinite lengths
+		{"5f42010243030405ff", `"AQIDBAU="`},
+		{"7f657374726561646d696e67ff", `"streaming"`},
+		{"9fff", `[]`},
+		{"9f018202039f0405ffff", `[1,[2,3],[4,5]]`},
+		{"83018202039f0405ff", `[1,[2,3],[4,5]]`},
+		{"bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+		{"bf6346756ef563416d7421ff", `{"Fun":true,"Amt":-2}`},
+		// tags
+		{"c074323031332d30332d32315432303a30343a30305a", `"2013-03-21T20:04:00Z"`},
+		{"c11a514b67b0", `1363896240`},
+		{"c249010000000000000000", `18446744073709551616`},
+		{"c349010000000000000000", `-18446744073709551617`},
+		{"d74401020304", `"AQIDBA=="`},
+		// simple values
+		{"f4", `false`},
+		{"f5", `true`},
+		{"f6", `null`},
+		{"f7", `null`},
+		// half, single and double precision floats
+		{"f90000", `0`},
+		{"f98000", `-0`},
+		{"f93c00", `1`},
+		{"f93e00", `1.5`},
+		{"f97bff", `65504`},
+		{"f90001", `5.9604645e-08`},
+		{"f90400", `6.1035156e-05`},
+		{"f9c400", `-4`},
+		{"fa47c35000", `100000`},
+		{"fa7f7fffff", `3.4028235e+38`},
+		{"fb3ff199999999999a", `1.1`},
+		{"fb7e37e43c8800759c", `1e+300`},
+		{"fbc010666666666666", `-4.1`},
+	}
+	for _, tt := range tests {
+		src, err := hex.DecodeString(tt.hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := &bytes.Buffer{}
+		err = (&Formatter{}).FormatCBOR(buf, src)
+		if err != nil {
+			t.Errorf("FormatCBOR(%s): %v", tt.hex, err)
+			continue
+		}
+		if got := uncolored(buf.String()); got != tt.want {
+			t.Errorf("FormatCBOR(%s) = %s, want %s", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCBORNonFinite(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want string
+	}{
+		{"f97c00", `Infinity`},
+		{"f97e00", `NaN`},
+		{"f9fc00", `-Infinity`},
+		{"fa7f800000", `Infinity`},
+		{"fb7ff8000000000000", `NaN`},
+		{"82f97c0001", `[Infinity,1]`},
+	}
+	for _, tt := range tests {
+		src, _ := hex.DecodeString(tt.hex)
+		err := (&Formatter{}).FormatCBOR(&bytes.Buffer{}, src)
+		if err == nil {
+			t.Errorf("FormatCBOR(%s) succeeded", tt.hex)
+		}
+		buf := &bytes.Buffer{}
+		err = (&Formatter{Tolerant: true}).FormatCBOR(buf, src)
+		if err != nil {
+			t.Errorf("FormatCBOR(%s) with Tolerant: %v", tt.hex, err)
+			continue
+		}
+		if got := uncolored(buf.String()); got != tt.want {
+			t.Errorf("FormatCBOR(%s) with Tolerant = %s, want %s", tt.hex, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCBORErrors(t *testing.T) {
+	for _, s := range []string{
+		// truncated input
+		"",
+		"18",
+		"1903",
+		"1bffffffff",
+		"62225c"[:4],
+		"5f4201",
+		"9f01",
+		"83010203"[:6],
+		"a26161016162"[:10],
+		"c2",
+		"f93c",
+		"fb3ff1999999",
+		// malformed input
+		"1c",
+		"1f",
+		"ff",
+		"5f6161ff",
+		"5f5f4101ffff",
+		"c201",
+		"f820",
+		// trailing input
+		"0000",
+		"80ff",
+	} {
+		src, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = (&Formatter{}).FormatCBOR(&bytes.Buffer{}, src)
+		if err == nil {
+			t.Errorf("FormatCBOR(%s) succeeded", s)
+		}
+	}
+}
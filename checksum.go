@@ -0,0 +1,113 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"hash"
+)
+
+// FormatWithChecksum is like Format but returns the colorized form of
+// the JSON-encoded src along with the SHA-256 checksum of the
+// normalized form of src, computed while formatting, for use as a
+// cache key.  The normalized form is the compact encoding of src
+// without insignificant whitespace in which strings are re-encoded
+// as by encoding/json without HTML escaping and numbers are kept
+// as-is, so that documents differing only in whitespace or in how
+// the characters of strings are escaped have the same checksum.
+// The checksum covers all of src even if f's settings omit parts of
+// it from the output.
+func (f *Formatter) FormatWithChecksum(src []byte) ([]byte, [32]byte, error) {
+	var sum [32]byte
+
+	buf := &bytes.Buffer{}
+	fs := newFormatterState(f, buf)
+
+	h := &hashingReader{dec: fs.decoder(src), h: sha256.New()}
+
+	err := fs.formatTokens(h, false)
+	if err != nil {
+		return nil, sum, err
+	}
+
+	copy(sum[:], h.h.Sum(nil))
+	return buf.Bytes(), sum, nil
+}
+
+// hashingReader is a tokenReader writing the normalized compact
+// encoding of the tokens read from dec to h.
+type hashingReader struct {
+	dec tokenReader
+	h   hash.Hash
+
+	// stack holds, for each enclosing container, whether it is
+	// an object and the number of tokens read directly within it.
+	stack []hashingFrame
+}
+
+type hashingFrame struct {
+	object bool
+	n      int
+}
+
+func (r *hashingReader) Token() (json.Token, error) {
+	t, err := r.dec.Token()
+	if err != nil {
+		return t, err
+	}
+
+	if isCloseDelim(t) {
+		r.stack = r.stack[:len(r.stack)-1]
+		r.h.Write([]byte(t.(json.Delim).String()))
+		return t, nil
+	}
+
+	if n := len(r.stack); n > 0 {
+		f := &r.stack[n-1]
+		switch {
+		case f.object && f.n%2 == 1:
+			r.h.Write([]byte{':'})
+		case f.n > 0:
+			r.h.Write([]byte{','})
+		}
+		f.n++
+	}
+
+	switch x := t.(type) {
+	case json.Delim:
+		r.stack = append(r.stack, hashingFrame{object: x == json.Delim('{')})
+		r.h.Write([]byte(x.String()))
+	case string:
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		enc.Encode(x)
+		r.h.Write(bytes.TrimSuffix(b.Bytes(), []byte{'\n'}))
+	case json.Number:
+		r.h.Write([]byte(x))
+	case bool:
+		if x {
+			r.h.Write([]byte("true"))
+		} else {
+			r.h.Write([]byte("false"))
+		}
+	case nil:
+		r.h.Write([]byte("null"))
+	case unparseable:
+		r.h.Write(x)
+	}
+
+	return t, nil
+}
+
+func (r *hashingReader) More() bool {
+	return r.dec.More()
+}
+
+// InputOffset returns the input offset of dec, if it has one.
+func (r *hashingReader) InputOffset() int64 {
+	if x, ok := r.dec.(inputOffsetter); ok {
+		return x.InputOffset()
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestFormatWithChecksum(t *testing.T) {
+	normalized := `{"a":[1,2.50,"<é>"],"b":{"c":null,"d":true,"e":false},"f":{}}`
+	tests := []string{
+		normalized,
+		"{\n  \"a\": [ 1, 2.50, \"<\\u00e9>\" ],\n  \"b\": {\"c\": null, \"d\": true, \"e\": false},\n  \"f\": { }\n}",
+		`{"a":[1,2.50,"\u003c\u00e9\u003e"],"b":{"c":null,"d":true,"e":false},"f":{}}`,
+	}
+	want := sha256.Sum256([]byte(normalized))
+	f := &Formatter{Indent: "\t"}
+	for _, src := range tests {
+		colored, sum, err := f.FormatWithChecksum([]byte(src))
+		if err != nil {
+			t.Fatalf("FormatWithChecksum(%s): %v", src, err)
+		}
+		if sum != want {
+			t.Errorf("FormatWithChecksum(%s) sum = %x, want %x", src, sum, want)
+		}
+		buf := &bytes.Buffer{}
+		if err := f.Format(buf, []byte(src)); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(colored, buf.Bytes()) {
+			t.Errorf("FormatWithChecksum(%s) = %q, want %q", src, colored, buf.Bytes())
+		}
+	}
+
+	// the checksum differs with the numbers' encoding and covers
+	// values omitted from the output
+	_, sum, err := f.FormatWithChecksum([]byte(`{"a":[1,2.5,"<é>"],"b":{"c":null,"d":true,"e":false},"f":{}}`))
+	if err != nil || sum == want {
+		t.Errorf("FormatWithChecksum with 2.5 = %x, %v, want a different sum", sum, err)
+	}
+	f = &Formatter{MaxArrayElements: 1}
+	_, sum, err = f.FormatWithChecksum([]byte(normalized))
+	if err != nil || sum != want {
+		t.Errorf("FormatWithChecksum with MaxArrayElements = %x, %v, want %x", sum, err, want)
+	}
+
+	if _, _, err := f.FormatWithChecksum([]byte(`[1,`)); err == nil {
+		t.Error("FormatWithChecksum of invalid input succeeded")
+	}
+}
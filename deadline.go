@@ -0,0 +1,45 @@
+package jsoncolor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// deadlineInterval is the number of tokens formatted between checks
+// of the deadline of FormatWithDeadline.
+const deadlineInterval = 256
+
+// FormatWithDeadline is like Format but stops formatting and returns
+// an error if formatting src takes longer than d, limiting the time
+// spent on large or hostile input, such as a request body
+// colorized by a server.  The elapsed time is checked periodically
+// while formatting, so formatting may run slightly longer than d.
+// Any output already written to dst when the deadline passes is
+// left in place.
+func (f *Formatter) FormatWithDeadline(dst io.Writer, src []byte, d time.Duration) error {
+	fs := newFormatterState(f, dst)
+	fs.deadline = time.Now().Add(d)
+	err := fs.format(dst, src, false)
+	if err == errDeadlineExceeded {
+		return fmt.Errorf("jsoncolor: formatting exceeded deadline of %v", d)
+	}
+	return err
+}
+
+var errDeadlineExceeded = errors.New("jsoncolor: formatting exceeded deadline")
+
+// checkDeadline returns an error if the deadline of
+// FormatWithDeadline has passed, checking once every
+// deadlineInterval calls.
+func (fs *formatterState) checkDeadline() error {
+	if fs.deadline.IsZero() {
+		return nil
+	}
+	fs.tokens++
+	if fs.tokens%deadlineInterval == 0 && time.Now().After(fs.deadline) {
+		return errDeadlineExceeded
+	}
+	return nil
+}
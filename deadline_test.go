@@ -0,0 +1,35 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatWithDeadline(t *testing.T) {
+	src := "[" + strings.Repeat(`{"a":[1,"x",true]},`, 20000) + "null]"
+	f := &Formatter{Indent: "  "}
+
+	buf := &bytes.Buffer{}
+	err := f.FormatWithDeadline(buf, []byte(src), time.Nanosecond)
+	if err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Fatalf("FormatWithDeadline with a tiny deadline = %v, want deadline error", err)
+	}
+	want := formatString(t, f, src)
+	if buf.Len() == 0 || buf.Len() >= len(want) || !strings.HasPrefix(want, buf.String()) {
+		t.Errorf("FormatWithDeadline wrote %d bytes, want a prefix of the %d byte output", buf.Len(), len(want))
+	}
+
+	buf.Reset()
+	if err := f.FormatWithDeadline(buf, []byte(`{"a":[1,2]}`), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if want := formatString(t, f, `{"a":[1,2]}`); buf.String() != want {
+		t.Errorf("FormatWithDeadline = %q, want %q", buf.String(), want)
+	}
+
+	if err := f.FormatWithDeadline(&bytes.Buffer{}, []byte(`[1,`), time.Minute); err == nil || strings.Contains(err.Error(), "deadline") {
+		t.Errorf("FormatWithDeadline of invalid input = %v, want syntax error", err)
+	}
+}
@@ -0,0 +1,67 @@
+package jsoncolor
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DetectFormat returns the name of the format of the string value s
+// if it is one of UUID, such as 123e4567-e89b-12d3-a456-426614174000,
+// RFC3339, such as 2024-01-01T00:00:00Z, URL, such as
+// https://example.com/, which must be absolute and have a host, or
+// base64, which must be at least 16 characters long and contain a
+// digit, '+', '/' or '=' so that long words are not mistaken for it,
+// or else the empty string.
+func DetectFormat(s string) string {
+	switch {
+	case isUUID(s):
+		return "UUID"
+	case isRFC3339(s):
+		return "RFC3339"
+	case isURL(s):
+		return "URL"
+	case isBase64(s):
+		return "base64"
+	}
+	return ""
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isRFC3339(s string) bool {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && len(u.Scheme) > 0 && len(u.Host) > 0
+}
+
+func isBase64(s string) bool {
+	if len(s) < 16 || len(s)%4 != 0 || !strings.ContainsAny(s, "0123456789+/=") {
+		return false
+	}
+	_, err := base64.StdEncoding.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,103 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// FormatDiffANSI is like Format but colorizes cur relative to the
+// previously formatted document prev, so that interactive viewers
+// can identify which parts of a redrawn document changed.  Scalar
+// values whose JSON Pointer in cur does not exist in prev or refers
+// to a different value, as well as the names of fields not present
+// in prev, use ChangedColor.  All other tokens use their usual
+// colors.
+func (f *Formatter) FormatDiffANSI(dst io.Writer, prev, cur []byte) error {
+	fs, err := f.diffState(dst, prev)
+	if err != nil {
+		return err
+	}
+	return fs.format(dst, cur, false)
+}
+
+// diffState returns a formatterState writing to dst the colorized
+// form of a document relative to prev, see FormatDiffANSI.
+func (f *Formatter) diffState(dst io.Writer, prev []byte) (*formatterState, error) {
+	values, err := leafValues(prev)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := f.changedColor()
+
+	fs := newFormatterState(f, dst)
+	fs.colorValue = func(path []string, t json.Token, field bool) SprintfFuncer {
+		v, ok := values[formatPointer(path)]
+		if field {
+			if ok {
+				return nil
+			}
+		} else if ok && v == leafValue(t) {
+			return nil
+		}
+		return changed
+	}
+
+	return fs, nil
+}
+
+// leafValues decodes src and returns a map from the JSON Pointer of
+// each value it contains to the value's leafValue.
+func leafValues(src []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+
+	var walk func(path []string, v interface{})
+	walk = func(path []string, v interface{}) {
+		values[formatPointer(path)] = leafValue(v)
+		switch x := v.(type) {
+		case map[string]interface{}:
+			for k, e := range x {
+				walk(append(path[:len(path):len(path)], k), e)
+			}
+		case []interface{}:
+			for i, e := range x {
+				walk(append(path[:len(path):len(path)], strconv.Itoa(i)), e)
+			}
+		}
+	}
+	walk(nil, v)
+
+	return values, nil
+}
+
+// leafValue returns a string identifying the scalar value v, or the
+// kind of container if v is a decoded object or array or the
+// opening delimiter of one.
+func leafValue(v interface{}) string {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return "{"
+	case []interface{}:
+		return "["
+	case json.Delim:
+		return x.String()
+	case string:
+		return strconv.Quote(x)
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprint(x)
+	}
+}
@@ -0,0 +1,41 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatDiffANSI(t *testing.T) {
+	prev := `{"a":1,"b":[1,2],"c":{"d":"x"},"e":null}`
+	tests := []struct {
+		cur  string
+		want string
+	}{
+		{prev, ``},
+		{`{"a":1,"b":[1,3],"c":{"d":"x"},"e":null}`, `3`},
+		{`{"a":1,"b":[1,2,false],"c":{"d":"y","f":0},"e":null}`, `false"y""f"0`},
+		{`{"a":"1","b":[1,2],"c":{"d":"x"},"e":{}}`, `"1"`},
+		{`[1]`, `1`},
+	}
+	f := &Formatter{ChangedColor: color.New(color.FgMagenta)}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		err := f.FormatDiffANSI(buf, []byte(prev), []byte(tt.cur))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := uncolored(buf.String()); got != tt.cur {
+			t.Errorf("FormatDiffANSI(%s) = %q, want %q", tt.cur, got, tt.cur)
+		}
+		if got := colored(buf.String(), "35"); got != tt.want {
+			t.Errorf("FormatDiffANSI(%s) highlights %q, want %q", tt.cur, got, tt.want)
+		}
+	}
+
+	err := f.FormatDiffANSI(&bytes.Buffer{}, []byte(`{`), []byte(prev))
+	if err == nil {
+		t.Error("FormatDiffANSI with invalid previous document succeeded")
+	}
+}
@@ -0,0 +1,63 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// maxEmbeddedDepth is the maximum number of levels of JSON embedded
+// in string values unwrapped by UnwrapEmbeddedJSON.
+const maxEmbeddedDepth = 8
+
+// unwrapEmbedded returns the opening delimiter of the object or array
+// encoded by t if t is a string value containing one, unreading its
+// remaining tokens into r, otherwise it returns t.  The returned bool
+// reports whether t was unwrapped.
+func (fs *formatterState) unwrapEmbedded(r *replayReader, frame *frame, t json.Token) (json.Token, bool) {
+	s, ok := t.(string)
+	if !ok || frame.inField() {
+		return t, false
+	}
+	depth := 0
+	for _, f := range fs.frames {
+		if f.embedded {
+			depth++
+		}
+	}
+	if depth >= maxEmbeddedDepth {
+		return t, false
+	}
+	tokens := embeddedTokens(s)
+	if tokens == nil {
+		return t, false
+	}
+	offsets := make([]int64, len(tokens)-1)
+	for i := range offsets {
+		offsets[i] = r.offset
+	}
+	r.unread(tokens[1:], offsets)
+	return tokens[0], true
+}
+
+// embeddedTokens returns the tokens of the object or array encoded
+// by s, or nil if s does not encode one.
+func embeddedTokens(s string) []json.Token {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 || s[0] != '{' && s[0] != '[' || !json.Valid([]byte(s)) {
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var tokens []json.Token
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return tokens
+		}
+		if err != nil {
+			return nil
+		}
+		tokens = append(tokens, t)
+	}
+}
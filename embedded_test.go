@@ -0,0 +1,64 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestUnwrapEmbeddedJSON(t *testing.T) {
+	src := `{"a":"{\"b\":[1,\"[true]\"]}","{\"c\":1}":" [] ","d":"[1,","e":"1"}`
+	tests := []struct {
+		f         *Formatter
+		src, want string
+	}{
+		{&Formatter{}, src, src},
+		// field names, invalid JSON and scalars are unchanged
+		{&Formatter{UnwrapEmbeddedJSON: true}, src,
+			`{"a":embedded {"b":[1,embedded [true]]},"{\"c\":1}":embedded [],"d":"[1,","e":"1"}`},
+		{&Formatter{UnwrapEmbeddedJSON: true, Indent: " "}, src,
+			"{\n \"a\": embedded {\n  \"b\": [\n   1,\n   embedded [\n    true\n   ]\n  ]\n },\n \"{\\\"c\\\":1}\": embedded [],\n \"d\": \"[1,\",\n \"e\": \"1\"\n}"},
+		{&Formatter{UnwrapEmbeddedJSON: true, Indent: " "}, `"{\"x\":null}"`, "embedded {\n \"x\": null\n}"},
+	}
+	for _, tt := range tests {
+		if got := uncolored(formatString(t, tt.f, tt.src)); got != tt.want {
+			t.Errorf("Format(%s) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	f := &Formatter{UnwrapEmbeddedJSON: true, EmbeddedColor: color.New(color.FgMagenta), TrueColor: color.New(color.FgYellow)}
+	got := formatString(t, f, src)
+	if s := colored(got, "35"); s != strings.Repeat("embedded ", 3) {
+		t.Errorf("Format(%s) colors %q with EmbeddedColor, want the markers", src, s)
+	}
+	// unwrapped values are colored as values of their own
+	if s := colored(got, "33"); s != "true" {
+		t.Errorf("Format(%s) colors %q as true, want %q", src, s, "true")
+	}
+}
+
+func TestUnwrapEmbeddedJSONDepth(t *testing.T) {
+	// each level encodes an array holding the next
+	v := interface{}([]interface{}{})
+	for i := 0; i < maxEmbeddedDepth+2; i++ {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v = []interface{}{string(b)}
+	}
+	src, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := uncolored(formatString(t, &Formatter{UnwrapEmbeddedJSON: true}, string(src)))
+	if n := strings.Count(got, "embedded"); n != maxEmbeddedDepth {
+		t.Errorf("Format unwrapped %d levels of %s, want %d", n, src, maxEmbeddedDepth)
+	}
+	// the innermost levels are left as strings
+	if i := strings.LastIndex(got, "embedded ["); !strings.HasPrefix(got[i:], `embedded ["[\"[]\"]"]`) {
+		t.Errorf("Format(%s) = %s", src, got)
+	}
+}
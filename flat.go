@@ -0,0 +1,390 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FormatFlat appends to dst a colorized flattened form of the
+// JSON-encoded src in the style of gron, with one line per leaf
+// value such as a.b[3].c = "value", where the leaves are the scalar
+// values and the empty objects and arrays.  Field names are
+// displayed after a dot '.' colored with ObjectColor if they are
+// identifiers, such as a, _id or $ref, and otherwise as quoted
+// strings in brackets, such as ["content-type"], while array
+// indices are displayed in brackets colored with ArrayColor.  A
+// scalar outermost value is displayed as = value.  The lines are
+// parsed back into JSON by UnflattenFlat.  f's Prefix and Indent
+// fields are ignored.
+func (f *Formatter) FormatFlat(dst io.Writer, src []byte) error {
+	g := f.dataOnly()
+	g.setIndent("", "")
+	g.FocusPath = ""
+
+	fs := newFormatterState(g, dst)
+	defer fs.closeColor()
+	if g.MaxLines > 0 {
+		defer fs.bufferLines()()
+	}
+	dec := fs.decoder(src)
+
+	token := func() (json.Token, error) {
+		t, err := dec.Token()
+		if err == nil {
+			err = fs.checkDeadline()
+		}
+		return t, err
+	}
+
+	lines := 0
+
+	// leaf prints the line of the scalar or empty container made
+	// up of tokens, within the frames of the containers enclosing
+	// it.
+	leaf := func(tokens ...json.Token) error {
+		if lines > 0 {
+			fs.printSpace("\n", true)
+		}
+		lines++
+		for i, frame := range fs.frames[1:] {
+			if frame.array {
+				fs.print(TokenArray, "[")
+				fs.print(TokenNumber, frame.segment())
+				fs.print(TokenArray, "]")
+				continue
+			}
+			if isIdentifier(frame.key) {
+				if i > 0 {
+					fs.print(TokenObject, ".")
+				}
+				fs.print(TokenField, frame.key)
+				continue
+			}
+			b, _ := json.Marshal(frame.key)
+			fs.print(TokenArray, "[")
+			fs.print(TokenFieldQuote, `"`)
+			fs.print(TokenField, string(b[1:len(b)-1]))
+			fs.print(TokenFieldQuote, `"`)
+			fs.print(TokenArray, "]")
+		}
+		if len(fs.frames) > 1 {
+			fs.printSpace(" ", true)
+		}
+		fs.print(TokenColon, "=")
+		fs.printSpace(" ", true)
+		return fs.printLeaf(tokens...)
+	}
+
+	var walk func(t json.Token) error
+	walk = func(t json.Token) error {
+		open, ok := t.(json.Delim)
+		if !ok {
+			return leaf(t)
+		}
+		if !dec.More() {
+			close, err := token()
+			if err != nil {
+				return err
+			}
+			return leaf(open, close)
+		}
+		frame := fs.enterFrame(open, false)
+		for dec.More() {
+			t, err := token()
+			if err != nil {
+				return err
+			}
+			frame.index++
+			if frame.object {
+				frame.key = objectKey(t)
+				t, err = token()
+				if err != nil {
+					return err
+				}
+			}
+			err = walk(t)
+			if err != nil {
+				return err
+			}
+		}
+		fs.leaveFrame()
+		_, err := token()
+		return err
+	}
+
+	for {
+		t, err := token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = walk(t)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// printLeaf prints the scalar or empty container made up of tokens
+// as formatTokens prints a value within the current frame, for
+// Format methods that lay out the values of a document themselves.
+func (fs *formatterState) printLeaf(tokens ...json.Token) error {
+	if fs.f.ValueBadges {
+		fs.printBadge(tokens[0])
+	}
+	if len(tokens) == 1 {
+		fs.valueColor = fs.valueColorFor(fs.frame(), tokens[0])
+		defer func() { fs.valueColor = nil }()
+	}
+	for _, t := range tokens {
+		err := fs.formatToken(t)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// objectKey returns the field name t, which may be an unparseable
+// token.
+func objectKey(t json.Token) string {
+	if x, ok := t.(unparseable); ok {
+		return string(x)
+	}
+	s, _ := t.(string)
+	return s
+}
+
+// isIdentifier reports whether the field name s is displayed by
+// FormatFlat after a dot, which it is if it is made up of ASCII
+// letters, digits, '_' and '$' and does not begin with a digit.
+func isIdentifier(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == '$':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// UnflattenFlat returns the compact JSON encoding of the value whose
+// flattened form, as written by FormatFlat, is src, ignoring any ANSI
+// escape sequences coloring it, so that the flattened form can be
+// edited and turned back into JSON.  Fields and array elements appear
+// in the order in which their first lines do, and elements missing
+// from arrays, such as a[1] in a document giving only a[0] and a[2],
+// are null.  Blank lines are ignored.
+func UnflattenFlat(src []byte) ([]byte, error) {
+	var root *flatNode
+	for i, line := range strings.Split(stripANSI(string(src)), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		err := unflattenLine(&root, line)
+		if err != nil {
+			return nil, fmt.Errorf("jsoncolor: invalid flat form on line %d, %v", i+1, err)
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("jsoncolor: invalid flat form, no values")
+	}
+	buf := &bytes.Buffer{}
+	root.encode(buf)
+	return buf.Bytes(), nil
+}
+
+// flatNode is a value being assembled by UnflattenFlat.  Objects and
+// arrays have kind '{' or '[' and the values of their fields or
+// elements in elems, with the field names of objects in keys and
+// their indices in elems in index.  Other values have kind zero and
+// their JSON encoding raw.
+type flatNode struct {
+	kind  byte
+	keys  []string
+	index map[string]int
+	elems []*flatNode
+	raw   json.RawMessage
+}
+
+// unflattenLine adds the value given by line to the value root.
+func unflattenLine(root **flatNode, line string) error {
+	path, value, err := parseFlatLine(line)
+	if err != nil {
+		return err
+	}
+
+	p := root
+	for _, segment := range path {
+		kind := byte('{')
+		if _, ok := segment.(int); ok {
+			kind = '['
+		}
+		if *p == nil {
+			*p = &flatNode{kind: kind, index: map[string]int{}}
+		}
+		n := *p
+		if n.kind != kind {
+			return fmt.Errorf("value conflicts with a previous line")
+		}
+		switch x := segment.(type) {
+		case int:
+			for len(n.elems) <= x {
+				n.elems = append(n.elems, nil)
+			}
+			p = &n.elems[x]
+		case string:
+			j, ok := n.index[x]
+			if !ok {
+				j = len(n.elems)
+				n.keys = append(n.keys, x)
+				n.index[x] = j
+				n.elems = append(n.elems, nil)
+			}
+			p = &n.elems[j]
+		}
+	}
+
+	var leaf *flatNode
+	switch string(value) {
+	case "{}":
+		leaf = &flatNode{kind: '{', index: map[string]int{}}
+	case "[]":
+		leaf = &flatNode{kind: '['}
+	default:
+		leaf = &flatNode{raw: value}
+	}
+	switch {
+	case *p == nil:
+		*p = leaf
+	case (*p).kind == 0 || (*p).kind != leaf.kind:
+		return fmt.Errorf("value conflicts with a previous line")
+	}
+	return nil
+}
+
+// parseFlatLine parses a line of the flattened form written by
+// FormatFlat into the path of its value, made up of field names and
+// array indices, and the compact encoding of the value.
+func parseFlatLine(line string) ([]json.Token, json.RawMessage, error) {
+	var path []json.Token
+	i := 0
+	for i < len(line) && line[i] != ' ' && line[i] != '=' {
+		switch c := line[i]; {
+		case c == '[' && i+1 < len(line) && line[i+1] == '"':
+			end := i + 2
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			var k string
+			if end >= len(line) || json.Unmarshal([]byte(line[i+1:end+1]), &k) != nil {
+				return nil, nil, fmt.Errorf("invalid field name at offset %d", i)
+			}
+			if end+1 >= len(line) || line[end+1] != ']' {
+				return nil, nil, fmt.Errorf("expected ] at offset %d", end+1)
+			}
+			path = append(path, k)
+			i = end + 2
+		case c == '[':
+			end := strings.IndexByte(line[i:], ']')
+			if end < 0 {
+				return nil, nil, fmt.Errorf("expected ] after offset %d", i)
+			}
+			end += i
+			n, err := strconv.Atoi(line[i+1 : end])
+			if err != nil || n < 0 {
+				return nil, nil, fmt.Errorf("invalid array index at offset %d", i)
+			}
+			path = append(path, n)
+			i = end + 1
+		case c == '.' || i == 0:
+			if c == '.' {
+				i++
+			}
+			end := i
+			for end < len(line) && isIdentifier(line[i:end+1]) {
+				end++
+			}
+			if end == i {
+				return nil, nil, fmt.Errorf("invalid field name at offset %d", i)
+			}
+			path = append(path, line[i:end])
+			i = end
+		default:
+			return nil, nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+		}
+	}
+
+	rest := strings.TrimLeft(line[i:], " ")
+	if !strings.HasPrefix(rest, "=") {
+		return nil, nil, fmt.Errorf("expected =")
+	}
+	value := bytes.TrimSpace([]byte(rest[1:]))
+	if !json.Valid(value) {
+		return nil, nil, fmt.Errorf("invalid value %q", value)
+	}
+	buf := &bytes.Buffer{}
+	json.Compact(buf, value)
+	return path, buf.Bytes(), nil
+}
+
+// encode writes the compact JSON encoding of n to buf.
+func (n *flatNode) encode(buf *bytes.Buffer) {
+	switch {
+	case n == nil:
+		buf.WriteString("null")
+	case n.kind == '{':
+		buf.WriteByte('{')
+		for i, k := range n.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			b, _ := json.Marshal(k)
+			buf.Write(b)
+			buf.WriteByte(':')
+			n.elems[i].encode(buf)
+		}
+		buf.WriteByte('}')
+	case n.kind == '[':
+		buf.WriteByte('[')
+		for i, e := range n.elems {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			e.encode(buf)
+		}
+		buf.WriteByte(']')
+	default:
+		buf.Write(n.raw)
+	}
+}
+
+// stripANSI returns s without any ANSI escape sequences.
+func stripANSI(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if n := ansiLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
@@ -0,0 +1,170 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatFlat(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`"x"`, `= "x"`},
+		{`{}`, `= {}`},
+		{`{"a":1}`, `a = 1`},
+		{`{"a":{"b":[1,{"c":"d"}]}}`, "a.b[0] = 1\na.b[1].c = \"d\""},
+		{`[[],{},[null]]`, "[0] = []\n[1] = {}\n[2][0] = null"},
+		{`{"content-type":"json","_id":1,"$ref":true,"1a":false}`,
+			"[\"content-type\"] = \"json\"\n_id = 1\n$ref = true\n[\"1a\"] = false"},
+		{`{"a\"b":{"":0}}`, "[\"a\\\"b\"][\"\"] = 0"},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		err := (&Formatter{Indent: "  "}).FormatFlat(buf, []byte(tt.src))
+		if err != nil {
+			t.Fatalf("FormatFlat(%s): %v", tt.src, err)
+		}
+		if got := uncolored(buf.String()); got != tt.want {
+			t.Errorf("FormatFlat(%s) = %q, want %q", tt.src, got, tt.want)
+		}
+
+		b, err := UnflattenFlat(buf.Bytes())
+		if err != nil {
+			t.Fatalf("UnflattenFlat(%q): %v", buf.String(), err)
+		}
+		var want bytes.Buffer
+		json.Compact(&want, []byte(tt.src))
+		if string(b) != want.String() {
+			t.Errorf("UnflattenFlat(FormatFlat(%s)) = %s", tt.src, b)
+		}
+	}
+
+	for _, src := range []string{`[1,`, `{"a":}`, `[1] 2]`} {
+		if err := (&Formatter{}).FormatFlat(&bytes.Buffer{}, []byte(src)); err == nil {
+			t.Errorf("FormatFlat(%s) succeeded, want error", src)
+		}
+	}
+}
+
+func TestFormatFlatOutput(t *testing.T) {
+	src := []byte(`{"a":{"b":[1,"x"]},"c":null}`)
+
+	buf := &bytes.Buffer{}
+	err := (&Formatter{LeftMargin: 2}).FormatFlat(buf, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := uncolored(buf.String()), "  a.b[0] = 1\n  a.b[1] = \"x\"\n  c = null"; got != want {
+		t.Errorf("FormatFlat with LeftMargin = %q, want %q", got, want)
+	}
+	if _, active := render(buf.String()); active != "" {
+		t.Errorf("FormatFlat leaves %q in effect", active)
+	}
+
+	// the segments of paths are colored by their type
+	buf.Reset()
+	f := &Formatter{
+		FieldColor:  color.New(color.FgBlue),
+		ObjectColor: color.New(color.FgCyan),
+		ArrayColor:  color.New(color.FgMagenta),
+		NumberColor: color.New(color.FgRed),
+	}
+	err = f.FormatFlat(buf, []byte(`{"a":{"b":{"c-d":[1]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for params, want := range map[string]string{"34": "abc-d", "36": ".", "35": "[][]", "31": "01"} {
+		if s := colored(buf.String(), params); s != want {
+			t.Errorf("FormatFlat colors %q with %s, want %q", s, params, want)
+		}
+	}
+
+	// MaxLines applies to the output as a whole
+	buf.Reset()
+	err = (&Formatter{MaxLines: 3}).FormatFlat(buf, []byte(`[1,2,3,4,5,6]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := uncolored(buf.String()), "[0] = 1\n… (4 lines hidden) …\n[5] = 6"; got != want {
+		t.Errorf("FormatFlat with MaxLines = %q, want %q", got, want)
+	}
+
+	// only the outermost value is colored by RootContainerColor
+	f = &Formatter{RootContainerColor: color.New(color.FgMagenta)}
+	for src, want := range map[string]string{`[[],{"a":{}}]`: "", `{}`: "{}"} {
+		buf.Reset()
+		err = f.FormatFlat(buf, []byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := colored(buf.String(), "35"); s != want {
+			t.Errorf("FormatFlat(%s) colors %q with RootContainerColor, want %q", src, s, want)
+		}
+	}
+
+	// MaxColors also applies to the colors of paths
+	buf.Reset()
+	f = &Formatter{MaxColors: 1, StringColor: color.New(color.FgRed), NumberColor: color.New(color.FgHiRed)}
+	err = f.FormatFlat(buf, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	colors := map[ColorSpec]bool{}
+	cells, _ := render(buf.String())
+	for _, c := range cells {
+		if c.spec != (ColorSpec{}) && !strings.ContainsRune(" \n", rune(c.b)) {
+			colors[c.spec] = true
+		}
+	}
+	if len(colors) > 1 {
+		t.Errorf("FormatFlat with MaxColors 1 uses colors %v", colors)
+	}
+}
+
+func TestUnflattenFlat(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"= 1", `1`},
+		{"a[2] = true\n\na[0] = \"x\"\r\n", `{"a":["x",null,true]}`},
+		{"b.c = 1\na = 2\nb.d = []", `{"b":{"c":1,"d":[]},"a":2}`},
+		{"\x1b[1ma\x1b[0m \x1b[1m=\x1b[0m \x1b[32m\"é\"\x1b[0m", `{"a":"é"}`},
+		{"[\"a.b\"] = 1", `{"a.b":1}`},
+		{"a = { \"b\" : [ 1 ] }", `{"a":{"b":[1]}}`},
+	}
+	for _, tt := range tests {
+		b, err := UnflattenFlat([]byte(tt.src))
+		if err != nil {
+			t.Errorf("UnflattenFlat(%q): %v", tt.src, err)
+			continue
+		}
+		if string(b) != tt.want {
+			t.Errorf("UnflattenFlat(%q) = %s, want %s", tt.src, b, tt.want)
+		}
+	}
+
+	for _, src := range []string{"", "a", "a = ", "a = {", "a = 1\na.b = 2", "[0] = 1\na = 2", "a = 1\na = 2", "a[-1] = 1", "[\"a] = 1"} {
+		if b, err := UnflattenFlat([]byte(src)); err == nil {
+			t.Errorf("UnflattenFlat(%q) = %s, want error", src, b)
+		}
+	}
+
+	// the colorized output of a document round trips
+	buf := &bytes.Buffer{}
+	if err := (&Formatter{}).FormatFlat(buf, []byte(sample)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := UnflattenFlat(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != sample {
+		t.Errorf("UnflattenFlat(FormatFlat(%s)) = %s", sample, b)
+	}
+}
@@ -2,4 +2,8 @@ module github.com/nwidger/jsoncolor
 
 go 1.13
 
-require github.com/fatih/color v1.9.0
+require (
+	github.com/fatih/color v1.9.0
+	github.com/mattn/go-isatty v0.0.11
+	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037
+)
@@ -0,0 +1,553 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FormatGoValue is like Format but colorizes the JSON encoding of
+// the Go value v, as returned by json.Marshal, without first encoding
+// it to bytes.  Values are encoded following the rules of
+// json.Marshal, including its handling of json.Marshaler and
+// encoding.TextMarshaler values and of struct field tags, except
+// that the HTML escaping of strings depends on f's EscapeHTML field.
+// As with json.Marshal, the fields of structs are displayed in the
+// order they are declared, with the fields of embedded structs in
+// place of the embedded struct, while the entries of maps are
+// sorted by key.
+// Scalar values whose Go type has a color in f's TypeColors field,
+// including those encoded by a MarshalJSON or MarshalText method,
+// such as a time.Time, use that color.  Other scalar values whose Go
+// type implements fmt.Stringer or encoding.TextMarshaler use f's
+// StringerColor field, if it is non-nil.
+func (f *Formatter) FormatGoValue(dst io.Writer, v interface{}) error {
+	e := &goValueEncoder{
+		typeColors:     f.TypeColors,
+		stringerColor:  f.StringerColor,
+		colors:         map[string]SprintfFuncer{},
+		summarizeBytes: f.SummarizeByteSlices,
+	}
+	err := e.value(reflect.ValueOf(v), 0)
+	if err != nil {
+		return err
+	}
+
+	fs := newFormatterState(f, dst)
+	if len(e.colors) > 0 {
+		fs.colorValue = func(path []string, t json.Token, field bool) SprintfFuncer {
+			if field {
+				return nil
+			}
+			return e.colors[formatPointer(path)]
+		}
+	}
+
+	tokens := tokenSlice(e.tokens)
+	return fs.formatTokens(&tokens, false)
+}
+
+// tokenSlice is a tokenReader returning the tokens of a slice.
+type tokenSlice []json.Token
+
+func (s *tokenSlice) Token() (json.Token, error) {
+	if len(*s) == 0 {
+		return nil, io.EOF
+	}
+	t := (*s)[0]
+	*s = (*s)[1:]
+	return t, nil
+}
+
+func (s *tokenSlice) More() bool {
+	return len(*s) > 0 && !isCloseDelim((*s)[0])
+}
+
+// byteSliceSummary is a token replacing a byte slice of the given
+// length, see SummarizeByteSlices.
+type byteSliceSummary int
+
+func (n byteSliceSummary) String() string {
+	return fmt.Sprintf("bytes(len=%d)", int(n))
+}
+
+// maxSummarizedByteSliceLength is the length of the longest byte
+// slice not summarized, see SummarizeByteSlices.
+const maxSummarizedByteSliceLength = 64
+
+// maxGoValueDepth is the maximum nesting depth of Go values accepted
+// by FormatGoValue, which guards against cyclic values.
+const maxGoValueDepth = 10000
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	numberType        = reflect.TypeOf(json.Number(""))
+)
+
+// goValueEncoder encodes Go values as a sequence of JSON tokens.
+type goValueEncoder struct {
+	tokens []json.Token
+	path   []string
+
+	// colors maps the JSON Pointers of values whose Go type has a
+	// color in typeColors, or which implement fmt.Stringer or
+	// encoding.TextMarshaler if stringerColor is non-nil, to the
+	// color.
+	typeColors    map[reflect.Type]SprintfFuncer
+	stringerColor SprintfFuncer
+	colors        map[string]SprintfFuncer
+
+	// summarizeBytes specifies whether long byte slices are
+	// replaced by a byteSliceSummary, see SummarizeByteSlices.
+	summarizeBytes bool
+}
+
+// value encodes v at the given nesting depth.
+func (e *goValueEncoder) value(v reflect.Value, depth int) error {
+	if depth > maxGoValueDepth {
+		return fmt.Errorf("jsoncolor: exceeded max depth encoding Go value, it may be cyclic")
+	}
+	if !v.IsValid() {
+		e.tokens = append(e.tokens, nil)
+		return nil
+	}
+	if c, ok := e.typeColors[v.Type()]; ok {
+		e.colors[formatPointer(e.path)] = c
+	} else if e.stringerColor != nil && isStringer(v) {
+		defer e.colorScalar(formatPointer(e.path), len(e.tokens), e.stringerColor)
+	}
+
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		e.tokens = append(e.tokens, nil)
+		return nil
+	}
+	if m, ok := marshaler(v, marshalerType); ok {
+		return e.marshalJSON(m.(json.Marshaler))
+	}
+	if m, ok := marshaler(v, textMarshalerType); ok {
+		b, err := m.(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		e.tokens = append(e.tokens, string(b))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		e.tokens = append(e.tokens, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.tokens = append(e.tokens, json.Number(strconv.FormatInt(v.Int(), 10)))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.tokens = append(e.tokens, json.Number(strconv.FormatUint(v.Uint(), 10)))
+	case reflect.Float32, reflect.Float64:
+		n, err := goFloat(v.Float(), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		e.tokens = append(e.tokens, n)
+	case reflect.String:
+		if v.Type() == numberType {
+			n := v.String()
+			if len(n) == 0 {
+				n = "0"
+			}
+			e.tokens = append(e.tokens, json.Number(n))
+		} else {
+			e.tokens = append(e.tokens, v.String())
+		}
+	case reflect.Interface, reflect.Ptr:
+		return e.value(v.Elem(), depth+1)
+	case reflect.Slice:
+		if v.IsNil() {
+			e.tokens = append(e.tokens, nil)
+			return nil
+		}
+		if elem := v.Type().Elem(); elem.Kind() == reflect.Uint8 &&
+			!reflect.PtrTo(elem).Implements(marshalerType) && !reflect.PtrTo(elem).Implements(textMarshalerType) {
+			if e.summarizeBytes && v.Len() > maxSummarizedByteSliceLength {
+				e.tokens = append(e.tokens, byteSliceSummary(v.Len()))
+				return nil
+			}
+			e.tokens = append(e.tokens, base64.StdEncoding.EncodeToString(v.Bytes()))
+			return nil
+		}
+		return e.array(v, depth)
+	case reflect.Array:
+		return e.array(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			e.tokens = append(e.tokens, nil)
+			return nil
+		}
+		return e.mapValue(v, depth)
+	case reflect.Struct:
+		return e.structValue(v, depth)
+	default:
+		return fmt.Errorf("jsoncolor: unsupported Go type %s", v.Type())
+	}
+	return nil
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// isStringer reports whether v, or a pointer to it if v is
+// addressable, implements fmt.Stringer or encoding.TextMarshaler.
+func isStringer(v reflect.Value) bool {
+	if _, ok := marshaler(v, stringerType); ok {
+		return true
+	}
+	_, ok := marshaler(v, textMarshalerType)
+	return ok
+}
+
+// colorScalar colors the value with JSON Pointer p whose encoding
+// begins with the token at index start with c, if the value is a
+// string, number or boolean.
+func (e *goValueEncoder) colorScalar(p string, start int, c SprintfFuncer) {
+	if len(e.tokens) != start+1 {
+		return
+	}
+	switch e.tokens[start].(type) {
+	case string, json.Number, bool:
+		e.colors[p] = c
+	}
+}
+
+// marshaler returns v, or a pointer to it if v is addressable, as a
+// value implementing the interface t, if either does.
+func marshaler(v reflect.Value, t reflect.Type) (interface{}, bool) {
+	if v.Type().Implements(t) && v.CanInterface() {
+		return v.Interface(), true
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(t) && v.Addr().CanInterface() {
+		return v.Addr().Interface(), true
+	}
+	return nil, false
+}
+
+// marshalJSON encodes the JSON returned by m's MarshalJSON method.
+func (e *goValueEncoder) marshalJSON(m json.Marshaler) error {
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("jsoncolor: invalid JSON from MarshalJSON of %T: %v", m, err)
+		}
+		e.tokens = append(e.tokens, t)
+	}
+}
+
+// goFloat returns the number with the encoding json.Marshal gives
+// the floating point number v of the given bit size.
+func goFloat(v float64, bits int) (json.Number, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "", fmt.Errorf("jsoncolor: unsupported Go value %v", v)
+	}
+	format := byte('f')
+	if abs := math.Abs(v); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b := strconv.AppendFloat(nil, v, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return json.Number(b), nil
+}
+
+// array encodes the elements of the slice or array v.
+func (e *goValueEncoder) array(v reflect.Value, depth int) error {
+	e.tokens = append(e.tokens, json.Delim('['))
+	for i := 0; i < v.Len(); i++ {
+		e.path = append(e.path, strconv.Itoa(i))
+		err := e.value(v.Index(i), depth+1)
+		e.path = e.path[:len(e.path)-1]
+		if err != nil {
+			return err
+		}
+	}
+	e.tokens = append(e.tokens, json.Delim(']'))
+	return nil
+}
+
+// mapValue encodes the entries of the map v sorted by key, as with
+// json.Marshal.
+func (e *goValueEncoder) mapValue(v reflect.Value, depth int) error {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+
+	var entries []entry
+	iter := v.MapRange()
+	for iter.Next() {
+		k, err := goMapKey(iter.Key())
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{k, iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	e.tokens = append(e.tokens, json.Delim('{'))
+	for _, en := range entries {
+		e.tokens = append(e.tokens, en.key)
+		e.path = append(e.path, en.key)
+		err := e.value(en.value, depth+1)
+		e.path = e.path[:len(e.path)-1]
+		if err != nil {
+			return err
+		}
+	}
+	e.tokens = append(e.tokens, json.Delim('}'))
+	return nil
+}
+
+// goMapKey returns the field name encoding the map key k.
+func goMapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if m, ok := marshaler(k, textMarshalerType); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		b, err := m.(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("jsoncolor: unsupported Go map key type %s", k.Type())
+}
+
+// structValue encodes the fields of the struct v.
+func (e *goValueEncoder) structValue(v reflect.Value, depth int) error {
+	e.tokens = append(e.tokens, json.Delim('{'))
+fields:
+	for _, f := range goStructFields(v.Type()) {
+		fv := v
+		for _, i := range f.index {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue fields
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(i)
+		}
+		if f.omitEmpty && isEmptyGoValue(fv) {
+			continue
+		}
+
+		e.tokens = append(e.tokens, f.name)
+		e.path = append(e.path, f.name)
+		var err error
+		if f.quoted && isQuotableGoValue(fv) {
+			err = e.quoted(fv, depth+1)
+		} else {
+			err = e.value(fv, depth+1)
+		}
+		e.path = e.path[:len(e.path)-1]
+		if err != nil {
+			return err
+		}
+	}
+	e.tokens = append(e.tokens, json.Delim('}'))
+	return nil
+}
+
+// quoted encodes the scalar v as a string containing its JSON
+// encoding, for fields with the ",string" tag option.
+func (e *goValueEncoder) quoted(v reflect.Value, depth int) error {
+	err := e.value(v, depth)
+	if err != nil {
+		return err
+	}
+	n := len(e.tokens) - 1
+	switch x := e.tokens[n].(type) {
+	case string:
+		b, _ := json.Marshal(x)
+		e.tokens[n] = string(b)
+	case json.Number:
+		e.tokens[n] = string(x)
+	case bool:
+		e.tokens[n] = strconv.FormatBool(x)
+	}
+	return nil
+}
+
+// isQuotableGoValue reports whether the ",string" tag option applies
+// to v.
+func isQuotableGoValue(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isEmptyGoValue reports whether v is empty for the purposes of the
+// ",omitempty" tag option.
+func isEmptyGoValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// goStructField is a field of a struct encoded by json.Marshal.
+type goStructField struct {
+	name      string
+	index     []int
+	tagged    bool
+	omitEmpty bool
+	quoted    bool
+}
+
+// goStructFields returns the fields of the struct type t encoded by
+// json.Marshal, including those promoted from embedded structs, in
+// the order json.Marshal encodes them.
+func goStructFields(t reflect.Type) []goStructField {
+	var all []goStructField
+
+	var walk func(t reflect.Type, index []int, visited map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, visited map[reflect.Type]bool) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts := tag, ""
+			if j := strings.IndexByte(tag, ','); j >= 0 {
+				name, opts = tag[:j], tag[j:]
+			}
+			if !isValidTag(name) {
+				name = ""
+			}
+
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous {
+				if len(sf.PkgPath) > 0 && ft.Kind() != reflect.Struct {
+					// unexported embedded non-struct
+					continue
+				}
+				if len(name) == 0 && ft.Kind() == reflect.Struct {
+					walk(ft, append(index[:len(index):len(index)], i), visited)
+					continue
+				}
+			} else if len(sf.PkgPath) > 0 {
+				// unexported
+				continue
+			}
+
+			f := goStructField{
+				name:      name,
+				index:     append(index[:len(index):len(index)], i),
+				tagged:    len(name) > 0,
+				omitEmpty: strings.Contains(opts+",", ",omitempty,"),
+				quoted:    strings.Contains(opts+",", ",string,"),
+			}
+			if !f.tagged {
+				f.name = sf.Name
+			}
+			all = append(all, f)
+		}
+	}
+	walk(t, nil, map[reflect.Type]bool{})
+
+	// resolve fields with the same name as json.Marshal does,
+	// keeping the least nested field or, among equally nested
+	// fields, the only tagged one
+	var fields []goStructField
+	for _, f := range all {
+		dominant := true
+		for _, g := range all {
+			if g.name != f.name || len(g.index) > len(f.index) || reflect.DeepEqual(g.index, f.index) {
+				continue
+			}
+			if len(g.index) < len(f.index) || g.tagged == f.tagged || g.tagged {
+				dominant = false
+				break
+			}
+		}
+		if dominant {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// isValidTag reports whether the name given by a json struct tag is
+// valid, as with json.Marshal, which ignores invalid names.
+func isValidTag(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// backslash and quote are reserved, but
+			// other punctuation is allowed
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
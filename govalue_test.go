@@ -0,0 +1,290 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// formatGoValue returns v formatted by f's FormatGoValue.
+func formatGoValue(t *testing.T, f *Formatter, v interface{}) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	err := f.FormatGoValue(buf, v)
+	if err != nil {
+		t.Fatalf("FormatGoValue(%#v): %v", v, err)
+	}
+	return buf.String()
+}
+
+func TestSummarizeByteSlices(t *testing.T) {
+	type blob struct {
+		Name string
+		Data []byte
+	}
+
+	small := bytes.Repeat([]byte{0xff}, maxSummarizedByteSliceLength)
+	large := bytes.Repeat([]byte{0xff}, maxSummarizedByteSliceLength+1)
+	smallJSON, _ := json.Marshal(small)
+
+	tests := []struct {
+		name      string
+		summarize bool
+		v         interface{}
+		want      string
+	}{
+		{"empty", true, []byte{}, `""`},
+		{"small", true, small, string(smallJSON)},
+		{"large", true, large, `bytes(len=65)`},
+		{"large field", true, blob{"x", large}, `{"Name":"x","Data":bytes(len=65)}`},
+		{"nil", true, blob{"x", nil}, `{"Name":"x","Data":null}`},
+		{"disabled", false, large, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if len(want) == 0 {
+				b, err := json.Marshal(tt.v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want = string(b)
+			}
+			f := &Formatter{SummarizeByteSlices: tt.summarize}
+			if got := uncolored(formatGoValue(t, f, tt.v)); got != want {
+				t.Errorf("FormatGoValue(%v) = %q, want %q", tt.v, got, want)
+			}
+		})
+	}
+}
+
+func TestSummarizeByteSlicesColor(t *testing.T) {
+	f := &Formatter{SummarizeByteSlices: true}
+	got := formatGoValue(t, f, bytes.Repeat([]byte{1}, 100))
+	if want := DefaultMoreColor.SprintfFunc()("bytes(len=100)"); got != want {
+		t.Errorf("FormatGoValue = %q, want %q", got, want)
+	}
+}
+
+type goValueInner struct {
+	C     int    `json:"c"`
+	Inner string `json:",omitempty"`
+	D     bool
+}
+
+type goValueOther struct {
+	C string
+	E float64 `json:"e,string"`
+}
+
+type goValueMarshaler struct{ v int }
+
+func (m goValueMarshaler) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]int{"z": m.v, "a": -m.v})
+}
+
+type goValueStruct struct {
+	Zeta    string `json:"zeta"`
+	Alpha   int
+	Skipped string `json:"-"`
+	Dash    string `json:"-,"`
+	hidden  int
+	goValueInner
+	*goValueOther
+	Empty   string                 `json:",omitempty"`
+	Zero    int                    `json:"zero,omitempty"`
+	False   bool                   `json:"false,omitempty"`
+	Nil     *int                   `json:"nil,omitempty"`
+	NoSlice []int                  `json:"noSlice,omitempty"`
+	NoMap   map[string]int         `json:"noMap,omitempty"`
+	Struct  goValueInner           `json:"struct,omitempty"`
+	Number  int                    `json:"number,string"`
+	Map     map[string]interface{} `json:"map"`
+	Custom  goValueMarshaler
+	Middle  float32
+}
+
+func TestFormatGoValueMatchesMarshal(t *testing.T) {
+	n := 7
+	values := []interface{}{
+		goValueStruct{},
+		goValueStruct{
+			Zeta:         "z",
+			Alpha:        1,
+			Skipped:      "skipped",
+			Dash:         "dash",
+			hidden:       2,
+			goValueInner: goValueInner{C: 3, Inner: "inner", D: true},
+			goValueOther: &goValueOther{C: "shadowed", E: 2.5},
+			Empty:        "empty",
+			Zero:         4,
+			False:        true,
+			Nil:          &n,
+			NoSlice:      []int{5},
+			NoMap:        map[string]int{"b": 1, "a": 2},
+			Number:       6,
+			Map:          map[string]interface{}{"y": []interface{}{1, "two"}, "x": nil},
+			Custom:       goValueMarshaler{8},
+			Middle:       0.1,
+		},
+		[]goValueInner{{C: 1}, {Inner: "x"}},
+		map[int]goValueOther{2: {C: "b"}, 10: {C: "a"}},
+		struct {
+			B, A int
+		}{1, 2},
+	}
+	for _, v := range values {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := uncolored(formatGoValue(t, &Formatter{}, v)); got != string(want) {
+			t.Errorf("FormatGoValue(%+v) = %s, want %s", v, got, want)
+		}
+
+		f := &Formatter{Indent: "  "}
+		if got, want := formatGoValue(t, f, v), formatString(t, f, string(want)); got != want {
+			t.Errorf("FormatGoValue(%+v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestFormatGoValueFieldOrder(t *testing.T) {
+	type embedded struct {
+		M, B int
+	}
+	v := struct {
+		Z int
+		embedded
+		A int
+		N map[string]int
+	}{Z: 1, embedded: embedded{M: 2, B: 3}, A: 4, N: map[string]int{"z": 1, "a": 2, "m": 3}}
+	want, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := uncolored(formatGoValue(t, &Formatter{}, v))
+	if got != string(want) {
+		t.Errorf("FormatGoValue(%+v) = %s, want %s", v, got, want)
+	}
+	// declaration order for struct fields, sorted keys for maps
+	if want := `{"Z":1,"M":2,"B":3,"A":4,"N":{"a":2,"m":3,"z":1}}`; got != want {
+		t.Errorf("FormatGoValue(%+v) = %s, want %s", v, got, want)
+	}
+}
+
+type goValueTags struct {
+	Valid   int `json:"a-b.c@d"`
+	Space   int `json:"with space"`
+	Quote   int `json:"bad\"quote"`
+	Slash   int `json:"bad\\slash"`
+	Unicode int `json:"ünï"`
+	Comma   int `json:",omitempty"`
+}
+
+func TestFormatGoValueTagNames(t *testing.T) {
+	// invalid names are ignored, using the names of the fields
+	v := goValueTags{1, 2, 3, 4, 5, 6}
+	got := uncolored(formatGoValue(t, &Formatter{}, v))
+	if want := `{"a-b.c@d":1,"with space":2,"Quote":3,"Slash":4,"ünï":5,"Comma":6}`; got != want {
+		t.Errorf("FormatGoValue(%+v) = %s, want %s", v, got, want)
+	}
+}
+
+type goValueStringer int
+
+func (s goValueStringer) String() string { return "stringer" }
+
+type goValueText struct{ s string }
+
+func (t *goValueText) MarshalText() ([]byte, error) { return []byte(t.s), nil }
+
+type goValueID [2]byte
+
+func (id goValueID) String() string { return fmt.Sprintf("%x", id[:]) }
+
+func (id goValueID) MarshalText() ([]byte, error) { return []byte(id.String()), nil }
+
+func TestFormatGoValueTypeColors(t *testing.T) {
+	v := struct {
+		Time     time.Time
+		Stringer goValueStringer
+		Text     *goValueText
+		ID       goValueID
+		NilText  *goValueText
+		Plain    int
+		Array    []goValueStringer
+	}{
+		Time:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Stringer: 7,
+		Text:     &goValueText{"text"},
+		ID:       goValueID{0xab, 0xcd},
+		Plain:    8,
+		Array:    []goValueStringer{9},
+	}
+	want, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &Formatter{
+		StringerColor: color.New(color.FgMagenta),
+		TypeColors: map[reflect.Type]SprintfFuncer{
+			reflect.TypeOf(time.Time{}): color.New(color.FgCyan),
+		},
+	}
+	got := formatGoValue(t, f, v)
+	if uncolored(got) != string(want) {
+		t.Errorf("FormatGoValue(%+v) = %s, want %s", v, uncolored(got), want)
+	}
+	// TypeColors take precedence
+	if s, want := colored(got, "36"), `"2020-01-02T03:04:05Z"`; s != want {
+		t.Errorf("TypeColors colored %q, want %q", s, want)
+	}
+	// nulls and containers keep their usual colors
+	if s, want := colored(got, "35"), `7"text""abcd"9`; s != want {
+		t.Errorf("StringerColor colored %q, want %q", s, want)
+	}
+
+	// values keep their usual colors without StringerColor
+	got = formatGoValue(t, &Formatter{}, v)
+	if want := formatString(t, &Formatter{}, string(want)); got != want {
+		t.Errorf("FormatGoValue(%+v) = %q, want %q", v, got, want)
+	}
+}
+
+func BenchmarkFormatGoValue(b *testing.B) {
+	v := goValueStruct{
+		Zeta:         "z",
+		Alpha:        1,
+		goValueInner: goValueInner{C: 3, Inner: "inner", D: true},
+		goValueOther: &goValueOther{C: "shadowed", E: 2.5},
+		Map:          map[string]interface{}{"y": []interface{}{1, "two"}, "x": nil},
+		Custom:       goValueMarshaler{8},
+	}
+	f := &Formatter{Indent: "  "}
+	b.Run("FormatGoValue", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := f.FormatGoValue(ioutil.Discard, v); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("MarshalFormat", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			src, err := json.Marshal(v)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := f.Format(ioutil.Discard, src); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
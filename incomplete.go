@@ -0,0 +1,79 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// FormatIncomplete is like Format but colorizes as much of the
+// possibly incomplete JSON-encoded src as it can, such as the
+// contents of an editor buffer as it is being typed, appending the
+// remainder of src to dst as-is.  Colorizing stops after the last
+// complete value or field name followed by its colon.  It returns
+// the offset in src at which colorizing stopped, which is len(src)
+// if src is complete.  The returned error is nil if src is complete
+// or is a truncated JSON value, otherwise it describes why src is
+// invalid or, as for Format, why it could not be formatted.  Like
+// the Tolerant field, FormatIncomplete accepts the non-standard
+// number literals NaN, Infinity and -Infinity.
+func (f *Formatter) FormatIncomplete(dst io.Writer, src []byte) (int, error) {
+	// find the end of the last complete token whose following
+	// separator is known
+	d := newTolerantDecoder(src, false)
+	end := 0
+	var err error
+	for {
+		_, err = d.Token()
+		if err != nil {
+			break
+		}
+		if d.state == tokenObjectColon {
+			// a field name, stop after its colon
+			if c, err := d.peek(); err == nil && c == ':' {
+				end = d.pos + 1
+			}
+			continue
+		}
+		end = d.pos
+	}
+
+	fs := newFormatterState(f, dst)
+	if err == io.EOF {
+		return len(src), fs.formatTokens(newTolerantDecoder(src, false), false)
+	}
+	if err == io.ErrUnexpectedEOF || d.truncatedLiteral() {
+		err = nil
+	}
+
+	// src[:end] is truncated, or followed only by invalid input
+	ferr := fs.formatTokens(newTolerantDecoder(src[:end], false), false)
+	if ferr != nil && ferr != io.ErrUnexpectedEOF {
+		return 0, ferr
+	}
+	fs.write(TokenSpace, style{}, string(src[end:]))
+	return end, err
+}
+
+// truncatedLiteral reports whether the remainder of the input is a
+// truncated literal, such as tr or 1e, in a position where a value
+// is allowed.
+func (d *tolerantDecoder) truncatedLiteral() bool {
+	_, err := d.peek()
+	if err != nil || !d.valueAllowed() {
+		return false
+	}
+	lit := d.data[d.pos:]
+	for _, c := range lit {
+		if !isLiteralByte(c) {
+			return false
+		}
+	}
+	for _, s := range append([]string{"true", "false", "null"}, nonStandardNumbers...) {
+		if bytes.HasPrefix([]byte(s), lit) {
+			return true
+		}
+	}
+	// a truncated number becomes valid when followed by a digit
+	return json.Valid(append(lit[:len(lit):len(lit)], '0'))
+}
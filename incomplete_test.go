@@ -0,0 +1,68 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatIncomplete(t *testing.T) {
+	src := `{"str":"foo","num":-1.5e3,"arr":[true,false,null,{}],"obj":{"a":[]}}`
+	f := &Formatter{}
+	for i := 0; i <= len(src); i++ {
+		buf := &bytes.Buffer{}
+		stoppedAt, err := f.FormatIncomplete(buf, []byte(src[:i]))
+		if err != nil {
+			t.Errorf("FormatIncomplete(%s): %v", src[:i], err)
+			continue
+		}
+		if got := uncolored(buf.String()); got != src[:i] {
+			t.Errorf("FormatIncomplete(%s) = %q, want %q", src[:i], got, src[:i])
+		}
+		if stoppedAt > i {
+			t.Errorf("FormatIncomplete(%s) stopped at %d, want at most %d", src[:i], stoppedAt, i)
+		}
+		// the remainder is appended uncolored
+		if rest := src[stoppedAt:i]; !bytes.HasSuffix(buf.Bytes(), []byte(rest)) {
+			t.Errorf("FormatIncomplete(%s) = %q, want uncolored suffix %q", src[:i], buf.String(), rest)
+		}
+	}
+	if stoppedAt, _ := f.FormatIncomplete(&bytes.Buffer{}, []byte(src)); stoppedAt != len(src) {
+		t.Errorf("FormatIncomplete(%s) stopped at %d, want %d", src, stoppedAt, len(src))
+	}
+
+	tests := []struct {
+		src       string
+		stoppedAt int
+	}{
+		{`{"a":`, 5},
+		{`{"a"`, 1},
+		{`[1,tr`, 2},
+		{`[1,2`, 4},
+		{`[1e`, 1},
+		{`{"a":1,"b":"x`, 11},
+	}
+	for _, tt := range tests {
+		stoppedAt, err := f.FormatIncomplete(&bytes.Buffer{}, []byte(tt.src))
+		if err != nil || stoppedAt != tt.stoppedAt {
+			t.Errorf("FormatIncomplete(%s) = %d, %v, want %d, nil", tt.src, stoppedAt, err, tt.stoppedAt)
+		}
+	}
+}
+
+func TestFormatIncompleteErrors(t *testing.T) {
+	tests := []struct {
+		f   *Formatter
+		src string
+	}{
+		{&Formatter{}, `[1,]`},
+		{&Formatter{}, `{"a" 1`},
+		{&Formatter{}, `[1]]`},
+		{&Formatter{FocusPath: "a"}, `{"a":1`},
+		{&Formatter{FocusPath: "a"}, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		if _, err := tt.f.FormatIncomplete(&bytes.Buffer{}, []byte(tt.src)); err == nil {
+			t.Errorf("FormatIncomplete(%s) = nil error, want error", tt.src)
+		}
+	}
+}
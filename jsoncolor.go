@@ -7,10 +7,19 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 )
 
 // DefaultFormatter is the Formatter used by Marshal, MarshalIndent
@@ -126,6 +135,23 @@ type frame struct {
 	array  bool
 	empty  bool
 	indent int
+	index  int
+	key    string
+	inline bool
+
+	// expanded is true if the frame is an object that has the
+	// field ExpandObjectsWithKey or is nested inside one.
+	expanded bool
+	// columns is true if the frame is an object whose fields are
+	// packed into ObjectColumns columns.
+	columns bool
+	// embedded is true if the frame is an object or array
+	// unwrapped from a string value, see UnwrapEmbeddedJSON.
+	embedded bool
+	// intWidth, if positive, is the width to which the integer
+	// parts of the numbers of an array of numbers are padded,
+	// see AlignNumbers.
+	intWidth int
 }
 
 func (f *frame) inArray() bool {
@@ -170,6 +196,74 @@ func (f *frame) isEmpty() bool {
 	return (f.object || f.array) && f.empty
 }
 
+// segment returns the field name or array index identifying the
+// frame's current value.
+func (f *frame) segment() string {
+	if f.array {
+		return strconv.Itoa(f.index - 1)
+	}
+	return f.key
+}
+
+func isCloseDelim(t json.Token) bool {
+	x, ok := t.(json.Delim)
+	return ok && (x == json.Delim('}') || x == json.Delim(']'))
+}
+
+// skipValue consumes the remaining tokens of the value beginning
+// with token t.
+func skipValue(dec tokenReader, t json.Token) error {
+	depth := 0
+	for {
+		if x, ok := t.(json.Delim); ok {
+			if x == json.Delim('{') || x == json.Delim('[') {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		t, err = dec.Token()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipElements consumes the value beginning with token t and all
+// values following it in the current array, returning the number of
+// values skipped.  If object is true, t is instead the name of the
+// first of the remaining fields of the current object to skip.
+func skipElements(dec tokenReader, t json.Token, object bool) (int, error) {
+	n := 0
+	for {
+		if object {
+			var err error
+			t, err = dec.Token()
+			if err != nil {
+				return n, err
+			}
+		}
+		err := skipValue(dec, t)
+		if err != nil {
+			return n, err
+		}
+		n++
+		if !dec.More() {
+			return n, nil
+		}
+		t, err = dec.Token()
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+type sprintfFunc func(format string, a ...interface{}) string
+
 // SprintfFuncer is implemented by any value that has a SprintfFunc
 // method.
 type SprintfFuncer interface {
@@ -215,6 +309,110 @@ var (
 	DefaultNumberColor = color.New()
 	// DefaultNullColor is the default color for null values.
 	DefaultNullColor = color.New(color.FgBlack, color.Bold)
+	// DefaultMoreColor is the default color for the indicator
+	// summarizing elements omitted from truncated output.
+	DefaultMoreColor = color.New(color.FgBlack, color.Bold)
+	// DefaultDimColor is the default color for tokens outside
+	// the focused subtree.
+	DefaultDimColor = color.New(color.Faint)
+	// DefaultChangedColor is the default color for values that
+	// differ from those of a previous document.
+	DefaultChangedColor = color.New(color.FgYellow, color.Bold)
+	// DefaultAddedColor is the default color for values that are
+	// not present in a document of defaults.
+	DefaultAddedColor = color.New(color.FgGreen, color.Bold)
+	// DefaultDeletedColor is the default color for the fields a
+	// JSON Merge Patch deletes.
+	DefaultDeletedColor = color.New(color.FgRed, color.CrossedOut)
+	// DefaultUnparseableColor is the default color for
+	// placeholders replacing invalid values.
+	DefaultUnparseableColor = color.New(color.FgRed, color.Bold)
+	// DefaultErrorColor is the default color for invalid values
+	// reported by a validator.
+	DefaultErrorColor = color.New(color.FgRed, color.Bold)
+	// DefaultTrailingSpaceColor is the default color for
+	// trailing spaces in string values and the spaces surrounding
+	// field names.
+	DefaultTrailingSpaceColor = color.New(color.FgBlack, color.BgYellow)
+	// DefaultVisibleWhitespaceColor is the default color for
+	// glyphs displaying whitespace characters in strings.
+	DefaultVisibleWhitespaceColor = color.New(color.FgCyan)
+	// DefaultUnitColor is the default color for the
+	// human-readable forms of numbers with units.
+	DefaultUnitColor = color.New(color.Faint)
+	// DefaultMarkupTagColor is the default color for HTML and XML
+	// tags in strings.
+	DefaultMarkupTagColor = color.New(color.FgMagenta)
+	// DefaultMarkupAttributeColor is the default color for the
+	// attribute names of HTML and XML tags in strings.
+	DefaultMarkupAttributeColor = color.New(color.FgCyan)
+	// DefaultEmptyStringColor is the default color for empty
+	// string values.
+	DefaultEmptyStringColor = color.New(color.FgBlack, color.BgYellow)
+	// DefaultZeroColor is the default color for number values
+	// equal to zero.
+	DefaultZeroColor = color.New(color.FgYellow)
+	// DefaultStringNumberColor is the default color for string
+	// values that are numbers.
+	DefaultStringNumberColor = color.New(color.FgMagenta)
+	// DefaultRootLabelColor is the default color for the label
+	// preceding the output.
+	DefaultRootLabelColor = color.New(color.Bold, color.Underline)
+	// DefaultReplacementCharColor is the default color for
+	// Unicode replacement characters in strings and field names.
+	DefaultReplacementCharColor = color.New(color.FgWhite, color.BgRed)
+	// DefaultEmbeddedColor is the default color for the marker
+	// preceding JSON unwrapped from string values.
+	DefaultEmbeddedColor = color.New(color.FgYellow)
+	// DefaultCommentColor is the default color for comments
+	// annotating values.
+	DefaultCommentColor = color.New(color.FgBlack, color.Bold)
+
+	// DefaultWhitespaceGlyphs are the default glyphs displaying
+	// whitespace characters in strings.
+	DefaultWhitespaceGlyphs = map[rune]string{
+		' ':  "·",
+		'\t': "→",
+		'\n': "↵",
+		'\r': "←",
+	}
+
+	// DefaultBadges are the default badges preceding values of
+	// each kind when ValueBadges is true.
+	DefaultBadges = map[TokenKind]string{
+		TokenObject: "[o]",
+		TokenArray:  "[a]",
+		TokenString: "[s]",
+		TokenNumber: "[n]",
+		TokenTrue:   "[b]",
+		TokenFalse:  "[b]",
+		TokenNull:   "[-]",
+	}
+
+	// DefaultBooleanStrings are the default string values colored
+	// as booleans when HighlightBooleanStrings is true, mapped to
+	// the booleans they stand for.
+	DefaultBooleanStrings = map[string]bool{
+		"true":  true,
+		"false": false,
+		"yes":   true,
+		"no":    false,
+		"on":    true,
+		"off":   false,
+		"1":     true,
+		"0":     false,
+	}
+
+	// DefaultKeyColorPalette is the default palette of colors
+	// assigned to field names when HashKeyColors is true.
+	DefaultKeyColorPalette = []SprintfFuncer{
+		color.New(color.FgRed, color.Bold),
+		color.New(color.FgGreen, color.Bold),
+		color.New(color.FgYellow, color.Bold),
+		color.New(color.FgBlue, color.Bold),
+		color.New(color.FgMagenta, color.Bold),
+		color.New(color.FgCyan, color.Bold),
+	}
 
 	// By default, no prefix is used.
 	DefaultPrefix = ""
@@ -222,6 +420,19 @@ var (
 	DefaultIndent = "  "
 )
 
+func init() {
+	honorNoColor()
+}
+
+// honorNoColor disables colors globally if the NO_COLOR environment
+// variable is set to a non-empty value, see https://no-color.org.
+// The color package only does so itself in later versions.
+func honorNoColor() {
+	if len(os.Getenv("NO_COLOR")) > 0 {
+		color.NoColor = true
+	}
+}
+
 // Formatter colorizes buffers containing JSON.
 type Formatter struct {
 	// Color for whitespace characters.  If nil, DefaultSpaceColor
@@ -240,14 +451,20 @@ type Formatter struct {
 	// DefaultArrayColor is used.
 	ArrayColor SprintfFuncer
 	// Color for quotes '"' surrounding object field names.  If
-	// nil, DefaultFieldQuoteColor is used.
+	// nil, QuoteColor is used.
 	FieldQuoteColor SprintfFuncer
 	// Color for object field names.  If nil, DefaultFieldColor is
 	// used.
 	FieldColor SprintfFuncer
 	// Color for quotes '"' surrounding string values.  If nil,
-	// DefaultStringQuoteColor is used.
+	// QuoteColor is used.
 	StringQuoteColor SprintfFuncer
+	// Color for quotes '"' surrounding both object field names
+	// and string values, allowing quotes to be colored
+	// differently from the text they surround.  If nil,
+	// DefaultFieldQuoteColor and DefaultStringQuoteColor are
+	// used.
+	QuoteColor SprintfFuncer
 	// Color for string values.  If nil, DefaultStringColor is
 	// used.
 	StringColor SprintfFuncer
@@ -262,17 +479,697 @@ type Formatter struct {
 	NumberColor SprintfFuncer
 	// Color for null values.  If nil, DefaultNullColor is used.
 	NullColor SprintfFuncer
+	// Color for null array elements, such as missing elements of
+	// sparse arrays.  If nil, NullColor is used.
+	ArrayNullColor SprintfFuncer
+	// Color for null object field values, such as absent optional
+	// fields.  If nil, NullColor is used.
+	FieldNullColor SprintfFuncer
+	// Color for negative number values.  Negative zero is not
+	// considered negative.  If nil, NumberColor is used.
+	NegativeNumberColor SprintfFuncer
+	// Color for the exponent of numbers in exponent form, such as
+	// the e-10 of 1.5e-10, including those displayed in a form
+	// chosen by NumberNotation.  If nil, exponents use the color
+	// of the rest of the number.
+	ExponentColor SprintfFuncer
+	// Color for the indicator summarizing elements omitted from
+	// truncated output.  If nil, DefaultMoreColor is used.
+	MoreColor SprintfFuncer
+	// Color for the ellipsis marking omitted or truncated output,
+	// see Ellipsis.  If nil, MoreColor is used.
+	EllipsisColor SprintfFuncer
+	// Color for tokens outside the subtree identified by
+	// FocusPath.  If nil, DefaultDimColor is used.
+	DimColor SprintfFuncer
+	// Color for values that differ from those of a previous
+	// document, see FormatDiffANSI.  If nil, DefaultChangedColor
+	// is used.
+	ChangedColor SprintfFuncer
+	// Color for values that are not present in a document of
+	// defaults, see FormatOverrides.  If nil, DefaultAddedColor is
+	// used.
+	AddedColor SprintfFuncer
+	// Color for the fields a JSON Merge Patch deletes, see
+	// FormatMergePatch.  If nil, DefaultDeletedColor is used.
+	DeletedColor SprintfFuncer
+	// Color for placeholders replacing invalid values and field
+	// names, see OnUnparseable.  If nil, DefaultUnparseableColor
+	// is used.
+	UnparseableColor SprintfFuncer
+	// Color for the delimiter characters of the outermost object
+	// or array, framing the whole document.  If nil, ObjectColor
+	// or ArrayColor is used.
+	RootContainerColor SprintfFuncer
+	// Color for invalid values and the messages describing them,
+	// see FormatWithErrors.  If nil, DefaultErrorColor is used.
+	ErrorColor SprintfFuncer
+	// Color for trailing spaces in string values and the spaces
+	// surrounding field names, see ShowTrailingSpaceInStrings and
+	// HighlightKeyWhitespace.  If nil, DefaultTrailingSpaceColor
+	// is used.
+	TrailingSpaceColor SprintfFuncer
+	// Color for glyphs displaying whitespace characters in
+	// strings, see VisibleStringWhitespace.  If nil,
+	// DefaultVisibleWhitespaceColor is used.
+	VisibleWhitespaceColor SprintfFuncer
+	// Color for the human-readable forms of numbers with units,
+	// see InferUnits.  If nil, DefaultUnitColor is used.
+	UnitColor SprintfFuncer
+	// Color for HTML and XML tags in strings, see
+	// DetectMarkupInStrings.  If nil, DefaultMarkupTagColor is
+	// used.
+	MarkupTagColor SprintfFuncer
+	// Color for the attribute names of HTML and XML tags in
+	// strings, see DetectMarkupInStrings.  If nil,
+	// DefaultMarkupAttributeColor is used.
+	MarkupAttributeColor SprintfFuncer
+	// Color for empty string values, see HighlightEmptyStrings.
+	// If nil, DefaultEmptyStringColor is used.
+	EmptyStringColor SprintfFuncer
+	// Color for number values equal to zero, see HighlightZeros.
+	// If nil, DefaultZeroColor is used.
+	ZeroColor SprintfFuncer
+	// Color for string values that are numbers, see
+	// HighlightStringNumbers.  If nil, DefaultStringNumberColor
+	// is used.
+	StringNumberColor SprintfFuncer
+	// Color for the label preceding the output, see RootLabel.
+	// If nil, DefaultRootLabelColor is used.
+	RootLabelColor SprintfFuncer
+	// Color for Unicode replacement characters in strings and
+	// field names, see HighlightReplacementChars.  If nil,
+	// DefaultReplacementCharColor is used.
+	ReplacementCharColor SprintfFuncer
+	// Color for the marker preceding JSON unwrapped from string
+	// values, see UnwrapEmbeddedJSON.  If nil,
+	// DefaultEmbeddedColor is used.
+	EmbeddedColor SprintfFuncer
+	// Color for comments annotating values, see Annotations.  If
+	// nil, DefaultCommentColor is used.
+	CommentColor SprintfFuncer
 
 	// Prefix is prepended before indentation to newlines.
 	Prefix string
 	// Indent is prepended to newlines one or more times according
-	// to indentation nesting.
+	// to indentation nesting.  As with encoding/json's
+	// MarshalIndent, a space follows the colon after each field
+	// name when either Prefix or Indent is set.
 	Indent string
+	// IndentOverrides, if non-empty, maps JSON Pointers (see RFC
+	// 6901) to strings used instead of Indent for the contents of
+	// the object or array they identify and of every value nested
+	// inside it, such as {"/data": " "} for a tighter indentation
+	// of the subtree under the top-level field data.  The
+	// indentation of each level of a line is chosen by the
+	// longest pointer that is a prefix of the path of the object
+	// or array beginning the level, so overrides for deeper
+	// subtrees take precedence.  IndentOverrides is ignored if
+	// Prefix and Indent are both empty.
+	IndentOverrides map[string]string
 
 	// EscapeHTML specifies whether problematic HTML characters
 	// should be escaped inside JSON quoted strings.  See
 	// json.Encoder.SetEscapeHTML's comment for more details.
 	EscapeHTML bool
+
+	// ExpandEmptyContainers specifies whether empty objects and
+	// arrays should be rendered across two lines rather than
+	// collapsed as {} and [].  It has no effect on compact
+	// output.
+	ExpandEmptyContainers bool
+
+	// ArrayInlineFirstElement specifies whether the first element
+	// of a non-empty array should be displayed on the same line
+	// as its opening bracket, as in [1, followed by the other
+	// elements on lines of their own, rather than on the next
+	// line.  It has no effect on compact output, arrays displayed
+	// inline or arrays whose elements are aligned by AlignNumbers
+	// or whose opening lines show ShowContainerCounts.
+	ArrayInlineFirstElement bool
+
+	// WrapLongValues, if positive, is the width above which the
+	// scalar values of fields are displayed on a line of their
+	// own below their field names, indented one level further,
+	// rather than after the colon, keeping objects with long
+	// values readable.  The width of a value is that of its JSON
+	// encoding.  It has no effect on compact output or objects
+	// packed into ObjectColumns columns.  This affects only the
+	// displayed output.
+	WrapLongValues int
+
+	// MaxArrayElements is the maximum number of elements
+	// displayed for each array.  Remaining elements are omitted
+	// and replaced with an indicator reporting how many were
+	// hidden.  If zero, all elements are displayed.
+	MaxArrayElements int
+	// MaxObjectFields is the maximum number of fields displayed
+	// for each object.  Remaining fields are omitted and replaced
+	// with an indicator reporting how many were hidden.  If zero,
+	// all fields are displayed.
+	MaxObjectFields int
+
+	// Ellipsis is the text marking omitted or truncated output,
+	// such as the indicators displayed by MaxArrayElements,
+	// MaxObjectFields, MaxLines and ExpandObjectsWithKey, lines
+	// truncated by FormatSideBySide and strings summarized by
+	// OutlineMode.  If empty, "…" is used.
+	Ellipsis string
+	// MoreIndicator, if non-nil, returns the text of the
+	// indicators reporting the number of elements, fields or
+	// lines hidden by MaxArrayElements, MaxObjectFields,
+	// MaxLines and ExpandObjectsWithKey, such as "(+42)".  The
+	// text is printed using MoreColor in place of the default
+	// indicator such as "… (42 more)".
+	MoreIndicator func(hidden int) string
+
+	// FocusPath is a JSON Pointer (see RFC 6901) identifying a
+	// subtree to highlight, such as "/items/3".  Tokens inside
+	// the subtree, including the field name it is stored under,
+	// use their normal colors while all other tokens use
+	// DimColor.  If empty, all tokens use their normal colors.
+	FocusPath string
+	// ShowBreadcrumb specifies whether the output should begin
+	// with a line such as root ▸ items ▸ [3] showing FocusPath,
+	// with field names colored with FieldColor and array indices,
+	// which are the path's segments made up of digits, colored
+	// with NumberColor.  Note that the output is therefore not
+	// valid JSON.
+	ShowBreadcrumb bool
+	// RootLabel, if non-empty, is a label such as the name of the
+	// document, colored with RootLabelColor, displayed on a line
+	// of its own before the output, for example to tell apart
+	// several documents shown side by side.  If a breadcrumb is
+	// shown, it begins with the label in place of root.  Note
+	// that the output is therefore not valid JSON.
+	RootLabel string
+
+	// Tolerant specifies whether non-standard input should be
+	// accepted.  Currently, this allows the number literals NaN,
+	// Infinity and -Infinity produced by some encoders, which are
+	// colored as numbers and preserved as-is in the output.  Note
+	// that the output is therefore not valid JSON.
+	Tolerant bool
+	// OnUnparseable, if non-nil and Tolerant is true, is called
+	// with the raw bytes of each invalid value or field name,
+	// such as undefined or an unquoted field name, and returns a
+	// placeholder, such as "‹?›", to display in its place colored
+	// with UnparseableColor.  Formatting then continues with the
+	// following token rather than failing.  The raw bytes extend
+	// up to the next whitespace, comma, colon or closing
+	// delimiter.
+	OnUnparseable func(raw []byte) string
+
+	// ScalarTextFunc, if non-nil, is called for each string,
+	// number, boolean and null value with the value's kind and
+	// its JSON encoding raw, excluding the surrounding quotes for
+	// strings.  If ScalarTextFunc returns true, the returned text
+	// is displayed in place of raw, colored as usual.  This allows
+	// values to be masked, truncated or otherwise reformatted for
+	// display.  Note that the returned text is written as-is and
+	// may therefore render the output invalid JSON.
+	ScalarTextFunc func(kind TokenKind, raw string) (string, bool)
+
+	// KeyTransform, if non-nil, is called for each field name key
+	// with the path to the field, made up of the field name or
+	// array index identifying it within each enclosing
+	// container, and returns the field name to display, such as
+	// a localized or title-cased form of key.  This affects only
+	// the displayed output, fields are still matched by their
+	// original names, for example by FocusPath and Classify.
+	// Note that the transformed field names of an object may not
+	// be unique and the output may therefore not round-trip to
+	// the input.
+	KeyTransform func(path []string, key string) string
+
+	// KeyOrder, if non-nil, is called for each object with the
+	// path to the object, made up of the field name or array
+	// index identifying it within each enclosing container, and
+	// the names of its fields in the order they appear, and
+	// returns the names in the order the fields should be
+	// displayed, such as with "id" and "name" first.  Fields
+	// whose names are not returned follow in their original
+	// order, and names that are not field names of the object
+	// are ignored.  Fields with the same name are displayed
+	// together.  The tokens of each object must be buffered to
+	// reorder its fields.
+	KeyOrder func(path []string, keys []string) []string
+
+	// EmitTrailingComma specifies whether a comma should follow
+	// the last element or field of each non-empty object and
+	// array, as permitted by JSON5 and JSONC, so that appending
+	// an element changes a single line.  Note that the output is
+	// therefore not valid JSON.  EmitTrailingComma has no effect
+	// on compact output or on containers displayed inline.
+	EmitTrailingComma bool
+
+	// GlyphLiterals specifies whether the literals true, false and
+	// null should be displayed as the glyphs ✓, ✗ and ∅, colored
+	// as usual, for compact display.  Text returned by
+	// ScalarTextFunc takes precedence.  Note that the output is
+	// therefore not valid JSON.
+	GlyphLiterals bool
+
+	// ValueBadges specifies whether each value should be preceded
+	// by a badge indicating its type, such as [s] "hello" or
+	// [n] 42, colored as the value, so that types can be scanned
+	// without a legend.  This affects only the displayed output,
+	// which is therefore not valid JSON.
+	ValueBadges bool
+	// Badges is the text of the badge of each kind of value if
+	// ValueBadges is true, keyed by TokenObject, TokenArray,
+	// TokenString, TokenNumber, TokenTrue, TokenFalse and
+	// TokenNull.  Kinds lacking a badge are displayed without
+	// one.  If nil, DefaultBadges is used.
+	Badges map[TokenKind]string
+
+	// Classify, if non-nil, is called for each string, number,
+	// boolean and null value and each field name with the path to
+	// the value, made up of the field name or array index
+	// identifying it within each enclosing container, its kind,
+	// such as TokenString or TokenField, and its text, which is
+	// the JSON encoding of numbers, booleans and null and the
+	// unquoted contents of strings and field names.  It returns a
+	// label such as "email", and if LabelColors has a color for
+	// the label, the value or field name is colored with it.
+	// Labeled colors take precedence over the colors of the other
+	// fields of Formatter, including those chosen according to
+	// the contents of values such as NegativeNumberColor and
+	// ArrayPositionColors.  An empty label, or one without a
+	// color, leaves the value's color unchanged.
+	Classify func(path []string, kind TokenKind, raw string) string
+	// LabelColors maps the labels returned by Classify to colors.
+	LabelColors map[string]SprintfFuncer
+
+	// ArrayPositionColors, if non-empty, specifies the colors of
+	// scalar array elements according to their position, such
+	// that the element at index i uses color i modulo the number
+	// of colors.  This is useful for tuple-like arrays such as
+	// [index, value] pairs.  A nil color leaves the element's
+	// usual color unchanged.
+	ArrayPositionColors []SprintfFuncer
+
+	// HighlightEmptyStrings specifies whether empty string values
+	// should be colored with EmptyStringColor, so that values of
+	// unset fields stand out, or are dimmed if the color is faint.
+	HighlightEmptyStrings bool
+	// HighlightZeros specifies whether number values equal to
+	// zero should be colored with ZeroColor, as for
+	// HighlightEmptyStrings.  Every form of zero counts, including
+	// 0.0, -0 and 0e0.
+	HighlightZeros bool
+
+	// HighlightStringNumbers specifies whether string values that
+	// are JSON numbers, such as "42" and "4.2e1", should be
+	// colored with StringNumberColor, distinguishing them from
+	// both numbers and other strings so that numbers sent as
+	// strings by some producers stand out.
+	HighlightStringNumbers bool
+
+	// HighlightBooleanStrings specifies whether string values
+	// standing for booleans, such as "yes", should be colored with
+	// TrueColor or FalseColor like the booleans they stand for, so
+	// that booleans sent as strings by some producers read
+	// consistently with real ones.  HighlightBooleanStrings takes
+	// precedence over HighlightStringNumbers.
+	HighlightBooleanStrings bool
+	// BooleanStrings maps each string value standing for a
+	// boolean if HighlightBooleanStrings is true, in lowercase,
+	// to the boolean it stands for.  Strings are matched
+	// regardless of case.  If nil, DefaultBooleanStrings is used.
+	BooleanStrings map[string]bool
+
+	// IndentBandColors, if non-empty, specifies the colors of the
+	// indentation of each line, such that the Indent string
+	// indenting the line's level i, counting from zero, uses
+	// color i modulo the number of colors.  Colors with a
+	// background, such as color.New(color.BgHiBlack), give the
+	// indentation faint vertical bands that help in following
+	// the levels of deeply nested values.  A nil color leaves
+	// the level's indentation uncolored.  Prefix is not colored.
+	IndentBandColors []SprintfFuncer
+
+	// TypeColors maps Go types, such as reflect.TypeOf(time.Time{}),
+	// to the colors of the scalar values of those types colorized
+	// by FormatGoValue.  Values of other types use their usual
+	// colors.
+	TypeColors map[reflect.Type]SprintfFuncer
+	// StringerColor, if non-nil, is the color of the scalar values
+	// colorized by FormatGoValue whose Go type implements
+	// fmt.Stringer or encoding.TextMarshaler, such as time.Time or
+	// a UUID type, unless TypeColors has a color for the type.
+	StringerColor SprintfFuncer
+
+	// SummarizeByteSlices specifies whether byte slices longer
+	// than 64 bytes colorized by FormatGoValue should be
+	// displayed as a summary of their length, such as
+	// bytes(len=1024), in MoreColor rather than as their base64
+	// encoding, so that binary fields do not dominate the output.
+	// Shorter byte slices are displayed as usual.  Note that the
+	// output is then not valid JSON.
+	SummarizeByteSlices bool
+
+	// HashKeyColors specifies whether each field name and its
+	// quotes should be colored with a color from KeyColorPalette
+	// chosen by a hash of the name, rather than with FieldColor
+	// and FieldQuoteColor, so that identical field names always
+	// have the same color.
+	HashKeyColors bool
+	// KeyColorPalette is the palette of colors assigned to field
+	// names when HashKeyColors is true.  If empty,
+	// DefaultKeyColorPalette is used.
+	KeyColorPalette []SprintfFuncer
+
+	// ColorKeysAsValues specifies whether object field names
+	// should be matched against the rules coloring string values
+	// according to their contents.  By default, such rules only
+	// apply to string values and field names always use
+	// FieldColor and FieldQuoteColor.
+	ColorKeysAsValues bool
+
+	// FloatPrecision, if positive, is the maximum number of
+	// digits displayed after the decimal point of numbers with a
+	// fractional part, which are rounded as necessary.  Numbers in
+	// exponent form keep their form, with their exponent written
+	// as in the input, and have the digits after the decimal point
+	// of their mantissa limited instead.  Trailing zeros left by
+	// rounding are dropped, so 100.999 is displayed as 101 and
+	// 1.23456E5 as 1.23E5 with a precision of 2.  Integers are
+	// unaffected.  This affects only the displayed output and
+	// discards precision.  If zero, numbers are displayed as-is.
+	FloatPrecision int
+
+	// NumberNotation is the notation in which numbers are
+	// displayed, such as DecimalNotation to display 1e3 as 1000 or
+	// ScientificNotation to display 1000 as 1e3.  Converted
+	// numbers are displayed with the shortest representation of
+	// their nearest 64-bit floating point value and thus large
+	// integers may lose exactness.  This affects only the
+	// displayed output.  By default, numbers are displayed as-is.
+	NumberNotation NumberNotation
+
+	// NumberLocale, if non-empty, is a language tag such as "de"
+	// or "fr-CH" naming the locale whose conventions numbers are
+	// displayed in, with the digits of their integer parts
+	// grouped in threes and their decimal points replaced by the
+	// locale's separators, such as 1.234,56 for 1234.56 in "de".
+	// The supported locales are those of the cs, de, en, es, fi,
+	// fr, it, ja, ko, nb, nl, pl, pt, ru, sv, tr, uk and zh
+	// languages, along with de-CH, fr-CH and pt-BR, and numbers
+	// are displayed as usual for any other locale.  Note that the
+	// output is therefore not valid JSON.
+	NumberLocale string
+
+	// QuoteLargeInts specifies whether integers whose magnitude
+	// exceeds LargeIntLimit should be displayed as quoted strings
+	// colored as strings, as APIs serving JavaScript clients,
+	// which cannot represent them exactly, often encode them.
+	// This affects only the displayed output, making visible the
+	// numbers such clients would mishandle.
+	QuoteLargeInts bool
+	// LargeIntLimit is the largest magnitude of the integers not
+	// quoted if QuoteLargeInts is true.  If zero, 2^53-1, the
+	// largest integer JavaScript represents exactly, is used.
+	LargeIntLimit uint64
+
+	// AppendLegend specifies whether the line returned by Legend
+	// should be appended to the output after the formatted JSON.
+	AppendLegend bool
+
+	// ShowSummary specifies whether a line such as (object, 5
+	// fields, 1.2 KiB) colored with DimColor should be appended to
+	// the output after the formatted JSON, describing the type of
+	// the outermost value, its number of fields or elements and
+	// the size of its input in bytes.  The size is only available
+	// when built with Go 1.14 or later.
+	ShowSummary bool
+
+	// ShowContainerCounts specifies whether the opening delimiter
+	// of each expanded object or array should be followed by a
+	// comment such as // 1000 elements colored with DimColor
+	// giving its number of fields or elements, for orientation
+	// within large values.  Counting requires reading all of the
+	// tokens of each container before it is displayed.  It has no
+	// effect on compact output.  Note that the output is
+	// therefore not valid JSON.
+	ShowContainerCounts bool
+
+	// AlignNumbers specifies whether the elements of expanded
+	// arrays whose elements are all numbers should be padded
+	// with spaces, colored with SpaceColor, so that their decimal
+	// points line up, as do the numbers of the columns of
+	// FormatTable whose cells are all numbers.  Numbers without a
+	// decimal point are aligned as if it followed their last
+	// digit.  This affects only the displayed output.
+	AlignNumbers bool
+
+	// OutlineMode specifies whether values should be replaced by
+	// a summary of their type, giving an outline of the
+	// structure of the input.  Objects are displayed as usual,
+	// showing their field names, while arrays are replaced by
+	// their number of elements, for example [3], strings by
+	// Ellipsis in quotes, numbers by # and booleans by bool.  Note
+	// that the output is therefore not valid JSON.
+	OutlineMode bool
+
+	// LeftMargin is the number of spaces inserted at the start of
+	// every line of output, including the first, before Prefix.
+	// This is useful when displaying output inside a bordered
+	// region.
+	LeftMargin int
+
+	// Annotations maps JSON Pointers (see RFC 6901), such as
+	// "/server/port", to comments displayed as // comments at the
+	// end of the line containing the identified value, or the
+	// opening line of a container.  The comments on the lines of
+	// the values of a single object or array are aligned to the
+	// same column, which requires the entire output to be
+	// buffered before any of it is written.  Annotations are not
+	// displayed in compact output.  Note that the output is
+	// therefore not valid JSON.
+	Annotations map[string]string
+
+	// AnnotateDetectedFormats specifies whether string values in
+	// one of the formats recognized by DetectFormat, such as
+	// "2024-01-01T00:00:00Z", should be annotated as by
+	// Annotations with the name of their format, such as
+	// RFC3339.  Comments given by Annotations take precedence.
+	AnnotateDetectedFormats bool
+
+	// MaxLines is the maximum number of lines of the output to
+	// display.  If the output has more lines, only the first and
+	// last lines are displayed with an indicator reporting how
+	// many lines were hidden in between, which counts as one of
+	// the MaxLines lines.  This requires the entire output to be
+	// buffered before any of it is written.  If zero, all lines
+	// are displayed.
+	MaxLines int
+
+	// ShouldExpand, if non-nil, is called for each non-empty
+	// object or array, other than those nested inside a container
+	// displayed inline, to decide whether it is expanded across
+	// multiple lines as usual or displayed inline on a single
+	// line as in compact output.  It is passed the path to the
+	// container, made up of the field name or array index
+	// identifying it within each enclosing container, its opening
+	// delimiter '{' or '[', its number of fields or elements and
+	// the number of runes in its compact JSON encoding.
+	// Measuring a container requires reading all of its tokens
+	// before it is displayed, and the tokens of nested containers
+	// are read again for each enclosing container that is
+	// expanded.  ShouldExpand has no effect on compact output.
+	ShouldExpand func(path []string, kind byte, childCount int, compactWidth int) bool
+
+	// BreakFunc, if non-nil, is called at each point between the
+	// elements of an expanded object or array, including the
+	// points following its opening delimiter and preceding its
+	// closing delimiter, to decide whether to break the line
+	// there as usual.  If it returns false, the following element
+	// continues the current line, separated by a space from the
+	// preceding element.  The LayoutContext passed to it
+	// describes the point and the element following it.  This
+	// requires reading the tokens of the following element
+	// before it is displayed.  BreakFunc has no effect on compact
+	// output or on containers displayed inline by ShouldExpand.
+	BreakFunc func(ctx LayoutContext) bool
+
+	// DebugOffsets specifies whether each token should be
+	// followed by a tag such as /*12*/ colored with DimColor
+	// giving the offset in bytes of the end of the token in the
+	// input, for debugging and for mapping displayed positions
+	// back to the input.  Offsets are only available when built
+	// with Go 1.14 or later.  Note that the output is therefore
+	// not valid JSON.
+	DebugOffsets bool
+
+	// ExpandObjectsWithKey, if non-empty, is the name of a field
+	// identifying the objects to display in full, such as the
+	// field giving the type of the events of a mixed event
+	// stream.  Objects other than the outermost value that do not
+	// have the field, and are not nested inside an object that
+	// does, are collapsed to a summary reporting their number of
+	// fields.  The field names of each object must be read before
+	// it is displayed, which requires all of its tokens to be
+	// buffered.
+	ExpandObjectsWithKey string
+
+	// ObjectColumns, if greater than 1, is the number of columns
+	// into which the fields of each object are packed, like the
+	// columns of ls, with the fields laid out from left to right
+	// and each column padded to align.  It only applies to
+	// objects whose values are all scalars, other objects are
+	// displayed as usual.  The tokens of each object must be
+	// buffered to decide whether it applies.  ObjectColumns has
+	// no effect on compact output.
+	ObjectColumns int
+
+	// TableColumnWidths maps the field names of the columns of
+	// tables written by FormatTable to the maximum number of runes
+	// displayed in the column's header and cells.  Longer headers
+	// and cells are truncated, ending with Ellipsis.  Columns
+	// without a maximum width, or with one that is not positive,
+	// are as wide as their widest cell.
+	TableColumnWidths map[string]int
+
+	// ShowTrailingSpaceInStrings specifies whether the run of
+	// spaces at the end of each string value should be displayed
+	// as middot characters '·' colored with TrailingSpaceColor,
+	// making otherwise invisible trailing spaces stand out.  This
+	// affects only the displayed output.
+	ShowTrailingSpaceInStrings bool
+
+	// HighlightKeyWhitespace specifies whether the runs of spaces
+	// at the beginning and end of each field name should be
+	// displayed as middot characters '·' colored with
+	// TrailingSpaceColor, so that field names such as " id " from
+	// sloppy producers stand out.  This affects only the displayed
+	// output.
+	HighlightKeyWhitespace bool
+
+	// VisibleStringWhitespace specifies whether whitespace
+	// characters in string values, including escaped characters
+	// such as \t, should be displayed as the glyphs given by
+	// WhitespaceGlyphs colored with VisibleWhitespaceColor.  This
+	// affects only the displayed output.
+	VisibleStringWhitespace bool
+	// VisibleKeyWhitespace is like VisibleStringWhitespace but
+	// applies to object field names.
+	VisibleKeyWhitespace bool
+	// WhitespaceGlyphs maps whitespace characters to the glyphs
+	// displaying them.  Whitespace characters without a glyph
+	// are displayed as-is.  If nil, DefaultWhitespaceGlyphs is
+	// used.
+	WhitespaceGlyphs map[rune]string
+
+	// HighlightReplacementChars specifies whether the Unicode
+	// replacement character U+FFFD in string values and field
+	// names should be colored with ReplacementCharColor.  Such
+	// characters usually replace invalid UTF-8 in the input, so
+	// highlighting them makes data corruption visible.
+	HighlightReplacementChars bool
+
+	// DetectMarkupInStrings specifies whether HTML and XML tags in
+	// string values, such as <a href="/">, should be highlighted,
+	// with the tag names and delimiters colored with
+	// MarkupTagColor and attribute names colored with
+	// MarkupAttributeColor.  Strings that do not contain '<' are
+	// displayed as usual.  Since tags are recognized in the
+	// JSON-encoded string, they are not highlighted if EscapeHTML
+	// is true.  It has no effect on strings displayed with
+	// VisibleStringWhitespace.
+	DetectMarkupInStrings bool
+
+	// UnwrapEmbeddedJSON specifies whether string values that
+	// are themselves the JSON encoding of an object or array, as
+	// often found in logs, should be displayed as the colorized
+	// object or array preceded by the marker "embedded" colored
+	// with EmbeddedColor, rather than as an escaped string.
+	// Embedded JSON is unwrapped at most 8 levels deep.  Note that
+	// the output is therefore not valid JSON.
+	UnwrapEmbeddedJSON bool
+
+	// InferUnits specifies whether numbers should be followed by
+	// a human-readable form, such as 1536 (1.5 KiB), according to
+	// the unit inferred from the name of the field they are
+	// stored under, colored with UnitColor.  Note that the output
+	// is therefore not valid JSON.
+	InferUnits bool
+	// UnitFormatters maps field name suffixes, such as "_bytes",
+	// to functions returning the human-readable form of the
+	// numbers stored under fields whose name ends with the
+	// suffix, or "" to display the number alone.  If several
+	// suffixes match, the longest is used.  If nil,
+	// DefaultUnitFormatters is used.
+	UnitFormatters map[string]func(v float64) string
+
+	// MarkdownFence specifies whether the output should be
+	// wrapped in a Markdown fenced code block, such as ```json,
+	// for pasting into documents and chat messages.  Since
+	// Markdown renderers display color escape sequences
+	// literally, fenced output is written without color.
+	MarkdownFence bool
+	// FenceLanguage is the language tag of the fenced code block
+	// written if MarkdownFence is true.  If empty, "json" is
+	// used.
+	FenceLanguage string
+
+	// PagerSafe specifies whether color escape sequences should be
+	// restricted to the attributes reliably displayed by pagers
+	// such as less -R, namely bold, underline, reverse video and
+	// the 16 standard and bright foreground and background colors.
+	// Faint, italic, blinking, concealed and crossed out text and
+	// 256 and 24-bit colors are removed from the colors f uses,
+	// and the text of each token is followed by a reset if its
+	// color leaves any attributes set.
+	PagerSafe bool
+
+	// MaxColors, if positive, is the maximum number of distinct
+	// colors, not counting resets, that colorized output may use,
+	// for terminals and export targets with limited palettes.
+	// The colors f uses, in the order of SpaceColor, CommaColor
+	// and the other color fields, are quantized by repeatedly
+	// merging the two nearest of them into the one used first
+	// until at most MaxColors remain.  The distance between two
+	// colors is the squared Euclidean distance between their
+	// foreground RGB values plus that between their background
+	// RGB values, plus a fixed amount for each attribute such as
+	// bold set by only one of them.  Other colors, such as those
+	// of ArrayPositionColors, are mapped to the nearest remaining
+	// color once MaxColors colors are in use.  This is most useful
+	// with 256 and 24-bit color themes.
+	MaxColors int
+
+	// ResetPerLine specifies whether each line of colorized output
+	// should end with an explicit reset of all attributes, with
+	// tokens spanning several lines colored again on each line,
+	// so that no line depends on colors set by a previous one.
+	// This keeps colors from bleeding into or disappearing from
+	// lines that are scrolled into view or copied individually,
+	// at the cost of larger output.
+	ResetPerLine bool
+
+	// OutputEscape, if non-nil, is applied to the text of the
+	// output, but not to the escape sequences coloring it, before
+	// it is written.  This allows output to be sent to sinks that
+	// interpret some characters specially, such as web-based
+	// terminals that require < and & to be written as the HTML
+	// entities &lt; and &amp;, as html.EscapeString does.
+	OutputEscape func(s string) string
+
+	// GreppableSeparators specifies whether the space separating a
+	// field's colon from its value and the newlines separating
+	// elements should be written without color, so that patterns
+	// such as `: value` can be matched against the raw colorized
+	// output with tools like grep.  Separators are then not colored
+	// by SpaceColor, leaving gaps in any background color it sets,
+	// and adjacent tokens of the same color cannot share the
+	// escape sequences around a separator, making the output
+	// slightly larger.
+	GreppableSeparators bool
+
+	// noColor specifies whether output is written without color,
+	// see Plain.
+	noColor bool
 }
 
 // NewFormatter returns a new formatter.
@@ -280,30 +1177,324 @@ func NewFormatter() *Formatter {
 	return &Formatter{}
 }
 
+// Plain returns a copy of f that writes output without color,
+// regardless of its color fields, so that colorized and plain output
+// can be produced with the same settings.  With an Indent and no
+// other options set, its output matches that of json.Indent with an
+// empty prefix.
+func (f *Formatter) Plain() *Formatter {
+	g := f.clone()
+	g.noColor = true
+	return g
+}
+
 func (f *Formatter) clone() *Formatter {
 	var g Formatter
 	g = *f
 	return &g
 }
 
+// dataOnly returns a copy of f without the options adding lines
+// before or after the output as a whole, such as RootLabel,
+// MarkdownFence and ShowSummary, for Format methods that lay out
+// values in forms other than a single JSON document.
+func (f *Formatter) dataOnly() *Formatter {
+	g := f.clone()
+	g.AppendLegend = false
+	g.ShowSummary = false
+	g.RootLabel = ""
+	g.ShowBreadcrumb = false
+	g.MarkdownFence = false
+	return g
+}
+
 func (f *Formatter) setIndent(prefix, indent string) {
 	f.Prefix = prefix
 	f.Indent = indent
 }
 
+// escapeOutput returns s escaped by OutputEscape, if it is set.
+func (f *Formatter) escapeOutput(s string) string {
+	if f.OutputEscape == nil {
+		return s
+	}
+	return f.OutputEscape(s)
+}
+
 func (f *Formatter) setEscapeHTML(on bool) {
 	f.EscapeHTML = on
 }
 
 // Format appends to dst a colorized form of the JSON-encoded src.
+// Adjacent tokens of the same color share a single escape sequence
+// setting it, and any color is closed before each newline, so that
+// each line of output displays correctly on its own.
 func (f *Formatter) Format(dst io.Writer, src []byte) error {
 	return newFormatterState(f, dst).format(dst, src, false)
 }
 
+// FormatTolerant is like Format but behaves as if f's Tolerant field
+// is true, additionally returning the number of invalid values and
+// field names that were replaced by placeholders returned by f's
+// OnUnparseable field.
+func (f *Formatter) FormatTolerant(dst io.Writer, src []byte) (int, error) {
+	g := f.clone()
+	g.Tolerant = true
+	fs := newFormatterState(g, dst)
+	err := fs.format(dst, src, false)
+	return fs.unparseable, err
+}
+
+// FormatReader is like Format but reads the JSON-encoded input from
+// src, writing colorized output to dst as each token is read rather
+// than after reading all of src.  If dst has a Flush method, such
+// as a *bufio.Writer or an http.Flusher, it is called after each
+// element of a top-level array is written so that the elements of
+// a large array arriving incrementally are displayed as they
+// arrive.  If f's Tolerant field is true, src is read in its
+// entirety before formatting begins.
+func (f *Formatter) FormatReader(dst io.Writer, src io.Reader) error {
+	fs := newFormatterState(f, dst)
+	fs.flush = flushFunc(dst)
+	return fs.formatReader(src)
+}
+
+// FormatReaderTo is like FormatReader but returns the colorized
+// output rather than writing it to a writer, so that callers with an
+// io.Reader need not read all of it into memory before formatting.
+func (f *Formatter) FormatReaderTo(src io.Reader) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := f.FormatReader(buf, src)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatFile is like FormatReader but reads the JSON-encoded input
+// from the named file.  If dst is an *os.File that is not a
+// terminal, such as a redirected os.Stdout, the output is written
+// without color.
+func (f *Formatter) FormatFile(dst io.Writer, name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fs := newFormatterState(f, dst)
+	fs.flush = flushFunc(dst)
+	if x, ok := dst.(*os.File); ok && !isTerminal(x) {
+		fs.plain = true
+	}
+	return fs.formatReader(file)
+}
+
+// isTerminal reports whether file is a terminal.
+func isTerminal(file *os.File) bool {
+	fd := file.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// flushFunc returns a function calling w's Flush method, or nil if
+// w does not have one.
+func flushFunc(w io.Writer) func() {
+	switch x := w.(type) {
+	case interface{ Flush() error }:
+		return func() { x.Flush() }
+	case interface{ Flush() }:
+		return x.Flush
+	}
+	return nil
+}
+
 func (f *Formatter) format(dst io.Writer, src []byte, terminateWithNewline bool) error {
 	return newFormatterState(f, dst).format(dst, src, terminateWithNewline)
 }
 
+// WillColorize reports whether output produced by f will contain
+// color escape sequences given its current settings.  This is false
+// if every color f uses has been disabled, either individually or
+// globally by color.NoColor, for example because stdout is not a
+// terminal or the NO_COLOR environment variable is set, or if
+// MarkdownFence is true.
+func (f *Formatter) WillColorize() bool {
+	if f.MarkdownFence || f.noColor {
+		return false
+	}
+	for _, c := range f.usedColors() {
+		if c != nil && c.SprintfFunc()("%s", "x") != "x" {
+			return true
+		}
+	}
+	return false
+}
+
+// usedColors returns the colors that may be used by f given its
+// current settings.
+func (f *Formatter) usedColors() []SprintfFuncer {
+	colors := []SprintfFuncer{
+		f.spaceColor(),
+		f.commaColor(),
+		f.colonColor(),
+		f.objectColor(),
+		f.arrayColor(),
+		f.fieldQuoteColor(),
+		f.fieldColor(),
+		f.stringQuoteColor(),
+		f.stringColor(),
+		f.trueColor(),
+		f.falseColor(),
+		f.numberColor(),
+		f.nullColor(),
+	}
+	if f.omits() {
+		colors = append(colors, f.moreColor(), f.ellipsisColor())
+	}
+	if len(f.FocusPath) > 0 {
+		colors = append(colors, f.dimColor())
+	}
+	if f.Tolerant && f.OnUnparseable != nil {
+		colors = append(colors, f.unparseableColor())
+	}
+	if f.NegativeNumberColor != nil {
+		colors = append(colors, f.NegativeNumberColor)
+	}
+	if f.ExponentColor != nil {
+		colors = append(colors, f.ExponentColor)
+	}
+	if f.HighlightEmptyStrings {
+		colors = append(colors, f.emptyStringColor())
+	}
+	if f.HighlightZeros {
+		colors = append(colors, f.zeroColor())
+	}
+	if f.HighlightStringNumbers {
+		colors = append(colors, f.stringNumberColor())
+	}
+	if f.ArrayNullColor != nil {
+		colors = append(colors, f.ArrayNullColor)
+	}
+	if f.FieldNullColor != nil {
+		colors = append(colors, f.FieldNullColor)
+	}
+	if len(f.Annotations) > 0 || f.AnnotateDetectedFormats {
+		colors = append(colors, f.commentColor())
+	}
+	if f.DebugOffsets || f.ShowSummary || f.ShowContainerCounts {
+		colors = append(colors, f.dimColor())
+	}
+	if f.ShowTrailingSpaceInStrings || f.HighlightKeyWhitespace {
+		colors = append(colors, f.trailingSpaceColor())
+	}
+	if f.VisibleStringWhitespace || f.VisibleKeyWhitespace {
+		colors = append(colors, f.visibleWhitespaceColor())
+	}
+	if f.InferUnits {
+		colors = append(colors, f.unitColor())
+	}
+	if f.DetectMarkupInStrings {
+		colors = append(colors, f.markupTagColor(), f.markupAttributeColor())
+	}
+	if f.UnwrapEmbeddedJSON {
+		colors = append(colors, f.embeddedColor())
+	}
+	if len(f.RootLabel) > 0 {
+		colors = append(colors, f.rootLabelColor())
+	}
+	if f.HighlightReplacementChars {
+		colors = append(colors, f.replacementCharColor())
+	}
+	if f.RootContainerColor != nil {
+		colors = append(colors, f.RootContainerColor)
+	}
+	if f.Classify != nil {
+		for _, c := range f.LabelColors {
+			colors = append(colors, c)
+		}
+	}
+	if f.HashKeyColors {
+		colors = append(colors, f.keyColorPalette()...)
+	}
+	colors = append(colors, f.ArrayPositionColors...)
+	colors = append(colors, f.IndentBandColors...)
+	for _, c := range f.TypeColors {
+		colors = append(colors, c)
+	}
+	if f.StringerColor != nil {
+		colors = append(colors, f.StringerColor)
+	}
+	return colors
+}
+
+// omits reports whether f may omit parts of its output, displaying
+// indicators colored with MoreColor in their place.
+func (f *Formatter) omits() bool {
+	return f.MaxArrayElements > 0 || f.MaxObjectFields > 0 || f.MaxLines > 0 ||
+		len(f.ExpandObjectsWithKey) > 0
+}
+
+// kindColor returns the color used for tokens of the given kind.
+func (f *Formatter) kindColor(kind TokenKind) SprintfFuncer {
+	switch kind {
+	case TokenSpace:
+		return f.spaceColor()
+	case TokenComma:
+		return f.commaColor()
+	case TokenColon:
+		return f.colonColor()
+	case TokenObject:
+		return f.objectColor()
+	case TokenArray:
+		return f.arrayColor()
+	case TokenFieldQuote:
+		return f.fieldQuoteColor()
+	case TokenField:
+		return f.fieldColor()
+	case TokenStringQuote:
+		return f.stringQuoteColor()
+	case TokenString:
+		return f.stringColor()
+	case TokenTrue:
+		return f.trueColor()
+	case TokenFalse:
+		return f.falseColor()
+	case TokenNumber:
+		return f.numberColor()
+	case TokenNull:
+		return f.nullColor()
+	case TokenMore:
+		return f.moreColor()
+	case TokenEllipsis:
+		return f.ellipsisColor()
+	case TokenUnparseable:
+		return f.unparseableColor()
+	case TokenComment:
+		return f.commentColor()
+	case TokenOffset:
+		return f.dimColor()
+	case TokenTrailingSpace:
+		return f.trailingSpaceColor()
+	case TokenVisibleWhitespace:
+		return f.visibleWhitespaceColor()
+	case TokenUnit:
+		return f.unitColor()
+	case TokenMarkupTag:
+		return f.markupTagColor()
+	case TokenMarkupAttribute:
+		return f.markupAttributeColor()
+	case TokenEmbedded:
+		return f.embeddedColor()
+	case TokenRootLabel:
+		return f.rootLabelColor()
+	case TokenExponent:
+		return f.exponentColor()
+	case TokenReplacementChar:
+		return f.replacementCharColor()
+	}
+	return f.spaceColor()
+}
+
 func (f *Formatter) spaceColor() SprintfFuncer {
 	if f.SpaceColor != nil {
 		return f.SpaceColor
@@ -343,6 +1534,9 @@ func (f *Formatter) fieldQuoteColor() SprintfFuncer {
 	if f.FieldQuoteColor != nil {
 		return f.FieldQuoteColor
 	}
+	if f.QuoteColor != nil {
+		return f.QuoteColor
+	}
 	return DefaultFieldQuoteColor
 }
 
@@ -357,6 +1551,9 @@ func (f *Formatter) stringQuoteColor() SprintfFuncer {
 	if f.StringQuoteColor != nil {
 		return f.StringQuoteColor
 	}
+	if f.QuoteColor != nil {
+		return f.QuoteColor
+	}
 	return DefaultStringQuoteColor
 }
 
@@ -395,10 +1592,320 @@ func (f *Formatter) nullColor() SprintfFuncer {
 	return DefaultNullColor
 }
 
+func (f *Formatter) moreColor() SprintfFuncer {
+	if f.MoreColor != nil {
+		return f.MoreColor
+	}
+	return DefaultMoreColor
+}
+
+func (f *Formatter) ellipsisColor() SprintfFuncer {
+	if f.EllipsisColor != nil {
+		return f.EllipsisColor
+	}
+	return f.moreColor()
+}
+
+func (f *Formatter) ellipsis() string {
+	if len(f.Ellipsis) > 0 {
+		return f.Ellipsis
+	}
+	return "…"
+}
+
+func (f *Formatter) dimColor() SprintfFuncer {
+	if f.DimColor != nil {
+		return f.DimColor
+	}
+	return DefaultDimColor
+}
+
+func (f *Formatter) unparseableColor() SprintfFuncer {
+	if f.UnparseableColor != nil {
+		return f.UnparseableColor
+	}
+	return DefaultUnparseableColor
+}
+
+func (f *Formatter) errorColor() SprintfFuncer {
+	if f.ErrorColor != nil {
+		return f.ErrorColor
+	}
+	return DefaultErrorColor
+}
+
+func (f *Formatter) trailingSpaceColor() SprintfFuncer {
+	if f.TrailingSpaceColor != nil {
+		return f.TrailingSpaceColor
+	}
+	return DefaultTrailingSpaceColor
+}
+
+func (f *Formatter) visibleWhitespaceColor() SprintfFuncer {
+	if f.VisibleWhitespaceColor != nil {
+		return f.VisibleWhitespaceColor
+	}
+	return DefaultVisibleWhitespaceColor
+}
+
+func (f *Formatter) badges() map[TokenKind]string {
+	if f.Badges != nil {
+		return f.Badges
+	}
+	return DefaultBadges
+}
+
+func (f *Formatter) booleanStrings() map[string]bool {
+	if f.BooleanStrings != nil {
+		return f.BooleanStrings
+	}
+	return DefaultBooleanStrings
+}
+
+func (f *Formatter) whitespaceGlyphs() map[rune]string {
+	if f.WhitespaceGlyphs != nil {
+		return f.WhitespaceGlyphs
+	}
+	return DefaultWhitespaceGlyphs
+}
+
+func (f *Formatter) unitColor() SprintfFuncer {
+	if f.UnitColor != nil {
+		return f.UnitColor
+	}
+	return DefaultUnitColor
+}
+
+func (f *Formatter) markupTagColor() SprintfFuncer {
+	if f.MarkupTagColor != nil {
+		return f.MarkupTagColor
+	}
+	return DefaultMarkupTagColor
+}
+
+func (f *Formatter) markupAttributeColor() SprintfFuncer {
+	if f.MarkupAttributeColor != nil {
+		return f.MarkupAttributeColor
+	}
+	return DefaultMarkupAttributeColor
+}
+
+func (f *Formatter) exponentColor() SprintfFuncer {
+	if f.ExponentColor != nil {
+		return f.ExponentColor
+	}
+	return f.numberColor()
+}
+
+func (f *Formatter) emptyStringColor() SprintfFuncer {
+	if f.EmptyStringColor != nil {
+		return f.EmptyStringColor
+	}
+	return DefaultEmptyStringColor
+}
+
+func (f *Formatter) zeroColor() SprintfFuncer {
+	if f.ZeroColor != nil {
+		return f.ZeroColor
+	}
+	return DefaultZeroColor
+}
+
+func (f *Formatter) stringNumberColor() SprintfFuncer {
+	if f.StringNumberColor != nil {
+		return f.StringNumberColor
+	}
+	return DefaultStringNumberColor
+}
+
+func (f *Formatter) rootLabelColor() SprintfFuncer {
+	if f.RootLabelColor != nil {
+		return f.RootLabelColor
+	}
+	return DefaultRootLabelColor
+}
+
+func (f *Formatter) replacementCharColor() SprintfFuncer {
+	if f.ReplacementCharColor != nil {
+		return f.ReplacementCharColor
+	}
+	return DefaultReplacementCharColor
+}
+
+func (f *Formatter) embeddedColor() SprintfFuncer {
+	if f.EmbeddedColor != nil {
+		return f.EmbeddedColor
+	}
+	return DefaultEmbeddedColor
+}
+
+func (f *Formatter) keyColorPalette() []SprintfFuncer {
+	if len(f.KeyColorPalette) > 0 {
+		return f.KeyColorPalette
+	}
+	return DefaultKeyColorPalette
+}
+
+func (f *Formatter) commentColor() SprintfFuncer {
+	if f.CommentColor != nil {
+		return f.CommentColor
+	}
+	return DefaultCommentColor
+}
+
+func (f *Formatter) changedColor() SprintfFuncer {
+	if f.ChangedColor != nil {
+		return f.ChangedColor
+	}
+	return DefaultChangedColor
+}
+
+func (f *Formatter) addedColor() SprintfFuncer {
+	if f.AddedColor != nil {
+		return f.AddedColor
+	}
+	return DefaultAddedColor
+}
+
+func (f *Formatter) deletedColor() SprintfFuncer {
+	if f.DeletedColor != nil {
+		return f.DeletedColor
+	}
+	return DefaultDeletedColor
+}
+
+func (f *Formatter) largeIntLimit() uint64 {
+	if f.LargeIntLimit > 0 {
+		return f.LargeIntLimit
+	}
+	return 1<<53 - 1
+}
+
+func (f *Formatter) fenceLanguage() string {
+	if len(f.FenceLanguage) > 0 {
+		return f.FenceLanguage
+	}
+	return "json"
+}
+
+// limitPrecision returns the number n rounded to at most prec digits
+// after its decimal point without trailing zeros, or n unchanged if
+// it has no more than prec such digits.  The mantissa of a number in
+// exponent form is rounded instead, keeping its exponent as is.
+func limitPrecision(n string, prec int) string {
+	mantissa, exponent := n, ""
+	if i := strings.IndexAny(n, "eE"); i >= 0 {
+		mantissa, exponent = n[:i], n[i:]
+	}
+	i := strings.IndexByte(mantissa, '.')
+	if i < 0 || len(mantissa)-i-1 <= prec {
+		return n
+	}
+	v, err := strconv.ParseFloat(mantissa, 64)
+	if err != nil {
+		return n
+	}
+	mantissa = strconv.FormatFloat(v, 'f', prec, 64)
+	if strings.IndexByte(mantissa, '.') >= 0 {
+		mantissa = strings.TrimSuffix(strings.TrimRight(mantissa, "0"), ".")
+	}
+	return mantissa + exponent
+}
+
+// literalGlyphs are the glyphs displayed in place of literals when
+// GlyphLiterals is true.
+var literalGlyphs = map[TokenKind]string{
+	TokenTrue:  "✓",
+	TokenFalse: "✗",
+	TokenNull:  "∅",
+}
+
 type formatterState struct {
+	f       *Formatter
 	compact bool
-	indent  string
-	frames  []*frame
+	// indent is the indentation of the most deeply nested level
+	// printed so far, whose prefixes give the indentation of
+	// the other levels.  It belongs to a single call of a Format
+	// method, so f's Indent may change between calls.
+	indent string
+	frames []*frame
+	focus  []string
+	dim    bool
+	flush  func()
+
+	// indentOverrides are the parsed IndentOverrides, longest
+	// pointer first.
+	indentOverrides []indentOverride
+
+	// unparseable is the number of unparseable tokens replaced
+	// by placeholders.
+	unparseable int
+
+	// lineStart is true if nothing has been written to the
+	// current line of output.
+	lineStart bool
+	margin    string
+
+	// column is the number of runes written to the current line
+	// of output, which is only tracked if BreakFunc is set.
+	column int
+	// pendingBreak, if non-nil, prints a line break to be
+	// decided by BreakFunc before the next token, see
+	// resolveBreak.
+	pendingBreak func(s string)
+	// skipIndent is true if the next indentation is omitted
+	// because BreakFunc decided not to break the line.
+	skipIndent bool
+
+	// plain is true if tokens are written without color.
+	plain bool
+
+	// line, if non-nil, is the line of output currently being
+	// buffered, see bufferLines.
+	line *annotatedLine
+
+	styles [numTokenKinds]style
+	dimmed style
+	bands  []style
+
+	// valueColor, if non-nil, overrides the color of the scalar
+	// value, field name or delimiter currently being printed.
+	valueColor SprintfFuncer
+	// colorValue, if non-nil, is called for each scalar value or,
+	// if field is true, field name t at path.  It returns a color
+	// overriding that of t, or nil to leave it unchanged.
+	colorValue func(path []string, t json.Token, field bool) SprintfFuncer
+	// redact, if non-nil, is called for each scalar value with
+	// its path and reports whether the value is replaced by the
+	// summary of its type displayed in OutlineMode.
+	redact func(path []string) bool
+
+	// offsets, if non-nil, reports the input offset of the end
+	// of each token as it is read, see DebugOffsets, and offset
+	// is that of the most recently read token.
+	offsets inputOffsetter
+	offset  int64
+
+	// cells are the buffered fields of the object being packed
+	// into columns and endColumns writes them, see startColumns.
+	cells      [][]emittedToken
+	endColumns func()
+
+	// summary describes the outermost value, see ShowSummary.
+	summary *rootSummary
+
+	// deadline, if non-zero, is the time by which formatting must
+	// finish and tokens is the number of tokens read, see
+	// FormatWithDeadline.
+	deadline time.Time
+	tokens   int
+
+	// emit writes the text s of a token of the given kind using
+	// style st.
+	emit func(kind TokenKind, st style, s string)
+	// closeColor closes the color of the tokens most recently
+	// written by the default emit, see newFormatterState.
+	closeColor func()
 
 	printSpace  func(s string, force bool)
 	printComma  func()
@@ -410,23 +1917,43 @@ type formatterState struct {
 	printBool   func(b bool)
 	printNumber func(n json.Number)
 	printNull   func()
+	printMore   func(hidden int)
 	printIndent func()
+
+	// printSeparator is like printSpace for the spaces and
+	// newlines separating values, see GreppableSeparators.
+	printSeparator func(s string)
+}
+
+// style is a color used to print tokens.  A zero style prints
+// tokens without color.
+type style struct {
+	color   SprintfFuncer
+	sprintf sprintfFunc
+
+	// prefix and suffix are the escape sequences the color writes
+	// before and after text, if affixed is true.  Colors that
+	// otherwise transform text are not affixed.
+	prefix, suffix string
+	affixed        bool
+}
+
+// affixMarker is text used to find the escape sequences a color
+// writes before and after text.
+const affixMarker = "\x00"
+
+func newStyle(c SprintfFuncer) style {
+	st := style{color: c, sprintf: c.SprintfFunc()}
+	out := st.sprintf("%s", affixMarker)
+	if i := strings.Index(out, affixMarker); i >= 0 && strings.Count(out, affixMarker) == 1 {
+		st.prefix, st.suffix = out[:i], out[i+len(affixMarker):]
+		st.affixed = true
+	}
+	return st
 }
 
 func newFormatterState(f *Formatter, dst io.Writer) *formatterState {
-	sprintfSpace := f.spaceColor().SprintfFunc()
-	sprintfComma := f.commaColor().SprintfFunc()
-	sprintfColon := f.colonColor().SprintfFunc()
-	sprintfObject := f.objectColor().SprintfFunc()
-	sprintfArray := f.arrayColor().SprintfFunc()
-	sprintfFieldQuote := f.fieldQuoteColor().SprintfFunc()
-	sprintfField := f.fieldColor().SprintfFunc()
-	sprintfStringQuote := f.stringQuoteColor().SprintfFunc()
-	sprintfString := f.stringColor().SprintfFunc()
-	sprintfTrue := f.trueColor().SprintfFunc()
-	sprintfFalse := f.falseColor().SprintfFunc()
-	sprintfNumber := f.numberColor().SprintfFunc()
-	sprintfNull := f.nullColor().SprintfFunc()
+	var fs *formatterState
 
 	// json.Encoder.SetEscapeHTML was added in Go 1.7, we need to
 	// test to see if it exists
@@ -455,32 +1982,126 @@ func newFormatterState(f *Formatter, dst io.Writer) *formatterState {
 		return string(sbuf[1 : len(sbuf)-2]), nil
 	}
 
-	fs := &formatterState{
+	// scalarText returns the text displayed for the scalar value
+	// of the given kind whose JSON encoding is raw.
+	scalarText := func(kind TokenKind, raw string) string {
+		if f.ScalarTextFunc != nil {
+			if s, ok := f.ScalarTextFunc(kind, raw); ok {
+				return s
+			}
+		}
+		if f.GlyphLiterals {
+			if g, ok := literalGlyphs[kind]; ok {
+				return g
+			}
+		}
+		return raw
+	}
+
+	palette := f.palette()
+
+	// colorize returns s colored using style st.
+	colorize := func(st style, s string) string {
+		if st.sprintf == nil || len(s) == 0 {
+			return s
+		}
+		s = palette.remap(st.sprintf("%s", s))
+		if f.PagerSafe {
+			s = pagerSafe(s)
+		}
+		return s
+	}
+
+	resetPerLine := f.ResetPerLine && f.WillColorize()
+
+	// suffix closes the color of the most recently written
+	// tokens, which adjacent tokens of the same color share
+	// rather than each opening and closing the color.  It is
+	// only set by the default emit, so nothing is written when
+	// emit is replaced, as by FormatSpans and FormatRuns, whose
+	// dst is nil.  Text containing a newline closes the color
+	// rather than sharing it, so that each line of output ends
+	// with its colors reset and displays correctly on its own,
+	// as when filtered by grep.
+	var prefix, suffix string
+	coalesce := !f.PagerSafe && !resetPerLine
+	closeColor := func() {
+		if len(suffix) > 0 {
+			io.WriteString(dst, suffix)
+		}
+		prefix, suffix = "", ""
+	}
+
+	fs = &formatterState{
+		f:       f,
 		compact: len(f.Prefix) == 0 && len(f.Indent) == 0,
 		indent:  "",
 		frames: []*frame{
 			{},
 		},
+		dimmed:    newStyle(f.dimColor()),
+		lineStart: true,
+		margin:    strings.Repeat(" ", f.LeftMargin),
+		plain:     f.MarkdownFence || f.noColor,
+		emit: func(kind TokenKind, st style, s string) {
+			s = f.escapeOutput(s)
+			if coalesce && st.affixed && strings.IndexByte(s, '\n') < 0 {
+				if p := palette.remap(st.prefix); p != prefix || len(prefix) == 0 {
+					closeColor()
+					prefix, suffix = p, st.suffix
+					io.WriteString(dst, prefix)
+				}
+				io.WriteString(dst, s)
+				return
+			}
+			closeColor()
+			if !resetPerLine {
+				io.WriteString(dst, colorize(st, s))
+				return
+			}
+			for i, l := range strings.Split(s, "\n") {
+				if i > 0 {
+					io.WriteString(dst, "\x1b[0m\n")
+				}
+				io.WriteString(dst, colorize(st, l))
+			}
+		},
 		printComma: func() {
-			fmt.Fprint(dst, sprintfComma(","))
+			fs.print(TokenComma, ",")
 		},
 		printColon: func() {
-			fmt.Fprint(dst, sprintfColon(":"))
+			fs.print(TokenColon, ":")
 		},
 		printObject: func(t json.Delim) {
-			fmt.Fprint(dst, sprintfObject(t.String()))
+			fs.print(TokenObject, t.String())
 		},
 		printArray: func(t json.Delim) {
-			fmt.Fprint(dst, sprintfArray(t.String()))
+			fs.print(TokenArray, t.String())
 		},
 		printField: func(k string) error {
+			if f.KeyTransform != nil {
+				k = f.KeyTransform(fs.path(), k)
+			}
 			encStr, err := encodeString(k)
 			if err != nil {
 				return err
 			}
-			fmt.Fprint(dst, sprintfFieldQuote(`"`))
-			fmt.Fprint(dst, sprintfField("%s", encStr))
-			fmt.Fprint(dst, sprintfFieldQuote(`"`))
+			text, leading, trailing := encStr, "", ""
+			if f.HighlightKeyWhitespace {
+				trimmed := strings.TrimLeft(text, " ")
+				leading = strings.Repeat("·", len(text)-len(trimmed))
+				text = strings.TrimRight(trimmed, " ")
+				trailing = strings.Repeat("·", len(trimmed)-len(text))
+			}
+			fs.print(TokenFieldQuote, `"`)
+			fs.print(TokenTrailingSpace, leading)
+			if f.VisibleKeyWhitespace {
+				fs.printVisibleWhitespace(TokenField, text)
+			} else {
+				fs.printText(TokenField, text)
+			}
+			fs.print(TokenTrailingSpace, trailing)
+			fs.print(TokenFieldQuote, `"`)
 			return nil
 		},
 		printString: func(s string) error {
@@ -488,57 +2109,550 @@ func newFormatterState(f *Formatter, dst io.Writer) *formatterState {
 			if err != nil {
 				return err
 			}
-			fmt.Fprint(dst, sprintfStringQuote(`"`))
-			fmt.Fprint(dst, sprintfString("%s", encStr))
-			fmt.Fprint(dst, sprintfStringQuote(`"`))
+			text, trailing := scalarText(TokenString, encStr), ""
+			if f.ShowTrailingSpaceInStrings {
+				trimmed := strings.TrimRight(text, " ")
+				trailing = strings.Repeat("·", len(text)-len(trimmed))
+				text = trimmed
+			}
+			fs.print(TokenStringQuote, `"`)
+			if f.VisibleStringWhitespace {
+				fs.printVisibleWhitespace(TokenString, text)
+			} else if f.DetectMarkupInStrings && strings.IndexByte(text, '<') >= 0 {
+				fs.printMarkup(text)
+			} else {
+				fs.printText(TokenString, text)
+			}
+			fs.print(TokenTrailingSpace, trailing)
+			fs.print(TokenStringQuote, `"`)
 			return nil
 		},
 		printBool: func(b bool) {
 			if b {
-				fmt.Fprint(dst, sprintfTrue("%v", b))
+				fs.print(TokenTrue, scalarText(TokenTrue, "true"))
 			} else {
-				fmt.Fprint(dst, sprintfFalse("%v", b))
+				fs.print(TokenFalse, scalarText(TokenFalse, "false"))
 			}
 		},
 		printNumber: func(n json.Number) {
-			fmt.Fprint(dst, sprintfNumber("%v", n))
+			if f.QuoteLargeInts && isLargeInt(n.String(), f.largeIntLimit()) {
+				fs.print(TokenStringQuote, `"`)
+				fs.print(TokenString, n.String())
+				fs.print(TokenStringQuote, `"`)
+				return
+			}
+			text := fs.f.numberText(n.String())
+			display := scalarText(TokenNumber, text)
+			if i := strings.IndexAny(text, "eE"); i > 0 && display == text && f.ExponentColor != nil {
+				fs.print(TokenNumber, text[:i])
+				fs.printDecoration(TokenExponent, text[i:])
+				return
+			}
+			fs.print(TokenNumber, display)
 		},
 		printNull: func() {
-			fmt.Fprint(dst, sprintfNull("null"))
+			fs.print(TokenNull, scalarText(TokenNull, "null"))
+		},
+		printMore: func(hidden int) {
+			fs.printIndicator(hidden, " (%d more)")
 		},
 	}
 
+	fs.closeColor = closeColor
+
+	for kind := range fs.styles {
+		fs.styles[kind] = newStyle(f.kindColor(TokenKind(kind)))
+	}
+
+	for _, c := range f.IndentBandColors {
+		st := fs.styles[TokenSpace]
+		if c != nil {
+			st = newStyle(c)
+		}
+		fs.bands = append(fs.bands, st)
+	}
+
 	fs.printSpace = func(s string, force bool) {
 		if fs.compact && !force {
 			return
 		}
-		fmt.Fprint(dst, sprintfSpace(s))
+		fs.print(TokenSpace, s)
+	}
+
+	fs.printSeparator = func(s string) {
+		if !f.GreppableSeparators {
+			fs.printSpace(s, false)
+		} else if !fs.compact {
+			fs.write(TokenSpace, style{}, s)
+		}
 	}
 
 	fs.printIndent = func() {
+		if fs.skipIndent {
+			fs.skipIndent = false
+			return
+		}
 		if fs.compact {
 			return
 		}
 		if len(f.Prefix) > 0 {
-			fmt.Fprint(dst, f.Prefix)
+			fs.write(TokenSpace, style{}, f.Prefix)
 		}
 		indent := fs.frame().indent
-		if indent > 0 {
+		if indent > 0 && len(fs.bands) > 0 && !fs.dim {
+			path := fs.path()
+			for i := 0; i < indent; i++ {
+				fs.write(TokenSpace, fs.bands[i%len(fs.bands)], fs.indentLevel(path, i))
+			}
+		} else if indent > 0 && len(fs.indentOverrides) > 0 {
+			path := fs.path()
+			for i := 0; i < indent; i++ {
+				fs.print(TokenSpace, fs.indentLevel(path, i))
+			}
+		} else if indent > 0 {
 			ilen := len(f.Indent) * indent
 			if len(fs.indent) < ilen {
 				fs.indent = strings.Repeat(f.Indent, indent)
 			}
-			fmt.Fprint(dst, sprintfSpace(fs.indent[:ilen]))
+			fs.print(TokenSpace, fs.indent[:ilen])
 		}
 	}
 
 	return fs
 }
 
+// print prints s as a token of the given kind.  Tokens outside the
+// subtree identified by FocusPath are dimmed, otherwise tokens use
+// the color of their kind unless the color of the current value has
+// been overridden.
+func (fs *formatterState) print(kind TokenKind, s string) {
+	st := fs.styles[kind]
+	if fs.dim {
+		st = fs.dimmed
+	} else if fs.valueColor != nil {
+		st = newStyle(fs.valueColor)
+	}
+	fs.write(kind, st, s)
+}
+
+// printIndicator prints the indicator reporting the number of
+// hidden elements, which is the ellipsis followed by hidden formatted
+// with format unless MoreIndicator is set.
+func (fs *formatterState) printIndicator(hidden int, format string) {
+	if fs.f.MoreIndicator != nil {
+		fs.print(TokenMore, fs.f.MoreIndicator(hidden))
+		return
+	}
+	fs.print(TokenEllipsis, fs.f.ellipsis())
+	fs.print(TokenMore, fmt.Sprintf(format, hidden))
+}
+
+// numberText returns the text of the number n displayed according to
+// NumberNotation and FloatPrecision.
+func (f *Formatter) numberText(n string) string {
+	text := formatNotation(n, f.NumberNotation)
+	if f.FloatPrecision > 0 {
+		text = limitPrecision(text, f.FloatPrecision)
+	}
+	if l, ok := f.numberLocale(); ok {
+		text = l.format(text)
+	}
+	return text
+}
+
+// printBadge prints the badge preceding the value t, see ValueBadges,
+// in the color of the value.
+func (fs *formatterState) printBadge(t json.Token) {
+	kind := valueTokenKind(t)
+	badge := fs.f.badges()[kind]
+	if len(badge) == 0 {
+		return
+	}
+	st := fs.styles[kind]
+	if fs.dim {
+		st = fs.dimmed
+	} else if fs.valueColor != nil {
+		st = newStyle(fs.valueColor)
+	}
+	fs.write(TokenBadge, st, badge+" ")
+}
+
+// printDecoration prints s as a token of the given kind decorating
+// the current value, which uses the color of its kind even if the
+// color of the value has been overridden.
+func (fs *formatterState) printDecoration(kind TokenKind, s string) {
+	st := fs.styles[kind]
+	if fs.dim {
+		st = fs.dimmed
+	}
+	fs.write(kind, st, s)
+}
+
+// write writes the text s of a token of the given kind using style
+// st, first writing LeftMargin if s begins a new line of output.
+func (fs *formatterState) write(kind TokenKind, st style, s string) {
+	if len(s) == 0 {
+		return
+	}
+	space := fs.styles[TokenSpace]
+	if fs.plain {
+		st, space = style{}, style{}
+	}
+	if fs.lineStart && len(fs.margin) > 0 {
+		fs.emit(TokenSpace, space, fs.margin)
+		fs.column = utf8.RuneCountInString(fs.margin)
+	}
+	fs.emit(kind, st, s)
+	fs.lineStart = s[len(s)-1] == '\n'
+	if fs.f.BreakFunc != nil {
+		if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+			fs.column = utf8.RuneCountInString(s[i+1:])
+		} else {
+			fs.column += utf8.RuneCountInString(s)
+		}
+	}
+}
+
+// printVisibleWhitespace prints the JSON-encoded string s as a token
+// of the given kind, except for its whitespace characters, which are
+// printed as the glyphs given by WhitespaceGlyphs.
+func (fs *formatterState) printVisibleWhitespace(kind TokenKind, s string) {
+	glyphs := fs.f.whitespaceGlyphs()
+	start := 0
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == '\\' && i+1 < len(s) {
+			// escaped characters
+			size = 2
+			switch s[i+1] {
+			case 't':
+				r = '\t'
+			case 'n':
+				r = '\n'
+			case 'r':
+				r = '\r'
+			}
+		}
+		g, ok := glyphs[r]
+		if !ok || !unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+		fs.printText(kind, s[start:i])
+		fs.print(TokenVisibleWhitespace, g)
+		i += size
+		start = i
+	}
+	fs.printText(kind, s[start:])
+}
+
+// printText prints the text s of a string value or field name of
+// the given kind, coloring any replacement characters in it with
+// ReplacementCharColor if HighlightReplacementChars is set.
+func (fs *formatterState) printText(kind TokenKind, s string) {
+	if !fs.f.HighlightReplacementChars {
+		fs.print(kind, s)
+		return
+	}
+	for {
+		i := strings.IndexRune(s, utf8.RuneError)
+		if i < 0 {
+			break
+		}
+		fs.print(kind, s[:i])
+		fs.printDecoration(TokenReplacementChar, s[i:i+utf8.RuneLen(utf8.RuneError)])
+		s = s[i+utf8.RuneLen(utf8.RuneError):]
+	}
+	fs.print(kind, s)
+}
+
+// valueColorFor returns the color overriding that of the scalar
+// value or field name t of frame, or nil if there is none.
+func (fs *formatterState) valueColorFor(frame *frame, t json.Token) SprintfFuncer {
+	if fs.colorValue != nil {
+		if c := fs.colorValue(fs.path(), t, frame.inField()); c != nil {
+			return c
+		}
+	}
+	if c := fs.labelColor(frame, t); c != nil {
+		return c
+	}
+	if s, ok := t.(string); ok && (!frame.inField() || fs.f.ColorKeysAsValues) {
+		if c := fs.stringValueColor(s); c != nil {
+			return c
+		}
+	}
+	if n, ok := t.(json.Number); ok {
+		if c := fs.numberValueColor(n); c != nil {
+			return c
+		}
+	}
+	if k, ok := t.(string); ok && frame.inField() && fs.f.HashKeyColors {
+		return fs.f.hashKeyColor(k)
+	}
+	if c := fs.positionColor(frame); c != nil {
+		return c
+	}
+	if t == nil {
+		return fs.nullColor(frame)
+	}
+	return nil
+}
+
+// nullColor returns the color of a null value of frame according to
+// whether it is an array element or object field value, or nil if it
+// uses NullColor.
+func (fs *formatterState) nullColor(frame *frame) SprintfFuncer {
+	switch {
+	case frame.inArray():
+		return fs.f.ArrayNullColor
+	case frame.inObject():
+		return fs.f.FieldNullColor
+	}
+	return nil
+}
+
+// labelColor returns the color of the label Classify returns for the
+// scalar value or field name t of frame, or nil if there is none.
+func (fs *formatterState) labelColor(frame *frame, t json.Token) SprintfFuncer {
+	if fs.f.Classify == nil {
+		return nil
+	}
+	var kind TokenKind
+	var raw string
+	switch x := t.(type) {
+	case string:
+		kind, raw = TokenString, x
+		if frame.inField() {
+			kind = TokenField
+		}
+	case json.Number:
+		kind, raw = TokenNumber, x.String()
+	case bool:
+		kind, raw = TokenFalse, "false"
+		if x {
+			kind, raw = TokenTrue, "true"
+		}
+	case nil:
+		kind, raw = TokenNull, "null"
+	default:
+		return nil
+	}
+	label := fs.f.Classify(fs.path(), kind, raw)
+	if len(label) == 0 {
+		return nil
+	}
+	return fs.f.LabelColors[label]
+}
+
+// hashKeyColor returns the color of KeyColorPalette chosen by a hash
+// of the field name k.
+func (f *Formatter) hashKeyColor(k string) SprintfFuncer {
+	palette := f.keyColorPalette()
+	h := fnv.New32a()
+	io.WriteString(h, k)
+	return palette[h.Sum32()%uint32(len(palette))]
+}
+
+// numberValueColor returns the color selected for the number value
+// n by the rules matching number values, or nil if no rule matches.
+func (fs *formatterState) numberValueColor(n json.Number) SprintfFuncer {
+	if fs.f.HighlightZeros && isZero(n) {
+		return fs.f.zeroColor()
+	}
+	if fs.f.NegativeNumberColor != nil && isNegative(n) {
+		return fs.f.NegativeNumberColor
+	}
+	return nil
+}
+
+// isZero reports whether n is zero, including negative zero.
+func isZero(n json.Number) bool {
+	digits := false
+	for _, c := range n {
+		switch {
+		case c == 'e' || c == 'E':
+			return digits
+		case c >= '1' && c <= '9':
+			return false
+		case c == '0':
+			digits = true
+		}
+	}
+	return digits
+}
+
+// isNegative reports whether n is less than zero.
+func isNegative(n json.Number) bool {
+	return strings.HasPrefix(string(n), "-") && !isZero(n)
+}
+
+// stringValueColor returns the color selected for the string value
+// s by the rules matching the contents of string values, or nil if
+// no rule matches.  Field names are only matched against these
+// rules if ColorKeysAsValues is true.
+func (fs *formatterState) stringValueColor(s string) SprintfFuncer {
+	if fs.f.HighlightEmptyStrings && len(s) == 0 {
+		return fs.f.emptyStringColor()
+	}
+	if fs.f.HighlightBooleanStrings {
+		if b, ok := fs.f.booleanStrings()[strings.ToLower(s)]; ok {
+			if b {
+				return fs.f.trueColor()
+			}
+			return fs.f.falseColor()
+		}
+	}
+	if fs.f.HighlightStringNumbers && isNumber(s) {
+		return fs.f.stringNumberColor()
+	}
+	return nil
+}
+
+// isNumber reports whether s is a JSON number.
+func isNumber(s string) bool {
+	if len(s) == 0 || s[0] != '-' && (s[0] < '0' || s[0] > '9') || s[len(s)-1] < '0' || s[len(s)-1] > '9' {
+		return false
+	}
+	return json.Valid([]byte(s))
+}
+
+// positionColor returns the color selected by ArrayPositionColors
+// for the current value of frame, or nil if there is none.
+func (fs *formatterState) positionColor(frame *frame) SprintfFuncer {
+	colors := fs.f.ArrayPositionColors
+	if !frame.inArray() || len(colors) == 0 {
+		return nil
+	}
+	return colors[(frame.index-1)%len(colors)]
+}
+
 func (fs *formatterState) frame() *frame {
 	return fs.frames[len(fs.frames)-1]
 }
 
+// path returns the path to the current value, made up of the field
+// name or array index identifying the value within each enclosing
+// frame.
+func (fs *formatterState) path() []string {
+	path := make([]string, 0, len(fs.frames)-1)
+	for _, f := range fs.frames[1:] {
+		path = append(path, f.segment())
+	}
+	return path
+}
+
+// updateFocus determines whether tokens about to be printed lie
+// outside the subtree identified by FocusPath.  If container is
+// true, the tokens belong to the current frame itself rather than
+// its current value.
+func (fs *formatterState) updateFocus(container bool) {
+	if fs.focus == nil {
+		return
+	}
+	path := fs.path()
+	if container {
+		path = path[:len(path)-1]
+	}
+	fs.dim = !hasPathPrefix(path, fs.focus)
+}
+
+// printBreadcrumb prints a line showing the path to the subtree
+// identified by FocusPath.
+func (fs *formatterState) printBreadcrumb() {
+	if len(fs.f.RootLabel) > 0 {
+		fs.write(TokenRootLabel, fs.styles[TokenRootLabel], fs.f.RootLabel)
+	} else {
+		fs.write(TokenSpace, fs.dimmed, "root")
+	}
+	for _, seg := range fs.focus {
+		fs.write(TokenSpace, fs.dimmed, " ▸ ")
+		if isIndex(seg) {
+			fs.printDecoration(TokenNumber, "["+seg+"]")
+		} else {
+			fs.printDecoration(TokenField, seg)
+		}
+	}
+	fs.printSpace("\n", true)
+}
+
+// isIndex reports whether the path segment seg is made up of digits
+// and may therefore be an array index.
+func isIndex(seg string) bool {
+	if len(seg) == 0 {
+		return false
+	}
+	for _, c := range seg {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// objectSummary is a token replacing an object with the given number
+// of fields that does not have the field ExpandObjectsWithKey.
+type objectSummary int
+
+// summarizeObject returns the token to display for the object whose
+// opening delimiter has been read from r, which is either the
+// opening delimiter or, if the object does not have the field
+// ExpandObjectsWithKey, an objectSummary replacing the entire
+// object.  It also reports whether the object has the field.
+func (fs *formatterState) summarizeObject(r *replayReader) (json.Token, bool, error) {
+	keys, _, err := objectFields(r)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, k := range keys {
+		if k == fs.f.ExpandObjectsWithKey {
+			return json.Delim('{'), true, nil
+		}
+	}
+	if len(keys) == 0 {
+		return json.Delim('{'), false, nil
+	}
+	err = skipValue(r, json.Delim('{'))
+	if err != nil {
+		return nil, false, err
+	}
+	return objectSummary(len(keys)), false, nil
+}
+
+// inline reports whether the container whose opening delimiter t
+// has been read from r should be displayed inline, according to
+// ShouldExpand.
+func (fs *formatterState) inline(r *replayReader, t json.Delim) (bool, error) {
+	children, width, err := measureContainer(r, t)
+	if err != nil {
+		return false, err
+	}
+	return !fs.f.ShouldExpand(fs.path(), byte(t), children, width), nil
+}
+
+// needsReplay reports whether the tokens of containers must be read
+// before the containers are displayed, requiring the tokens to be
+// read through a replayReader.
+func (fs *formatterState) needsReplay() bool {
+	// ShouldExpand is passed the width of each container
+	measure := fs.f.ShouldExpand != nil && !fs.compact
+	// objects are collapsed unless they have ExpandObjectsWithKey
+	summarize := len(fs.f.ExpandObjectsWithKey) > 0
+	// objects are packed into columns if they hold only scalars
+	columns := fs.f.ObjectColumns > 1 && !fs.compact
+	// the fields of objects are sorted by KeyOrder
+	reorder := fs.f.KeyOrder != nil
+	// embedded JSON is unread as the tokens it encodes
+	unwrap := fs.f.UnwrapEmbeddedJSON
+	// BreakFunc is passed the element following each break
+	breaks := fs.f.BreakFunc != nil && !fs.compact
+	// containers are preceded by the number of their children
+	counts := fs.f.ShowContainerCounts && !fs.compact
+	// arrays of numbers are padded to the widest of their numbers
+	align := fs.f.AlignNumbers && !fs.compact
+	return measure || summarize || columns || reorder || unwrap || breaks || counts || align
+}
+
 func (fs *formatterState) enterFrame(t json.Delim, empty bool) *frame {
 	indent := fs.frames[len(fs.frames)-1].indent + 1
 	fs.frames = append(fs.frames, &frame{
@@ -555,9 +2669,76 @@ func (fs *formatterState) leaveFrame() *frame {
 	return fs.frame()
 }
 
+// arrayOutline is a token replacing an array with the given number
+// of elements in OutlineMode.
+type arrayOutline int
+
+// countElements consumes the remaining tokens of an array whose
+// opening delimiter has been read, returning its number of elements.
+func countElements(dec tokenReader) (int, error) {
+	n := 0
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return n, err
+		}
+		err = skipValue(dec, t)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	_, err := dec.Token()
+	return n, err
+}
+
+// formatOutline prints the summary of the scalar value t displayed
+// in OutlineMode.
+func (fs *formatterState) formatOutline(t json.Token) {
+	switch x := t.(type) {
+	case arrayOutline:
+		fs.print(TokenArray, fmt.Sprintf("[%d]", int(x)))
+	case string:
+		fs.print(TokenStringQuote, `"`)
+		fs.print(TokenString, fs.f.ellipsis())
+		fs.print(TokenStringQuote, `"`)
+	case json.Number:
+		fs.print(TokenNumber, "#")
+	case bool:
+		if x {
+			fs.print(TokenTrue, "bool")
+		} else {
+			fs.print(TokenFalse, "bool")
+		}
+	case nil:
+		fs.print(TokenNull, "null")
+	}
+}
+
+// printOffset prints the input offset of the most recently read
+// token.
+func (fs *formatterState) printOffset() {
+	s := fmt.Sprintf("/*%d*/", fs.offset)
+	fs.write(TokenOffset, fs.styles[TokenOffset], s)
+}
+
 func (fs *formatterState) formatToken(t json.Token) error {
+	if fs.offsets != nil {
+		defer fs.printOffset()
+	}
+	if (fs.f.OutlineMode || fs.redact != nil && fs.redact(fs.path())) && !fs.frame().inField() {
+		switch t.(type) {
+		case arrayOutline, string, json.Number, bool, nil:
+			fs.formatOutline(t)
+			return nil
+		}
+	}
 	switch x := t.(type) {
 	case json.Delim:
+		if len(fs.frames) == 1 && fs.f.RootContainerColor != nil {
+			fs.valueColor = fs.f.RootContainerColor
+			defer func() { fs.valueColor = nil }()
+		}
 		if x == json.Delim('{') || x == json.Delim('}') {
 			fs.printObject(x)
 		} else {
@@ -565,6 +2746,11 @@ func (fs *formatterState) formatToken(t json.Token) error {
 		}
 	case json.Number:
 		fs.printNumber(x)
+		if fs.f.InferUnits {
+			if u := fs.unitText(x); len(u) > 0 {
+				fs.printDecoration(TokenUnit, " ("+u+")")
+			}
+		}
 	case string:
 		if !fs.frame().inField() {
 			return fs.printString(x)
@@ -574,6 +2760,19 @@ func (fs *formatterState) formatToken(t json.Token) error {
 		fs.printBool(x)
 	case nil:
 		fs.printNull()
+	case byteSliceSummary:
+		fs.print(TokenMore, x.String())
+	case objectSummary:
+		fields := "fields"
+		if x == 1 {
+			fields = "field"
+		}
+		fs.printObject(json.Delim('{'))
+		fs.printIndicator(int(x), " (%d "+fields+")")
+		fs.printObject(json.Delim('}'))
+	case unparseable:
+		fs.unparseable++
+		fs.print(TokenUnparseable, fs.f.OnUnparseable(x))
 	default:
 		return fmt.Errorf("unknown type %T", t)
 	}
@@ -581,78 +2780,315 @@ func (fs *formatterState) formatToken(t json.Token) error {
 }
 
 func (fs *formatterState) format(dst io.Writer, src []byte, terminateWithNewline bool) error {
+	return fs.formatTokens(fs.decoder(src), terminateWithNewline)
+}
+
+// decoder returns a tokenReader reading the JSON-encoded src.
+func (fs *formatterState) decoder(src []byte) tokenReader {
+	if fs.f.Tolerant {
+		return newTolerantDecoder(src, fs.f.OnUnparseable != nil)
+	}
 	dec := json.NewDecoder(bytes.NewReader(src))
 	dec.UseNumber()
+	return dec
+}
 
-	frame := fs.frame()
+func (fs *formatterState) formatReader(src io.Reader) error {
+	if fs.f.Tolerant {
+		b, err := ioutil.ReadAll(src)
+		if err != nil {
+			return err
+		}
+		return fs.formatTokens(newTolerantDecoder(b, fs.f.OnUnparseable != nil), false)
+	}
+	dec := json.NewDecoder(src)
+	dec.UseNumber()
+	return fs.formatTokens(dec, false)
+}
+
+func (fs *formatterState) formatTokens(dec tokenReader, terminateWithNewline bool) error {
+	if len(fs.f.FocusPath) > 0 {
+		focus, err := parsePointer(fs.f.FocusPath)
+		if err != nil {
+			return err
+		}
+		fs.focus = focus
+	}
+	if len(fs.f.IndentOverrides) > 0 {
+		overrides, err := parseIndentOverrides(fs.f.IndentOverrides)
+		if err != nil {
+			return err
+		}
+		fs.indentOverrides = overrides
+	}
+
+	if fs.flush != nil {
+		defer fs.flush()
+	}
+	defer fs.closeColor()
+
+	if (len(fs.f.Annotations) > 0 || fs.f.AnnotateDetectedFormats) && !fs.compact || fs.f.MaxLines > 0 {
+		defer fs.bufferLines()()
+	}
+
+	if fs.f.MarkdownFence {
+		fs.write(TokenSpace, style{}, "```"+fs.f.fenceLanguage()+"\n")
+	}
+
+	if fs.f.ShowBreadcrumb && fs.focus != nil {
+		fs.printBreadcrumb()
+	} else if len(fs.f.RootLabel) > 0 {
+		fs.write(TokenRootLabel, fs.styles[TokenRootLabel], fs.f.RootLabel)
+		fs.printSpace("\n", true)
+	}
+
+	var replay *replayReader
+	if fs.needsReplay() {
+		replay = &replayReader{dec: dec}
+		dec = replay
+	}
 
-	// this variable indicates whether the original input
-	// is a JSON object or JSON array. This allows us to
-	// correctly print JSON scalar values such as strings,
-	// numbers, `true`, `false` and `null`.
+	if x, ok := dec.(inputOffsetter); ok && fs.f.DebugOffsets {
+		fs.offsets = x
+	}
 
-	inputIsObjectOrArray := false
+	frame := fs.frame()
 
 	for {
 		t, err := dec.Token()
 		if err == io.EOF {
 			break
 		}
+		if err == nil {
+			err = fs.checkDeadline()
+		}
 		if err != nil {
 			return err
 		}
+		if fs.offsets != nil {
+			// before More skips any following whitespace
+			fs.offset = fs.offsets.InputOffset()
+		}
+
+		embedded := false
+		if fs.f.UnwrapEmbeddedJSON {
+			t, embedded = fs.unwrapEmbedded(replay, frame, t)
+		}
+
+		if fs.pendingBreak != nil {
+			err = fs.resolveBreak(replay, t)
+			if err != nil {
+				return err
+			}
+		}
+
+		if fs.f.ShowSummary && fs.summary == nil {
+			fs.summary = &rootSummary{kind: valueKind(t), size: -1}
+		}
+
+		if (frame.inArray() || frame.inField()) && !isCloseDelim(t) {
+			max := fs.f.MaxArrayElements
+			if frame.inObject() {
+				max = fs.f.MaxObjectFields
+			}
+			if max > 0 && frame.index >= max {
+				hidden, err := skipElements(dec, t, frame.inObject())
+				if err != nil {
+					return err
+				}
+				fs.updateFocus(true)
+				if fs.summary != nil && len(fs.frames) == 2 {
+					fs.summary.children += hidden
+				}
+				if frame.columns {
+					fs.cells = append(fs.cells, nil)
+				}
+				fs.printIndent()
+				fs.printMore(hidden)
+				fs.breakLine(fs.printNewline)
+				continue
+			}
+			frame.index++
+			if fs.summary != nil && len(fs.frames) == 2 {
+				fs.summary.children++
+			}
+			if frame.inField() {
+				frame.key, _ = t.(string)
+			}
+		}
+
+		fs.updateFocus(isCloseDelim(t))
+
+		expanded := frame.expanded
+		if x, ok := t.(json.Delim); ok && x == json.Delim('{') && replay != nil && len(fs.f.ExpandObjectsWithKey) > 0 && len(fs.frames) > 1 && !expanded {
+			t, expanded, err = fs.summarizeObject(replay)
+			if err != nil {
+				return err
+			}
+		}
+
+		if x, ok := t.(json.Delim); ok && x == json.Delim('{') && replay != nil && fs.f.KeyOrder != nil {
+			path := fs.path()
+			err = reorderFields(replay, func(keys []string) []string {
+				return fs.f.KeyOrder(path, keys)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if x, ok := t.(json.Delim); ok && x == json.Delim('[') && fs.f.OutlineMode {
+			n, err := countElements(dec)
+			if err != nil {
+				return err
+			}
+			t = arrayOutline(n)
+		}
 
 		more := dec.More()
 		printComma := frame.inArrayOrObject() && more
+		if !more && fs.f.EmitTrailingComma {
+			// the last element or field of a container
+			// other than one displayed inline, which
+			// closes before its own trailing comma
+			if isCloseDelim(t) {
+				printComma = len(fs.frames) > 2 && (!fs.compact || frame.inline)
+			} else {
+				printComma = frame.inArrayOrObject() && !fs.compact
+			}
+		}
 
 		if x, ok := t.(json.Delim); ok {
-			inputIsObjectOrArray = inputIsObjectOrArray && true
 			if x == json.Delim('{') || x == json.Delim('[') {
 				if frame.inObject() {
-					fs.printSpace(" ", false)
+					fs.printSeparator(" ")
 				} else {
 					fs.printIndent()
 				}
+				if fs.f.ValueBadges {
+					fs.printBadge(x)
+				}
+				if embedded {
+					fs.printDecoration(TokenEmbedded, "embedded ")
+				}
 				err = fs.formatToken(x)
-				if more {
-					fs.printSpace("\n", false)
+				inline := false
+				if err == nil && fs.f.ShouldExpand != nil && replay != nil && more && !fs.compact {
+					inline, err = fs.inline(replay, x)
+					if err != nil {
+						return err
+					}
+					fs.compact = inline
+				}
+				columns := false
+				if err == nil && fs.f.ObjectColumns > 1 && replay != nil && x == json.Delim('{') && more && !fs.compact {
+					_, columns, err = objectFields(replay)
+					if err != nil {
+						return err
+					}
+				}
+				intWidth := 0
+				if err == nil && fs.f.AlignNumbers && replay != nil && x == json.Delim('[') && more && !fs.compact {
+					intWidth, err = fs.numberColumn(replay)
+					if err != nil {
+						return err
+					}
+				}
+				if err == nil && fs.f.ShowContainerCounts && replay != nil && more && !fs.compact {
+					err = fs.printCount(replay, x)
+					if err != nil {
+						return err
+					}
+				}
+				if more || fs.f.ExpandEmptyContainers {
+					fs.annotate(frame, x)
+					if x == json.Delim('[') && more && fs.f.ArrayInlineFirstElement && !fs.compact && intWidth == 0 && !fs.f.ShowContainerCounts {
+						fs.skipIndent = true
+					} else {
+						fs.breakLine(fs.printNewline)
+					}
 				}
 				frame = fs.enterFrame(x, !more)
+				frame.inline = inline
+				frame.expanded = expanded
+				frame.embedded = embedded
+				frame.intWidth = intWidth
+				if columns {
+					frame.columns = true
+					fs.startColumns()
+				}
 			} else {
+				if frame.columns {
+					fs.endColumns()
+				}
 				empty := frame.isEmpty()
+				inline := frame.inline
 				frame = fs.leaveFrame()
-				if !empty {
+				if !empty || fs.f.ExpandEmptyContainers {
 					fs.printIndent()
 				}
 				err = fs.formatToken(x)
+				if inline {
+					fs.compact = false
+				}
 				if printComma {
 					fs.printComma()
 				}
+				if empty && !fs.f.ExpandEmptyContainers {
+					fs.annotate(frame, x)
+				}
 				if len(fs.frames) > 1 {
-					fs.printSpace("\n", false)
+					fs.breakLine(fs.printSeparator)
 				}
 			}
 		} else {
-			printIndent := frame.inArray()
+			printIndent := frame.inArray() || frame.inField()
 			if _, ok := t.(string); ok {
 				printIndent = !frame.inObject() || frame.inField()
 			}
 
+			if frame.columns && frame.inField() {
+				fs.cells = append(fs.cells, nil)
+			}
 			if printIndent {
 				fs.printIndent()
 			}
-			if !frame.inField() && inputIsObjectOrArray {
-				fs.printSpace(" ", false)
+			if n, ok := t.(json.Number); ok && frame.intWidth > 0 {
+				pad := frame.intWidth - fs.f.integerWidth(n.String())
+				fs.printSpace(strings.Repeat(" ", pad), false)
+			}
+			if frame.inObject() && !frame.inField() {
+				if fs.f.WrapLongValues > 0 && !fs.compact && !frame.columns && scalarWidth(t) > fs.f.WrapLongValues {
+					fs.printNewline("\n")
+					frame.indent++
+					fs.printIndent()
+					frame.indent--
+				} else if frame.columns {
+					// cells are buffered as compact output
+					// but separate a field name from its
+					// value as expanded output does
+					fs.compact = false
+					fs.printSeparator(" ")
+					fs.compact = true
+				} else {
+					fs.printSeparator(" ")
+				}
+			}
+			fs.valueColor = fs.valueColorFor(frame, t)
+			if fs.f.ValueBadges && !frame.inField() {
+				fs.printBadge(t)
 			}
 			err = fs.formatToken(t)
+			fs.valueColor = nil
 			if frame.inField() {
 				fs.printColon()
 			} else {
 				if printComma {
 					fs.printComma()
 				}
+				fs.annotate(frame, t)
 				if len(fs.frames) > 1 {
-					fs.printSpace("\n", false)
+					fs.breakLine(fs.printSeparator)
 				}
 			}
 		}
@@ -664,6 +3100,27 @@ func (fs *formatterState) format(dst io.Writer, src []byte, terminateWithNewline
 		if err != nil {
 			return err
 		}
+
+		if fs.flush != nil && len(fs.frames) == 2 && frame.inArray() && frame.index > 0 {
+			fs.closeColor()
+			fs.flush()
+		}
+	}
+
+	if fs.f.ShowSummary {
+		if x, ok := dec.(inputOffsetter); ok && fs.summary != nil {
+			fs.summary.size = x.InputOffset()
+		}
+		fs.printSummary()
+	}
+
+	if fs.f.AppendLegend {
+		fs.printLegend()
+	}
+
+	if fs.f.MarkdownFence {
+		fs.write(TokenSpace, style{}, "\n")
+		fs.write(TokenSpace, style{}, "```")
 	}
 
 	if terminateWithNewline {
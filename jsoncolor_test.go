@@ -0,0 +1,2338 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestMain(m *testing.M) {
+	// color disables colors when stdout is not a terminal, as it
+	// is not when running tests
+	color.NoColor = false
+	os.Exit(m.Run())
+}
+
+// sample is a document containing values of every type.
+const sample = `{"str":"hello","num":-1.5e3,"int":42,"t":true,"f":false,"n":null,"arr":[1,"two",[],{}],"obj":{"nested":{"deep":[null,"x"]}}}`
+
+var escapeRE = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// uncolored returns s without its color escape sequences.
+func uncolored(s string) string {
+	return escapeRE.ReplaceAllString(s, "")
+}
+
+// escapes is a SprintfFuncer writing the escape sequences it holds
+// before text.
+type escapes string
+
+func (e escapes) SprintfFunc() func(format string, a ...interface{}) string {
+	return func(format string, a ...interface{}) string {
+		return string(e) + fmt.Sprintf(format, a...)
+	}
+}
+
+// cell is a byte of output and the color it is displayed with.
+type cell struct {
+	b    byte
+	spec ColorSpec
+}
+
+// render returns the bytes of s other than escape sequences along
+// with the colors a terminal would display them with, and the escape
+// sequences still in effect at the end of s.
+func render(s string) ([]cell, string) {
+	var cells []cell
+	var active string
+	for len(s) > 0 {
+		if loc := escapeRE.FindStringIndex(s); loc != nil && loc[0] == 0 {
+			if esc := s[:loc[1]]; esc == "\x1b[0m" || esc == "\x1b[m" {
+				active = ""
+			} else {
+				active += esc
+			}
+			s = s[loc[1]:]
+			continue
+		}
+		cells = append(cells, cell{b: s[0], spec: colorSpecOf(escapes(active))})
+		s = s[1:]
+	}
+	return cells, active
+}
+
+// colorOf returns the color of the first byte of the first occurrence
+// of sub in the text of the colorized s.
+func colorOf(t *testing.T, s, sub string) ColorSpec {
+	t.Helper()
+	cells, _ := render(s)
+	i := strings.Index(uncolored(s), sub)
+	if i < 0 {
+		t.Fatalf("%q not found in %q", sub, uncolored(s))
+	}
+	return cells[i].spec
+}
+
+// colored returns the text of s written in the color whose escape
+// sequence sets the SGR parameters params.
+func colored(s, params string) string {
+	var text string
+	re := regexp.MustCompile("\x1b\\[" + regexp.QuoteMeta(params) + "m([^\x1b]*)")
+	for _, m := range re.FindAllStringSubmatch(s, -1) {
+		text += m[1]
+	}
+	return text
+}
+
+// formatString returns src formatted by f.
+func formatString(t testing.TB, f *Formatter, src string) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	err := f.Format(buf, []byte(src))
+	if err != nil {
+		t.Fatalf("Format(%s): %v", src, err)
+	}
+	return buf.String()
+}
+
+// formatPerToken formats src as Format did before adjacent tokens of
+// the same color were coalesced, with each token wrapped in its own
+// escape sequences.
+func formatPerToken(f *Formatter, src []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fs := newFormatterState(f, buf)
+	fs.emit = func(kind TokenKind, st style, s string) {
+		if st.sprintf != nil && len(s) > 0 {
+			s = st.sprintf("%s", s)
+		}
+		buf.WriteString(s)
+	}
+	err := fs.format(buf, src, false)
+	return buf.Bytes(), err
+}
+
+// indented returns src indented by json.Indent.
+func indented(t *testing.T, src, prefix, indent string) string {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	err := json.Indent(buf, []byte(src), prefix, indent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestExpandEmptyContainers(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`{}`, "{\n}"},
+		{`[]`, "[\n]"},
+		{`{"a":{},"b":[[]],"c":[{}]}`,
+			"{\n  \"a\": {\n  },\n  \"b\": [\n    [\n    ]\n  ],\n  \"c\": [\n    {\n    }\n  ]\n}"},
+		{`[[[{}]]]`, "[\n  [\n    [\n      {\n      }\n    ]\n  ]\n]"},
+	}
+	for _, tt := range tests {
+		got := uncolored(formatString(t, &Formatter{Indent: "  ", ExpandEmptyContainers: true}, tt.src))
+		if got != tt.want {
+			t.Errorf("Format(%s) with ExpandEmptyContainers = %q, want %q", tt.src, got, tt.want)
+		}
+		got = uncolored(formatString(t, &Formatter{Indent: "  "}, tt.src))
+		if want := indented(t, tt.src, "", "  "); got != want {
+			t.Errorf("Format(%s) = %q, want %q", tt.src, got, want)
+		}
+		got = uncolored(formatString(t, &Formatter{ExpandEmptyContainers: true}, tt.src))
+		if want := tt.src; got != want {
+			t.Errorf("compact Format(%s) with ExpandEmptyContainers = %q, want %q", tt.src, got, want)
+		}
+	}
+}
+
+func TestMaxArrayElements(t *testing.T) {
+	src := `[1,[2,3,4],{"a":[5,6,7]},8]`
+	tests := []struct {
+		f    *Formatter
+		want string
+	}{
+		{&Formatter{MaxArrayElements: 2}, `[1,[2,3,… (1 more)],… (2 more)]`},
+		{&Formatter{MaxArrayElements: 2, Indent: "  "},
+			"[\n  1,\n  [\n    2,\n    3,\n    … (1 more)\n  ],\n  … (2 more)\n]"},
+		{&Formatter{MaxArrayElements: 4}, src},
+	}
+	for _, tt := range tests {
+		if got := uncolored(formatString(t, tt.f, src)); got != tt.want {
+			t.Errorf("Format with MaxArrayElements %d = %q, want %q", tt.f.MaxArrayElements, got, tt.want)
+		}
+	}
+}
+
+func TestMaxObjectFields(t *testing.T) {
+	src := `{"a":1,"b":{"x":1,"y":2,"z":3},"c":[1],"d":null}`
+	tests := []struct {
+		f    *Formatter
+		want string
+	}{
+		{&Formatter{MaxObjectFields: 2}, `{"a":1,"b":{"x":1,"y":2,… (1 more)},… (2 more)}`},
+		{&Formatter{MaxObjectFields: 2, Indent: "  "},
+			"{\n  \"a\": 1,\n  \"b\": {\n    \"x\": 1,\n    \"y\": 2,\n    … (1 more)\n  },\n  … (2 more)\n}"},
+		{&Formatter{MaxObjectFields: 4}, src},
+	}
+	for _, tt := range tests {
+		if got := uncolored(formatString(t, tt.f, src)); got != tt.want {
+			t.Errorf("Format with MaxObjectFields %d = %q, want %q", tt.f.MaxObjectFields, got, tt.want)
+		}
+	}
+}
+
+func TestFocusPath(t *testing.T) {
+	src := `{"a":1,"b":[true,{"c":"x"}]}`
+	got := formatString(t, &Formatter{FocusPath: "/b/1"}, src)
+	if s := uncolored(got); s != src {
+		t.Errorf("Format with FocusPath = %q, want %q", s, src)
+	}
+
+	// the text written in DefaultDimColor's faint escape sequence
+	var dimmed string
+	for _, m := range regexp.MustCompile("\x1b\\[2m([^\x1b]*)").FindAllStringSubmatch(got, -1) {
+		dimmed += m[1]
+	}
+	if want := `{"a":1,"b":[true,]}`; dimmed != want {
+		t.Errorf("Format with FocusPath dims %q, want %q", dimmed, want)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := (&Formatter{FocusPath: "b"}).Format(buf, []byte(src)); err == nil {
+		t.Error("Format with FocusPath not beginning with / succeeded")
+	}
+}
+
+func TestScalarTextFunc(t *testing.T) {
+	var kinds []TokenKind
+	f := &Formatter{
+		NumberColor: color.New(color.FgRed),
+		ScalarTextFunc: func(kind TokenKind, raw string) (string, bool) {
+			kinds = append(kinds, kind)
+			switch kind {
+			case TokenString:
+				return "***", raw == "secret"
+			case TokenNumber:
+				return "<" + raw + ">", true
+			}
+			return raw, false
+		},
+	}
+	got := formatString(t, f, `{"a":"secret","b":"shown","c":[1,true,false,null]}`)
+	if want := `{"a":"***","b":"shown","c":[<1>,true,false,null]}`; uncolored(got) != want {
+		t.Errorf("Format with ScalarTextFunc = %q, want %q", uncolored(got), want)
+	}
+	if want := "\x1b[31m<1>"; !strings.Contains(got, want) {
+		t.Errorf("Format with ScalarTextFunc = %q, want rewritten text colored as %q", got, want)
+	}
+	want := []TokenKind{TokenString, TokenString, TokenNumber, TokenTrue, TokenFalse, TokenNull}
+	if len(kinds) != len(want) {
+		t.Fatalf("ScalarTextFunc called with kinds %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("ScalarTextFunc called with kinds %v, want %v", kinds, want)
+		}
+	}
+}
+
+// flushRecorder records the output written to it at each call to
+// Flush.
+type flushRecorder struct {
+	bytes.Buffer
+	flushed []string
+}
+
+func (w *flushRecorder) Flush() error {
+	w.flushed = append(w.flushed, uncolored(w.String()))
+	return nil
+}
+
+func TestFormatReader(t *testing.T) {
+	src := `[1,{"a":[2,3]},"x"]`
+	for _, f := range []*Formatter{{}, {Indent: "  "}, {Tolerant: true}} {
+		w := &flushRecorder{}
+		err := f.FormatReader(w, strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// colors are closed before each flush, but display
+		// as with Format
+		got, want := w.String(), formatString(t, f, src)
+		gotCells, active := render(got)
+		if wantCells, _ := render(want); !reflect.DeepEqual(gotCells, wantCells) || active != "" {
+			t.Errorf("FormatReader = %q, want %q", got, want)
+		}
+		if len(w.flushed) != 4 || w.flushed[3] != uncolored(w.String()) {
+			t.Fatalf("FormatReader flushed %q, want once per element and once at the end", w.flushed)
+		}
+		// each element is flushed once it has been written in full
+		for i, want := range []string{"[1", `[1,{"a":[2,3]}`, `[1,{"a":[2,3]},"x"`} {
+			got := strings.NewReplacer("\n", "", " ", "").Replace(w.flushed[i])
+			if got != want && got != want+"," {
+				t.Errorf("FormatReader flushed %q after element %d, want %q", w.flushed[i], i, want)
+			}
+		}
+	}
+
+	err := (&Formatter{}).FormatReader(&bytes.Buffer{}, strings.NewReader(`[1,`))
+	if err == nil {
+		t.Error("FormatReader of truncated input succeeded")
+	}
+}
+
+func TestFormatReaderTo(t *testing.T) {
+	src := `{"a":[1,"x",null]}`
+	for _, f := range []*Formatter{{}, {Indent: "  "}} {
+		got, err := f.FormatReaderTo(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := formatString(t, f, src); string(got) != want {
+			t.Errorf("FormatReaderTo(%s) = %q, want %q", src, got, want)
+		}
+	}
+
+	got, err := (&Formatter{}).FormatReaderTo(strings.NewReader(`[1,`))
+	if err == nil || got != nil {
+		t.Errorf("FormatReaderTo of truncated input = %q, %v, want an error", got, err)
+	}
+}
+
+func TestArrayPositionColors(t *testing.T) {
+	f := &Formatter{
+		NumberColor:         color.New(color.FgRed),
+		ArrayPositionColors: []SprintfFuncer{color.New(color.FgBlue), nil},
+	}
+	got := formatString(t, f, `[[1,2],[3,4,5],{"a":6}]`)
+	if want := `[[1,2],[3,4,5],{"a":6}]`; uncolored(got) != want {
+		t.Fatalf("Format with ArrayPositionColors = %q, want %q", uncolored(got), want)
+	}
+	for _, want := range []string{"\x1b[34m1", "\x1b[31m2", "\x1b[34m3", "\x1b[31m4", "\x1b[34m5", "\x1b[31m6"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Format with ArrayPositionColors = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestColorKeysAsValues(t *testing.T) {
+	src := `{"a":"a","b":["a"]}`
+	for _, keysAsValues := range []bool{false, true} {
+		f := &Formatter{
+			FieldColor:        color.New(color.FgBlue),
+			StringColor:       color.New(color.FgGreen),
+			ColorKeysAsValues: keysAsValues,
+		}
+		got := formatString(t, f, src)
+		if s, want := colored(got, "34"), `ab`; s != want {
+			t.Errorf("Format with ColorKeysAsValues %v colors %q as field names, want %q", keysAsValues, s, want)
+		}
+		if s, want := colored(got, "32"), `"a""a"`; s != want {
+			t.Errorf("Format with ColorKeysAsValues %v colors %q as string values, want %q", keysAsValues, s, want)
+		}
+	}
+
+	// field names are matched against the rules for string values
+	// only if ColorKeysAsValues is true
+	tests := []struct {
+		name     string
+		f        *Formatter
+		src      string
+		want     string
+		keysWant string
+	}{
+		{"HighlightEmptyStrings", &Formatter{HighlightEmptyStrings: true, EmptyStringColor: color.New(color.FgMagenta)},
+			`{"":"","a":[""]}`, `""""`, `""""""`},
+		{"HighlightStringNumbers", &Formatter{HighlightStringNumbers: true, StringNumberColor: color.New(color.FgMagenta)},
+			`{"1":"2","a":["3"]}`, `"2""3"`, `"1""2""3"`},
+		{"HighlightBooleanStrings", &Formatter{HighlightBooleanStrings: true, TrueColor: color.New(color.FgMagenta)},
+			`{"yes":"on","a":["true"]}`, `"on""true"`, `"yes""on""true"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, keysAsValues := range []bool{false, true} {
+				f := *tt.f
+				f.ColorKeysAsValues = keysAsValues
+				want := tt.want
+				if keysAsValues {
+					want = tt.keysWant
+				}
+				if s := colored(formatString(t, &f, tt.src), "35"); s != want {
+					t.Errorf("Format(%s) with ColorKeysAsValues %v colors %q, want %q", tt.src, keysAsValues, s, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHighlightEmptyStringsAndZeros(t *testing.T) {
+	src := `{"s":"","t":" ","u":"x","n":[0,0.0,-0,0e0,0E+5,-0.000e-3,1,0.01,10,1e0]}`
+	f := &Formatter{
+		HighlightEmptyStrings: true,
+		HighlightZeros:        true,
+		EmptyStringColor:      color.New(color.FgMagenta),
+		ZeroColor:             color.New(color.FgCyan),
+		StringColor:           color.New(color.FgGreen),
+		NumberColor:           color.New(color.FgRed),
+	}
+	got := formatString(t, f, src)
+	if uncolored(got) != src {
+		t.Errorf("Format(%s) = %s", src, uncolored(got))
+	}
+	if s, want := colored(got, "35"), `""`; s != want {
+		t.Errorf("Format(%s) colors %q with EmptyStringColor, want %q", src, s, want)
+	}
+	if s, want := colored(got, "36"), `00.0-00e00E+5-0.000e-3`; s != want {
+		t.Errorf("Format(%s) colors %q with ZeroColor, want %q", src, s, want)
+	}
+	if s, want := colored(got, "31"), `10.01101e0`; s != want {
+		t.Errorf("Format(%s) colors %q as numbers, want %q", src, s, want)
+	}
+
+	// each option only affects its own kind of value
+	f.HighlightZeros = false
+	got = formatString(t, f, src)
+	if s := colored(got, "36"); s != "" {
+		t.Errorf("Format(%s) without HighlightZeros colors %q with ZeroColor", src, s)
+	}
+	if s, want := colored(got, "35"), `""`; s != want {
+		t.Errorf("Format(%s) colors %q with EmptyStringColor, want %q", src, s, want)
+	}
+	f.HighlightEmptyStrings, f.HighlightZeros = false, true
+	got = formatString(t, f, src)
+	if s := colored(got, "35"); s != "" {
+		t.Errorf("Format(%s) without HighlightEmptyStrings colors %q with EmptyStringColor", src, s)
+	}
+
+	// the default colors are used if unset
+	got = formatString(t, &Formatter{HighlightZeros: true, HighlightEmptyStrings: true}, `["",0]`)
+	if c := colorOf(t, got, "0"); c != colorSpecOf(DefaultZeroColor) {
+		t.Errorf("zero displayed as %+v, want DefaultZeroColor", c)
+	}
+	if c := colorOf(t, got, `""`); c != colorSpecOf(DefaultEmptyStringColor) {
+		t.Errorf("empty string displayed as %+v, want DefaultEmptyStringColor", c)
+	}
+}
+
+func TestFloatPrecision(t *testing.T) {
+	tests := []struct {
+		n    string
+		prec int
+		want string
+	}{
+		{"1", 2, "1"},
+		{"-12", 2, "-12"},
+		{"1.5", 2, "1.5"},
+		{"1.25", 2, "1.25"},
+		{"3.14159", 2, "3.14"},
+		{"-3.14159", 3, "-3.142"},
+		{"100.999", 2, "101"},
+		{"0.001", 2, "0"},
+		{"1.23456e5", 2, "1.23e5"},
+		{"1.23456E+05", 2, "1.23E+05"},
+		{"-9.87654e-10", 3, "-9.877e-10"},
+		{"9.9999e-3", 2, "10e-3"},
+		{"1e10", 2, "1e10"},
+		{"1.5E5", 2, "1.5E5"},
+	}
+	for _, tt := range tests {
+		if got := limitPrecision(tt.n, tt.prec); got != tt.want {
+			t.Errorf("limitPrecision(%s, %d) = %s, want %s", tt.n, tt.prec, got, tt.want)
+		}
+	}
+
+	src := `{"a":3.14159,"b":[10,2.5e-3,1.23456E5],"c":"3.14159"}`
+	got := uncolored(formatString(t, &Formatter{FloatPrecision: 2}, src))
+	if want := `{"a":3.14,"b":[10,2.5e-3,1.23E5],"c":"3.14159"}`; got != want {
+		t.Errorf("Format with FloatPrecision = %q, want %q", got, want)
+	}
+	got = uncolored(formatString(t, &Formatter{}, src))
+	if got != src {
+		t.Errorf("Format = %q, want %q", got, src)
+	}
+}
+
+func TestWillColorize(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	disabled := color.New(color.FgRed)
+	disabled.DisableColor()
+	plain := &Formatter{}
+	plain.SpaceColor = disabled
+	plain.CommaColor = disabled
+	plain.ColonColor = disabled
+	plain.ObjectColor = disabled
+	plain.ArrayColor = disabled
+	plain.FieldQuoteColor = disabled
+	plain.FieldColor = disabled
+	plain.StringQuoteColor = disabled
+	plain.StringColor = disabled
+	plain.TrueColor = disabled
+	plain.FalseColor = disabled
+	plain.NumberColor = disabled
+	plain.NullColor = disabled
+
+	color.NoColor = false
+	if !(&Formatter{}).WillColorize() {
+		t.Error("WillColorize() = false, want true")
+	}
+	if plain.WillColorize() {
+		t.Error("WillColorize() with every color disabled = true, want false")
+	}
+	if got := formatString(t, plain, `{"a":[1]}`); got != `{"a":[1]}` {
+		t.Errorf("Format with every color disabled = %q", got)
+	}
+	more := *plain
+	more.MaxArrayElements = 1
+	if !more.WillColorize() {
+		t.Error("WillColorize() with MaxArrayElements = false, want true")
+	}
+
+	// color sets NoColor when stdout is not a terminal
+	color.NoColor = true
+	if (&Formatter{}).WillColorize() {
+		t.Error("WillColorize() with color.NoColor = true, want false")
+	}
+
+	color.NoColor = false
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	os.Setenv("NO_COLOR", "1")
+	honorNoColor()
+	if (&Formatter{}).WillColorize() {
+		t.Error("WillColorize() with NO_COLOR set = true, want false")
+	}
+}
+
+func TestQuoteColor(t *testing.T) {
+	quote := color.New(color.Faint)
+	tests := []struct {
+		f          *Formatter
+		fieldQuote ColorSpec
+		valueQuote ColorSpec
+	}{
+		{&Formatter{}, colorSpecOf(DefaultFieldQuoteColor), colorSpecOf(DefaultStringQuoteColor)},
+		{&Formatter{QuoteColor: quote}, ColorSpec{Faint: true}, ColorSpec{Faint: true}},
+		{&Formatter{QuoteColor: quote, FieldQuoteColor: color.New(color.FgRed)},
+			ColorSpec{Foreground: color.FgRed}, ColorSpec{Faint: true}},
+		{&Formatter{QuoteColor: quote, StringQuoteColor: color.New(color.FgRed)},
+			ColorSpec{Faint: true}, ColorSpec{Foreground: color.FgRed}},
+	}
+	for _, tt := range tests {
+		got := formatString(t, tt.f, `{"key":"value"}`)
+		if c := colorOf(t, got, `"key`); c != tt.fieldQuote {
+			t.Errorf("field name quote displayed as %+v, want %+v", c, tt.fieldQuote)
+		}
+		if c := colorOf(t, got, `"value`); c != tt.valueQuote {
+			t.Errorf("string quote displayed as %+v, want %+v", c, tt.valueQuote)
+		}
+		if c, want := colorOf(t, got, `key`), colorSpecOf(DefaultFieldColor); c != want {
+			t.Errorf("field name displayed as %+v, want %+v", c, want)
+		}
+		if c, want := colorOf(t, got, `value`), colorSpecOf(DefaultStringColor); c != want {
+			t.Errorf("string displayed as %+v, want %+v", c, want)
+		}
+	}
+}
+
+func TestNegativeNumberColor(t *testing.T) {
+	f := &Formatter{
+		NumberColor:         color.New(color.FgBlue),
+		NegativeNumberColor: color.New(color.FgRed),
+	}
+	tests := []struct {
+		n        string
+		negative bool
+	}{
+		{"-5", true},
+		{"-1.2e3", true},
+		{"-0.001", true},
+		{"-1e-300", true},
+		{"-0", false},
+		{"-0.0", false},
+		{"-0e5", false},
+		{"0", false},
+		{"5", false},
+		{"1.2e-3", false},
+	}
+	for _, tt := range tests {
+		got := formatString(t, f, `[`+tt.n+`]`)
+		want := ColorSpec{Foreground: color.FgBlue}
+		if tt.negative {
+			want = ColorSpec{Foreground: color.FgRed}
+		}
+		if c := colorOf(t, got, tt.n); c != want {
+			t.Errorf("Format(%s) with NegativeNumberColor displays %s as %+v, want %+v", tt.n, tt.n, c, want)
+		}
+	}
+}
+
+func TestOutlineMode(t *testing.T) {
+	tests := []struct {
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{&Formatter{OutlineMode: true}, sample,
+			`{"str":"…","num":#,"int":#,"t":bool,"f":bool,"n":null,"arr":[4],"obj":{"nested":{"deep":[2]}}}`},
+		{&Formatter{OutlineMode: true, Indent: "  "}, `{"a":[],"b":{"c":"x"}}`,
+			"{\n  \"a\": [0],\n  \"b\": {\n    \"c\": \"…\"\n  }\n}"},
+		{&Formatter{OutlineMode: true}, `[1,2,3]`, `[3]`},
+		{&Formatter{OutlineMode: true}, `"x"`, `"…"`},
+	}
+	for _, tt := range tests {
+		if got := uncolored(formatString(t, tt.f, tt.src)); got != tt.want {
+			t.Errorf("Format(%s) with OutlineMode = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	got := formatString(t, &Formatter{OutlineMode: true, TrueColor: color.New(color.FgGreen)}, `[[true]]`)
+	if c := colorOf(t, got, "[1]"); c != colorSpecOf(DefaultArrayColor) {
+		t.Errorf("array summary displayed as %+v, want it in ArrayColor", c)
+	}
+	got = formatString(t, &Formatter{OutlineMode: true, TrueColor: color.New(color.FgGreen)}, `{"a":true}`)
+	if c := colorOf(t, got, "bool"); c != (ColorSpec{Foreground: color.FgGreen}) {
+		t.Errorf("true summary displayed as %+v, want it in TrueColor", c)
+	}
+
+	if err := (&Formatter{OutlineMode: true}).Format(&bytes.Buffer{}, []byte(`{"a":[1,`)); err == nil {
+		t.Error("Format of truncated input with OutlineMode succeeded")
+	}
+}
+
+func TestLeftMargin(t *testing.T) {
+	tests := []struct {
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{&Formatter{LeftMargin: 2}, `{"a":[1]}`, `  {"a":[1]}`},
+		{&Formatter{LeftMargin: 2, Indent: "  "}, `{"a":[1]}`,
+			"  {\n    \"a\": [\n      1\n    ]\n  }"},
+		{&Formatter{LeftMargin: 1, Prefix: "> ", Indent: "\t"}, `[1,2]`,
+			" > [\n > \t1,\n > \t2\n > ]"},
+		{&Formatter{LeftMargin: 3}, `"x"`, `   "x"`},
+	}
+	for _, tt := range tests {
+		got := formatString(t, tt.f, tt.src)
+		if uncolored(got) != tt.want {
+			t.Errorf("Format(%s) with LeftMargin %d = %q, want %q", tt.src, tt.f.LeftMargin, uncolored(got), tt.want)
+		}
+	}
+
+	f := &Formatter{LeftMargin: 2, SpaceColor: color.New(color.BgBlue)}
+	if c := colorOf(t, formatString(t, f, `1`), " "); c != (ColorSpec{Background: color.BgBlue}) {
+		t.Errorf("margin displayed as %+v, want it in SpaceColor", c)
+	}
+}
+
+func TestGlyphLiterals(t *testing.T) {
+	f := &Formatter{
+		GlyphLiterals: true,
+		TrueColor:     color.New(color.FgGreen),
+		FalseColor:    color.New(color.FgRed),
+		NullColor:     color.New(color.FgBlue),
+	}
+	src := `{"t":true,"f":false,"n":null,"s":"true","x":0}`
+	got := formatString(t, f, src)
+	if want := `{"t":✓,"f":✗,"n":∅,"s":"true","x":0}`; uncolored(got) != want {
+		t.Errorf("Format with GlyphLiterals = %q, want %q", uncolored(got), want)
+	}
+	tests := []struct {
+		glyph string
+		want  ColorSpec
+	}{
+		{"✓", ColorSpec{Foreground: color.FgGreen}},
+		{"✗", ColorSpec{Foreground: color.FgRed}},
+		{"∅", ColorSpec{Foreground: color.FgBlue}},
+	}
+	for _, tt := range tests {
+		if c := colorOf(t, got, tt.glyph); c != tt.want {
+			t.Errorf("%s displayed as %+v, want %+v", tt.glyph, c, tt.want)
+		}
+	}
+
+	f.ScalarTextFunc = func(kind TokenKind, raw string) (string, bool) {
+		return "yes", kind == TokenTrue
+	}
+	if got, want := uncolored(formatString(t, f, `[true,false]`)), `[yes,✗]`; got != want {
+		t.Errorf("Format with GlyphLiterals and ScalarTextFunc = %q, want %q", got, want)
+	}
+}
+
+func TestFormatFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsoncolor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "in.json")
+	err = ioutil.WriteFile(name, []byte(sample), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &Formatter{Indent: "  "}
+	buf := &bytes.Buffer{}
+	err = f.FormatFile(buf, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), formatString(t, f, sample); got != want {
+		t.Errorf("FormatFile = %q, want %q", got, want)
+	}
+
+	// files other than terminals are written without color
+	out, err := os.Create(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	err = f.FormatFile(out, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uncolored(buf.String()); string(got) != want {
+		t.Errorf("FormatFile to a file = %q, want %q", got, want)
+	}
+
+	if err := f.FormatFile(&bytes.Buffer{}, filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("FormatFile of a missing file succeeded")
+	}
+}
+
+func TestRootContainerColor(t *testing.T) {
+	root := ColorSpec{Foreground: color.FgMagenta}
+	f := &Formatter{
+		RootContainerColor: color.New(color.FgMagenta),
+		ObjectColor:        color.New(color.FgBlue),
+		ArrayColor:         color.New(color.FgCyan),
+	}
+	tests := []struct {
+		src  string
+		want map[int]ColorSpec
+	}{
+		// byte offsets of delimiters in the output and their colors
+		{`{"a":{"b":[1]}}`, map[int]ColorSpec{0: root, 5: {Foreground: color.FgBlue}, 10: {Foreground: color.FgCyan}, 12: {Foreground: color.FgCyan}, 13: {Foreground: color.FgBlue}, 14: root}},
+		{`[[],{}]`, map[int]ColorSpec{0: root, 1: {Foreground: color.FgCyan}, 2: {Foreground: color.FgCyan}, 4: {Foreground: color.FgBlue}, 5: {Foreground: color.FgBlue}, 6: root}},
+		{`[]`, map[int]ColorSpec{0: root, 1: root}},
+	}
+	for _, tt := range tests {
+		got := formatString(t, f, tt.src)
+		cells, _ := render(got)
+		if uncolored(got) != tt.src {
+			t.Fatalf("Format(%s) with RootContainerColor = %q", tt.src, uncolored(got))
+		}
+		for i, want := range tt.want {
+			if cells[i].spec != want {
+				t.Errorf("Format(%s) with RootContainerColor displays %q at %d as %+v, want %+v", tt.src, cells[i].b, i, cells[i].spec, want)
+			}
+		}
+	}
+
+	// scalars following the root delimiter keep their colors
+	got := formatString(t, f, `[1]`)
+	if c, want := colorOf(t, got, "1"), colorSpecOf(DefaultNumberColor); c != want {
+		t.Errorf("element displayed as %+v, want %+v", c, want)
+	}
+}
+
+func TestMarkdownFence(t *testing.T) {
+	tests := []struct {
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{&Formatter{MarkdownFence: true}, `{"a":[1]}`, "```json\n{\"a\":[1]}\n```"},
+		{&Formatter{MarkdownFence: true, FenceLanguage: "jsonc", Indent: "  "}, `[1]`, "```jsonc\n[\n  1\n]\n```"},
+		{&Formatter{MarkdownFence: true, LeftMargin: 2}, `1`, "  ```json\n  1\n  ```"},
+	}
+	for _, tt := range tests {
+		got := formatString(t, tt.f, tt.src)
+		if got != tt.want {
+			t.Errorf("Format(%s) with MarkdownFence = %q, want %q", tt.src, got, tt.want)
+		}
+		if tt.f.WillColorize() {
+			t.Errorf("WillColorize() with MarkdownFence = true, want false")
+		}
+	}
+}
+
+func TestDebugOffsets(t *testing.T) {
+	offsetRE := regexp.MustCompile(`/\*(\d+)\*/`)
+	src := `{"a": [1, "two", {"b": null}], "c" : true}`
+	for _, f := range []*Formatter{
+		{DebugOffsets: true},
+		{DebugOffsets: true, Indent: "  "},
+		{DebugOffsets: true, Tolerant: true},
+		{DebugOffsets: true, Indent: "  ", ShouldExpand: func([]string, byte, int, int) bool { return false }},
+	} {
+		got := uncolored(formatString(t, f, src))
+		matches := offsetRE.FindAllStringSubmatch(got, -1)
+		if want := 13; len(matches) != want {
+			t.Fatalf("Format with DebugOffsets = %q, want %d offsets", got, want)
+		}
+		last := int64(-1)
+		for _, m := range matches {
+			n, _ := strconv.ParseInt(m[1], 10, 64)
+			if n <= last {
+				t.Errorf("Format with DebugOffsets = %q, offset %d follows %d", got, n, last)
+			}
+			if n > int64(len(src)) || !strings.ContainsAny(src[n-1:n], `{}[]"0123456789el`) {
+				t.Errorf("Format with DebugOffsets = %q, offset %d is not the end of a token", got, n)
+			}
+			last = n
+		}
+		g := *f
+		g.DebugOffsets = false
+		if s, want := offsetRE.ReplaceAllString(got, ""), uncolored(formatString(t, &g, src)); s != want {
+			t.Errorf("Format with DebugOffsets = %q, want %q with offsets", got, want)
+		}
+	}
+
+	got := formatString(t, &Formatter{DebugOffsets: true}, `1`)
+	if c, want := colorOf(t, got, "/*"), colorSpecOf(DefaultDimColor); c != want {
+		t.Errorf("offset displayed as %+v, want %+v", c, want)
+	}
+}
+
+func TestShowTrailingSpaceInStrings(t *testing.T) {
+	f := &Formatter{ShowTrailingSpaceInStrings: true, TrailingSpaceColor: color.New(color.BgRed)}
+	src := `{"a ":"x  ","b":" y","c":"   ","d":"z\n "}`
+	got := formatString(t, f, src)
+	if want := `{"a ":"x··","b":" y","c":"···","d":"z\n·"}`; uncolored(got) != want {
+		t.Errorf("Format with ShowTrailingSpaceInStrings = %q, want %q", uncolored(got), want)
+	}
+	if s, want := colored(got, "41"), "······"; s != want {
+		t.Errorf("Format with ShowTrailingSpaceInStrings colors %q as trailing spaces, want %q", s, want)
+	}
+	if got := uncolored(formatString(t, &Formatter{}, src)); got != src {
+		t.Errorf("Format = %q, want %q", got, src)
+	}
+}
+
+func TestVisibleWhitespace(t *testing.T) {
+	src := `{"a b":"x y\tz\n","c":["\r w"]}`
+	tests := []struct {
+		f    *Formatter
+		want string
+	}{
+		{&Formatter{VisibleStringWhitespace: true}, `{"a b":"x·y→z↵","c":["←·w"]}`},
+		{&Formatter{VisibleKeyWhitespace: true}, `{"a·b":"x y\tz\n","c":["\r w"]}`},
+		{&Formatter{VisibleStringWhitespace: true, VisibleKeyWhitespace: true}, `{"a·b":"x·y→z↵","c":["←·w"]}`},
+		{&Formatter{VisibleStringWhitespace: true, WhitespaceGlyphs: map[rune]string{'\t': "⇥"}}, `{"a b":"x y⇥z\n","c":["\r w"]}`},
+		{&Formatter{}, src},
+	}
+	for _, tt := range tests {
+		tt.f.VisibleWhitespaceColor = color.New(color.FgMagenta)
+		got := formatString(t, tt.f, src)
+		if uncolored(got) != tt.want {
+			t.Errorf("Format = %q, want %q", uncolored(got), tt.want)
+		}
+		// only the glyphs use VisibleWhitespaceColor
+		glyphs := regexp.MustCompile(`[^·→↵←⇥]`).ReplaceAllString(tt.want, "")
+		if s := colored(got, "35"); s != glyphs {
+			t.Errorf("Format(%s) colors %q as glyphs, want %q", src, s, glyphs)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	type call struct {
+		path string
+		kind TokenKind
+		raw  string
+	}
+	var calls []call
+	f := &Formatter{
+		NegativeNumberColor: color.New(color.FgRed),
+		Classify: func(path []string, kind TokenKind, raw string) string {
+			calls = append(calls, call{strings.Join(path, "/"), kind, raw})
+			switch {
+			case strings.Contains(raw, "@"):
+				return "email"
+			case raw == "-1":
+				return "sentinel"
+			case raw == "null":
+				return "missing"
+			}
+			return ""
+		},
+		LabelColors: map[string]SprintfFuncer{
+			"email":    color.New(color.FgMagenta),
+			"sentinel": color.New(color.FgCyan),
+		},
+	}
+	got := formatString(t, f, `{"a@b":["x@y",-1,-2,true,null]}`)
+	wantCalls := []call{
+		{"a@b", TokenField, "a@b"},
+		{"a@b/0", TokenString, "x@y"},
+		{"a@b/1", TokenNumber, "-1"},
+		{"a@b/2", TokenNumber, "-2"},
+		{"a@b/3", TokenTrue, "true"},
+		{"a@b/4", TokenNull, "null"},
+	}
+	if fmt.Sprint(calls) != fmt.Sprint(wantCalls) {
+		t.Errorf("Classify calls = %v, want %v", calls, wantCalls)
+	}
+	if s := colored(got, "35"); s != `"a@b""x@y"` {
+		t.Errorf("email colored %q, want %q", s, `"a@b""x@y"`)
+	}
+	// labeled colors take precedence over NegativeNumberColor
+	if s := colored(got, "36"); s != "-1" {
+		t.Errorf("sentinel colored %q, want %q", s, "-1")
+	}
+	if s := colored(got, "31"); s != "-2" {
+		t.Errorf("NegativeNumberColor colored %q, want %q", s, "-2")
+	}
+	// a label without a color leaves the default color
+	if got, want := colorOf(t, got, "null"), colorOf(t, formatString(t, &Formatter{}, "null"), "null"); got != want {
+		t.Errorf("null color = %v, want %v", got, want)
+	}
+}
+
+func TestHashKeyColors(t *testing.T) {
+	src := `[{"id":1,"name":"a"},{"name":"b","id":2}]`
+	palette := []SprintfFuncer{color.New(color.FgRed), color.New(color.FgGreen), color.New(color.FgBlue)}
+	f := &Formatter{HashKeyColors: true, KeyColorPalette: palette}
+	got := formatString(t, f, src)
+	for _, k := range []string{"id", "name"} {
+		want := colorSpecOf(f.hashKeyColor(k))
+		cells, _ := render(got)
+		text := uncolored(got)
+		n := 0
+		for i := 0; ; n++ {
+			j := strings.Index(text[i:], `"`+k+`"`)
+			if j < 0 {
+				break
+			}
+			i += j
+			// the field name and its quotes
+			for _, c := range cells[i : i+len(k)+2] {
+				if c.spec != want {
+					t.Errorf("field %q at %d colored %v, want %v", k, i, c.spec, want)
+					break
+				}
+			}
+			i += len(k) + 2
+		}
+		if n != 2 {
+			t.Errorf("field %q found %d times, want 2", k, n)
+		}
+	}
+	// the hash is stable and spreads names across the palette
+	if f.hashKeyColor("id") != f.hashKeyColor("id") {
+		t.Error("hashKeyColor is not deterministic")
+	}
+	seen := map[SprintfFuncer]bool{}
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		seen[f.hashKeyColor(k)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("hashKeyColor uses %d of %d palette colors", len(seen), len(palette))
+	}
+
+	// the default palette, and FieldColor when off
+	if c := colorOf(t, formatString(t, &Formatter{HashKeyColors: true}, src), "id"); c != colorSpecOf((&Formatter{}).hashKeyColor("id")) {
+		t.Errorf("default palette colored id %v", c)
+	}
+	f = &Formatter{FieldColor: color.New(color.FgMagenta)}
+	if s := colored(formatString(t, f, src), "35"); s != "idnamenameid" {
+		t.Errorf("FieldColor colored %q, want %q", s, "idnamenameid")
+	}
+}
+
+func TestEllipsis(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{"MaxArrayElements", &Formatter{MaxArrayElements: 1}, `[1,2,3]`, `[1,... (2 more)]`},
+		{"MaxObjectFields", &Formatter{MaxObjectFields: 1}, `{"a":1,"b":2}`, `{"a":1,... (1 more)}`},
+		{"ExpandObjectsWithKey", &Formatter{ExpandObjectsWithKey: "id"}, `[{"a":1}]`, `[{... (1 field)}]`},
+		{"OutlineMode", &Formatter{OutlineMode: true}, `{"a":"x"}`, `{"a":"..."}`},
+		{"MaxLines", &Formatter{Indent: " ", MaxLines: 3}, `[1,2,3,4]`, "[\n... (4 lines hidden) ...\n]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.f.Ellipsis = "..."
+			tt.f.EllipsisColor = color.New(color.FgMagenta)
+			got := formatString(t, tt.f, tt.src)
+			if uncolored(got) != tt.want {
+				t.Errorf("Format(%s) = %q, want %q", tt.src, uncolored(got), tt.want)
+			}
+			if tt.name == "OutlineMode" {
+				// a summarized string keeps the string color
+				return
+			}
+			want := strings.Repeat("...", strings.Count(tt.want, "..."))
+			if s := colored(got, "35"); s != want {
+				t.Errorf("Format(%s) colors %q with EllipsisColor, want %q", tt.src, s, want)
+			}
+		})
+	}
+
+	t.Run("FormatSideBySide", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		f := &Formatter{Ellipsis: "~"}
+		if err := f.FormatSideBySide(buf, []byte(`["abcdef"]`), []byte(`["abcdef"]`), 15); err != nil {
+			t.Fatal(err)
+		}
+		want := "[      │ [\n  \"ab~ │   \"ab~\n]      │ ]"
+		if got := uncolored(buf.String()); got != want {
+			t.Errorf("FormatSideBySide = %q, want %q", got, want)
+		}
+	})
+
+	// the default ellipsis uses MoreColor
+	f := &Formatter{MaxArrayElements: 1, MoreColor: color.New(color.FgCyan)}
+	got := formatString(t, f, `[1,2]`)
+	if s := colored(got, "36"); s != "… (1 more)" {
+		t.Errorf("Format colors %q with MoreColor, want %q", s, "… (1 more)")
+	}
+}
+
+func TestMoreIndicator(t *testing.T) {
+	src := `{"a":[1,2,3],"b":{"c":1,"d":2},"e":[{"f":1,"g":2}]}`
+	expand := func(path []string, kind byte, childCount int, compactWidth int) bool {
+		return len(path) == 0
+	}
+	tests := []struct {
+		name string
+		f    *Formatter
+		want string
+	}{
+		{"MaxArrayElements", &Formatter{MaxArrayElements: 1},
+			`{"a":[1,(+2)],"b":{"c":1,"d":2},"e":[{"f":1,"g":2}]}`},
+		{"MaxObjectFields", &Formatter{MaxObjectFields: 1},
+			`{"a":[1,2,3],(+2)}`},
+		{"ExpandObjectsWithKey", &Formatter{ExpandObjectsWithKey: "id"},
+			`{"a":[1,2,3],"b":{(+2)},"e":[{(+2)}]}`},
+		{"ShouldExpand", &Formatter{Indent: " ", MaxArrayElements: 1, ShouldExpand: expand},
+			"{\n \"a\": [1,(+2)],\n \"b\": {\"c\":1,\"d\":2},\n \"e\": [{\"f\":1,\"g\":2}]\n}"},
+		{"OutlineMode", &Formatter{Indent: " ", OutlineMode: true, MaxObjectFields: 2},
+			"{\n \"a\": [3],\n \"b\": {\n  \"c\": #,\n  \"d\": #\n },\n (+1)\n}"},
+		{"MaxLines", &Formatter{Indent: " ", MaxLines: 3},
+			"{\n(+15)\n}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.f.MoreIndicator = func(hidden int) string {
+				return fmt.Sprintf("(+%d)", hidden)
+			}
+			tt.f.MoreColor = color.New(color.FgMagenta)
+			got := formatString(t, tt.f, src)
+			if uncolored(got) != tt.want {
+				t.Errorf("Format(%s) = %q, want %q", src, uncolored(got), tt.want)
+			}
+			// the indicators replace the ellipsis and use
+			// MoreColor
+			want := strings.Join(regexp.MustCompile(`\(\+\d+\)`).FindAllString(tt.want, -1), "")
+			if s := colored(got, "35"); s != want {
+				t.Errorf("Format(%s) colors %q with MoreColor, want %q", src, s, want)
+			}
+		})
+	}
+}
+
+func TestContextNullColors(t *testing.T) {
+	src := `{"a":null,"b":[null,1,{"c":null}]}`
+	tests := []struct {
+		name         string
+		f            *Formatter
+		array, field string
+	}{
+		{"both", &Formatter{ArrayNullColor: color.New(color.FgMagenta), FieldNullColor: color.New(color.FgCyan)}, "null", "nullnull"},
+		{"array", &Formatter{ArrayNullColor: color.New(color.FgMagenta)}, "null", ""},
+		{"field", &Formatter{FieldNullColor: color.New(color.FgCyan)}, "", "nullnull"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.f.NullColor = color.New(color.FgRed)
+			got := formatString(t, tt.f, src)
+			if s := colored(got, "35"); s != tt.array {
+				t.Errorf("ArrayNullColor colors %q, want %q", s, tt.array)
+			}
+			if s := colored(got, "36"); s != tt.field {
+				t.Errorf("FieldNullColor colors %q, want %q", s, tt.field)
+			}
+			// the others fall back to NullColor
+			want := strings.Repeat("null", 3-len(tt.array+tt.field)/4)
+			if s := colored(got, "31"); s != want {
+				t.Errorf("NullColor colors %q, want %q", s, want)
+			}
+		})
+	}
+
+	// a null root value uses NullColor
+	f := &Formatter{NullColor: color.New(color.FgRed), ArrayNullColor: color.New(color.FgMagenta), FieldNullColor: color.New(color.FgCyan)}
+	if s := colored(formatString(t, f, "null"), "31"); s != "null" {
+		t.Errorf("NullColor colors %q, want %q", s, "null")
+	}
+}
+
+func TestKeyTransform(t *testing.T) {
+	var paths []string
+	upper := func(path []string, key string) string {
+		paths = append(paths, strings.Join(path, "/"))
+		return strings.ToUpper(key)
+	}
+	src := `{"a":{"b":[{"c":1}]},"d":"x"}`
+	f := &Formatter{KeyTransform: upper}
+	got := formatString(t, f, src)
+	if want := `{"A":{"B":[{"C":1}]},"D":"x"}`; uncolored(got) != want {
+		t.Errorf("Format(%s) = %q, want %q", src, uncolored(got), want)
+	}
+	if want := "a a/b a/b/0/c d"; strings.Join(paths, " ") != want {
+		t.Errorf("KeyTransform paths = %q, want %q", strings.Join(paths, " "), want)
+	}
+
+	// fields are colored according to their original names
+	f = &Formatter{
+		KeyTransform:  upper,
+		HashKeyColors: true,
+		Classify: func(path []string, kind TokenKind, raw string) string {
+			if kind == TokenField && raw == "d" {
+				return "d"
+			}
+			return ""
+		},
+		LabelColors: map[string]SprintfFuncer{"d": color.New(color.FgMagenta)},
+	}
+	got = formatString(t, f, src)
+	if c, want := colorOf(t, got, "A"), colorSpecOf(f.hashKeyColor("a")); c != want {
+		t.Errorf("field A colored %v, want %v", c, want)
+	}
+	if s := colored(got, "35"); s != `"D"` {
+		t.Errorf("Classify colored %q, want %q", s, `"D"`)
+	}
+
+	// FocusPath matches the original names
+	f = &Formatter{KeyTransform: upper, FocusPath: "/d", DimColor: color.New(color.FgCyan)}
+	got = formatString(t, f, src)
+	if c := colorOf(t, got, "x"); c == colorSpecOf(f.DimColor) {
+		t.Errorf("focused value x is dimmed")
+	}
+	if c := colorOf(t, got, "B"); c != colorSpecOf(f.DimColor) {
+		t.Errorf("field B colored %v, want dimmed", c)
+	}
+}
+
+func TestShowBreadcrumb(t *testing.T) {
+	src := `{"items":[0,1,2,{"name":"x"}]}`
+	f := &Formatter{
+		FocusPath:      "/items/3/name",
+		ShowBreadcrumb: true,
+		FieldColor:     color.New(color.FgMagenta),
+		NumberColor:    color.New(color.FgCyan),
+	}
+	got := formatString(t, f, src)
+	want := "root ▸ items ▸ [3] ▸ name\n" + src
+	if uncolored(got) != want {
+		t.Errorf("Format(%s) = %q, want %q", src, uncolored(got), want)
+	}
+	line := got[:strings.Index(got, "\n")]
+	if s := colored(line, "36"); s != "[3]" {
+		t.Errorf("breadcrumb colors %q with NumberColor, want %q", s, "[3]")
+	}
+	if s := colored(line, "35"); s != "itemsname" {
+		t.Errorf("breadcrumb colors %q with FieldColor, want %q", s, "itemsname")
+	}
+
+	// no breadcrumb without FocusPath
+	f = &Formatter{ShowBreadcrumb: true}
+	if got := uncolored(formatString(t, f, src)); got != src {
+		t.Errorf("Format(%s) without FocusPath = %q, want %q", src, got, src)
+	}
+}
+
+func TestFormatterReuse(t *testing.T) {
+	settings := []struct{ prefix, indent string }{
+		{"", "  "},
+		{"", "    "},
+		{"", ""},
+		{"", "\t"},
+		{"// ", "  "},
+		{"", "  "},
+		{"#", ""},
+		{"", "    "},
+	}
+	f := NewFormatter()
+	for i, s := range settings {
+		f.Prefix, f.Indent = s.prefix, s.indent
+		g := NewFormatter()
+		g.Prefix, g.Indent = s.prefix, s.indent
+
+		want := formatString(t, g, sample)
+		if got := formatString(t, f, sample); got != want {
+			t.Errorf("call %d with Prefix %q and Indent %q = %q, want %q", i, s.prefix, s.indent, got, want)
+		}
+
+		var std bytes.Buffer
+		var err error
+		if len(s.prefix) == 0 && len(s.indent) == 0 {
+			err = json.Compact(&std, []byte(sample))
+		} else {
+			std.WriteString(s.prefix)
+			err = json.Indent(&std, []byte(sample), s.prefix, s.indent)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := uncolored(want); got != std.String() {
+			t.Errorf("call %d with Prefix %q and Indent %q = %q, want %q", i, s.prefix, s.indent, got, std.String())
+		}
+	}
+}
+
+func TestIndentBandColors(t *testing.T) {
+	src := `{"a":[{"b":1}],"c":2}`
+	bands := []SprintfFuncer{color.New(color.BgRed), nil, color.New(color.BgBlue)}
+	f := &Formatter{Prefix: "> ", Indent: "  ", IndentBandColors: bands}
+	got := formatString(t, f, src)
+	want := formatString(t, &Formatter{Prefix: "> ", Indent: "  "}, src)
+	if uncolored(got) != uncolored(want) {
+		t.Fatalf("Format(%s) = %q, want %q", src, uncolored(got), uncolored(want))
+	}
+	space := colorSpecOf(DefaultSpaceColor)
+	specs := []ColorSpec{colorSpecOf(bands[0]), space, colorSpecOf(bands[2])}
+	for _, line := range strings.Split(got, "\n") {
+		cells, active := render(line)
+		if active != "" {
+			t.Errorf("line %q leaves %q in effect", uncolored(line), active)
+		}
+		// the prefix is not colored
+		for _, c := range cells[:2] {
+			if c.spec != (ColorSpec{}) {
+				t.Errorf("prefix of line %q colored %v", uncolored(line), c.spec)
+			}
+		}
+		for i, c := range cells[2:] {
+			if c.b != ' ' {
+				break
+			}
+			if want := specs[i/2%len(specs)]; c.spec != want {
+				t.Errorf("indentation %d of line %q colored %v, want %v", i, uncolored(line), c.spec, want)
+			}
+		}
+	}
+}
+
+func TestResetPerLine(t *testing.T) {
+	src := `{"a":[1,{"b":"x","c":[true,null]}],"d":{}}`
+	f := &Formatter{
+		Indent:       "  ",
+		ResetPerLine: true,
+		SpaceColor:   color.New(color.BgBlue),
+	}
+	got := formatString(t, f, src)
+	if want := formatString(t, &Formatter{Indent: "  "}, src); uncolored(got) != uncolored(want) {
+		t.Fatalf("Format(%s) = %q, want %q", src, uncolored(got), uncolored(want))
+	}
+	lines := strings.Split(got, "\n")
+	if len(lines) < 10 {
+		t.Fatalf("Format(%s) has %d lines, want at least 10", src, len(lines))
+	}
+	space := colorSpecOf(f.SpaceColor)
+	for i, line := range lines {
+		if !strings.HasSuffix(line, "\x1b[0m") {
+			t.Errorf("line %d %q does not end with a reset", i, line)
+		}
+		cells, active := render(line)
+		if active != "" {
+			t.Errorf("line %d %q leaves %q in effect", i, line, active)
+		}
+		// the indentation, written along with the preceding
+		// newline, is colored again on each line
+		for _, c := range cells {
+			if c.b != ' ' {
+				break
+			}
+			if c.spec != space {
+				t.Errorf("indentation of line %d %q colored %v, want %v", i, uncolored(line), c.spec, space)
+				break
+			}
+		}
+	}
+
+	// no resets are added to uncolored output
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	if got := formatString(t, f, src); strings.Contains(got, "\x1b") {
+		t.Errorf("Format(%s) without colors = %q", src, got)
+	}
+}
+
+func TestEmitTrailingComma(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{"array", &Formatter{Indent: "  "}, `[1,2]`, "[\n  1,\n  2,\n]"},
+		{"object", &Formatter{Indent: "  "}, `{"a":1,"b":"x"}`, "{\n  \"a\": 1,\n  \"b\": \"x\",\n}"},
+		{"nested", &Formatter{Indent: "  "}, `{"a":[1,{"b":null}],"c":{}}`,
+			"{\n  \"a\": [\n    1,\n    {\n      \"b\": null,\n    },\n  ],\n  \"c\": {},\n}"},
+		{"empty", &Formatter{Indent: "  "}, `[[],{}]`, "[\n  [],\n  {},\n]"},
+		{"scalar", &Formatter{Indent: "  "}, `1`, "1"},
+		{"compact", &Formatter{}, `{"a":[1,2]}`, `{"a":[1,2]}`},
+		{"inline", &Formatter{Indent: "  ", ShouldExpand: func(path []string, kind byte, childCount, compactWidth int) bool {
+			return len(path) == 0
+		}}, `[[1,2],{"a":1}]`, "[\n  [1,2],\n  {\"a\":1},\n]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.f.EmitTrailingComma = true
+			tt.f.CommaColor = color.New(color.FgMagenta)
+			got := formatString(t, tt.f, tt.src)
+			if uncolored(got) != tt.want {
+				t.Errorf("Format(%s) = %q, want %q", tt.src, uncolored(got), tt.want)
+			}
+			if s, want := colored(got, "35"), strings.Repeat(",", strings.Count(tt.want, ",")); s != want {
+				t.Errorf("Format(%s) colors %q with CommaColor, want %q", tt.src, s, want)
+			}
+		})
+	}
+}
+
+func TestMarshalMatchesStd(t *testing.T) {
+	values := []interface{}{
+		"hello",
+		42,
+		nil,
+		[]interface{}{},
+		map[string]interface{}{},
+		map[string]interface{}{"a": 1, "b": "two", "c": true, "d": nil, "e": 1.5},
+		map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{1, map[string]interface{}{"c": false}}}},
+		[]interface{}{1, "x", []interface{}{}, map[string]interface{}{"y": nil}},
+	}
+	for _, v := range values {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uncolored(string(got)) != string(want) {
+			t.Errorf("Marshal(%v) = %q, want %q", v, uncolored(string(got)), want)
+		}
+
+		want, err = json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err = MarshalIndent(v, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uncolored(string(got)) != string(want) {
+			t.Errorf("MarshalIndent(%v) = %q, want %q", v, uncolored(string(got)), want)
+		}
+	}
+}
+
+func TestPlain(t *testing.T) {
+	f := &Formatter{
+		Indent:      "\t",
+		StringColor: color.New(color.FgRed),
+		SpaceColor:  color.New(color.BgBlue),
+	}
+	p := f.Plain()
+	if p.WillColorize() {
+		t.Error("Plain formatter will colorize")
+	}
+	if !f.WillColorize() {
+		t.Error("Plain disabled the colors of the original formatter")
+	}
+	for _, src := range []string{sample, `"hello"`, `[]`, `{"a":[{},[],{"b":null}]}`} {
+		var want bytes.Buffer
+		err := json.Indent(&want, []byte(src), "", "\t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := formatString(t, p, src); got != want.String() {
+			t.Errorf("Plain().Format(%s) = %q, want %q", src, got, want.String())
+		}
+		if got := formatString(t, f, src); got == want.String() || uncolored(got) != want.String() {
+			t.Errorf("Format(%s) = %q, want %q colorized", src, got, want.String())
+		}
+	}
+}
+
+func TestQuoteLargeInts(t *testing.T) {
+	tests := []struct {
+		f    *Formatter
+		n    string
+		want string
+	}{
+		{&Formatter{QuoteLargeInts: true}, "9007199254740991", "9007199254740991"},
+		{&Formatter{QuoteLargeInts: true}, "9007199254740992", `"9007199254740992"`},
+		{&Formatter{QuoteLargeInts: true}, "-9007199254740991", "-9007199254740991"},
+		{&Formatter{QuoteLargeInts: true}, "-9007199254740992", `"-9007199254740992"`},
+		{&Formatter{QuoteLargeInts: true}, "99999999999999999999999", `"99999999999999999999999"`},
+		{&Formatter{QuoteLargeInts: true}, "9007199254740993.5", "9007199254740993.5"},
+		{&Formatter{QuoteLargeInts: true}, "1e300", "1e300"},
+		{&Formatter{QuoteLargeInts: true, LargeIntLimit: 100}, "101", `"101"`},
+		{&Formatter{QuoteLargeInts: true, LargeIntLimit: 100}, "100", "100"},
+		{&Formatter{}, "9007199254740993", "9007199254740993"},
+	}
+	for _, tt := range tests {
+		got := formatString(t, tt.f, "["+tt.n+"]")
+		if s := uncolored(got); s != "["+tt.want+"]" {
+			t.Errorf("Format([%s]) = %s, want [%s]", tt.n, s, tt.want)
+		}
+		want := colorSpecOf(DefaultNumberColor)
+		if tt.want != tt.n {
+			want = colorSpecOf(DefaultStringColor)
+		}
+		if spec := colorOf(t, got, tt.n); spec != want {
+			t.Errorf("Format([%s]) colors the number %+v, want %+v", tt.n, spec, want)
+		}
+	}
+}
+
+func TestCoalesceNoBleed(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Formatter
+	}{
+		{"compact", &Formatter{}},
+		{"indent", &Formatter{Indent: "  "}},
+		{"prefix", &Formatter{Prefix: "> ", Indent: "\t"}},
+		{"margin", &Formatter{Indent: "  ", LeftMargin: 2}},
+		{"space color", &Formatter{Indent: "  ", SpaceColor: color.New(color.BgBlue)}},
+		{"shared color", &Formatter{Indent: "  ", CommaColor: DefaultFieldColor, ColonColor: DefaultFieldColor}},
+		{"reset per line", &Formatter{Indent: "  ", ResetPerLine: true}},
+		{"greppable", &Formatter{Indent: "  ", GreppableSeparators: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatString(t, tt.f, sample)
+			want, err := formatPerToken(tt.f, []byte(sample))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotCells, active := render(got)
+			wantCells, _ := render(string(want))
+			if active != "" {
+				t.Errorf("output leaves %q in effect", active)
+			}
+			// colors are closed before each newline, which
+			// is only colored by SpaceColor
+			if tt.f.SpaceColor == nil {
+				for i, line := range strings.Split(got, "\n") {
+					if _, active := render(line); active != "" {
+						t.Errorf("line %d %q leaves %q in effect", i, uncolored(line), active)
+					}
+				}
+			}
+			if len(gotCells) != len(wantCells) {
+				t.Fatalf("output is %q, want %q", uncolored(got), uncolored(string(want)))
+			}
+			for i := range gotCells {
+				if gotCells[i] != wantCells[i] {
+					t.Fatalf("byte %d %q of %q is displayed as %+v, want %+v",
+						i, gotCells[i].b, uncolored(got), gotCells[i].spec, wantCells[i].spec)
+				}
+			}
+			if len(got) > len(want) {
+				t.Errorf("coalesced output is %d bytes, more than the %d bytes of per-token output", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestCoalesceSpansAndRuns(t *testing.T) {
+	for _, f := range []*Formatter{{}, {Indent: "  "}} {
+		want := uncolored(formatString(t, f, sample))
+
+		spans, err := f.FormatSpans([]byte(sample))
+		if err != nil {
+			t.Fatalf("FormatSpans: %v", err)
+		}
+		var text strings.Builder
+		for _, s := range spans {
+			text.WriteString(s.Text)
+		}
+		if text.String() != want {
+			t.Errorf("FormatSpans text is %q, want %q", text.String(), want)
+		}
+
+		plain, _, err := f.FormatRuns([]byte(sample))
+		if err != nil {
+			t.Fatalf("FormatRuns: %v", err)
+		}
+		if string(plain) != want {
+			t.Errorf("FormatRuns plain output is %q, want %q", plain, want)
+		}
+	}
+}
+
+// largeDocument returns an array of n objects.
+func largeDocument(n int) []byte {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id":%d,"name":"item %d","tags":["a","b","c"],"active":true,"score":%d.5,"parent":null}`, i, i, i)
+	}
+	b.WriteString("]")
+	return b.Bytes()
+}
+
+func BenchmarkFormat(b *testing.B) {
+	src := largeDocument(1000)
+	f := &Formatter{Indent: "  "}
+	formats := []struct {
+		name   string
+		format func() ([]byte, error)
+	}{
+		{"coalesced", func() ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := f.Format(buf, src)
+			return buf.Bytes(), err
+		}},
+		{"per-token", func() ([]byte, error) {
+			return formatPerToken(f, src)
+		}},
+	}
+	for _, x := range formats {
+		b.Run(x.name, func(b *testing.B) {
+			var n int
+			for i := 0; i < b.N; i++ {
+				out, err := x.format()
+				if err != nil {
+					b.Fatal(err)
+				}
+				n = len(out)
+			}
+			b.ReportMetric(float64(n), "output-bytes")
+		})
+	}
+}
+
+func TestGreppableSeparators(t *testing.T) {
+	src := `{"a":1,"b":[true,{"c":"x"}]}`
+	for _, f := range []*Formatter{
+		{Indent: "  ", GreppableSeparators: true},
+		{Indent: "  ", GreppableSeparators: true, SpaceColor: color.New(color.BgBlue)},
+	} {
+		got := formatString(t, f, src)
+		g := *f
+		g.GreppableSeparators = false
+		if want := formatString(t, &g, src); uncolored(got) != uncolored(want) {
+			t.Errorf("Format(%s) = %q, want %q", src, uncolored(got), uncolored(want))
+		}
+
+		// the space after each colon and the newlines after
+		// elements are written between escape sequences,
+		// without color
+		cells, _ := render(got)
+		for i, c := range cells {
+			opening := i > 0 && (cells[i-1].b == '{' || cells[i-1].b == '[')
+			separator := c.b == '\n' && !opening || c.b == ' ' && i > 0 && cells[i-1].b == ':'
+			if separator && c.spec != (ColorSpec{}) {
+				t.Errorf("separator %q at %d of %q is displayed as %+v, want no color", c.b, i, uncolored(got), c.spec)
+			}
+			if !separator && c.b == ' ' && f.SpaceColor != nil && c.spec == (ColorSpec{}) {
+				t.Errorf("indentation at %d of %q is displayed without SpaceColor", i, uncolored(got))
+			}
+		}
+		if !strings.Contains(got, ":\x1b[0m \x1b") || !strings.Contains(got, ",\x1b[0m\n\x1b") {
+			t.Errorf("Format(%s) = %q, want plain separators between escape sequences", src, got)
+		}
+	}
+
+	// compact output has no separators
+	f := &Formatter{GreppableSeparators: true}
+	if got, want := formatString(t, f, src), formatString(t, &Formatter{}, src); got != want {
+		t.Errorf("compact Format(%s) = %q, want %q", src, got, want)
+	}
+}
+
+func TestFormatForLogger(t *testing.T) {
+	f := &Formatter{Prefix: "> ", Indent: "  ", LeftMargin: 2, AppendLegend: true, ShowSummary: true, RootLabel: "doc"}
+	got, err := f.FormatForLogger([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := "level=info msg=request body=" + got + " status=200"
+	for _, r := range line {
+		if r < ' ' && r != '\x1b' {
+			t.Fatalf("log line %q contains %q", line, r)
+		}
+	}
+	want := &bytes.Buffer{}
+	json.Compact(want, []byte(sample))
+	if s := uncolored(line); s != "level=info msg=request body="+want.String()+" status=200" {
+		t.Errorf("log line is %q", s)
+	}
+	if _, active := render(got); active != "" {
+		t.Errorf("FormatForLogger leaves %q in effect", active)
+	}
+
+	// control characters in strings stay escaped
+	got, err = f.FormatForLogger([]byte(`["a\nb\u0007\t"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := uncolored(got), `["a\nb\u0007\t"]`; s != want {
+		t.Errorf("FormatForLogger = %q, want %q", s, want)
+	}
+
+	if _, err = f.FormatForLogger([]byte(`[1,`)); err == nil {
+		t.Error("FormatForLogger of truncated input succeeded")
+	}
+}
+
+func TestValueBadges(t *testing.T) {
+	tests := []struct {
+		f    *Formatter
+		want string
+	}{
+		{&Formatter{ValueBadges: true}, `[o] {"a":[a] [[n] 1,[s] "x",[b] true,[b] false,[-] null]}`},
+		{&Formatter{ValueBadges: true, Badges: map[TokenKind]string{TokenString: "str"}}, `{"a":[1,str "x",true,false,null]}`},
+		{&Formatter{ValueBadges: true, Badges: map[TokenKind]string{TokenTrue: "T", TokenFalse: "F"}}, `{"a":[1,"x",T true,F false,null]}`},
+		{&Formatter{ValueBadges: true, Indent: " "}, "[o] {\n \"a\": [a] [\n  [n] 1,\n  [s] \"x\",\n  [b] true,\n  [b] false,\n  [-] null\n ]\n}"},
+	}
+	for _, tt := range tests {
+		got := formatString(t, tt.f, `{"a":[1,"x",true,false,null]}`)
+		if s := uncolored(got); s != tt.want {
+			t.Errorf("Format with ValueBadges = %q, want %q", s, tt.want)
+		}
+	}
+
+	got := formatString(t, &Formatter{ValueBadges: true}, `["x",1]`)
+	if spec, want := colorOf(t, got, "[s]"), colorSpecOf(DefaultStringColor); spec != want {
+		t.Errorf("string badge is %+v, want %+v", spec, want)
+	}
+	if spec, want := colorOf(t, got, "[n]"), colorSpecOf(DefaultNumberColor); spec != want {
+		t.Errorf("number badge is %+v, want %+v", spec, want)
+	}
+
+	// badges follow colors chosen for the value
+	got = formatString(t, &Formatter{ValueBadges: true, NegativeNumberColor: color.New(color.FgRed)}, `[-1]`)
+	if s, want := colored(got, "31"), "[n] -1"; s != want {
+		t.Errorf("Format([-1]) colors %q with NegativeNumberColor, want %q", s, want)
+	}
+}
+
+func TestShowContainerCounts(t *testing.T) {
+	got := uncolored(formatString(t, &Formatter{Indent: "  ", ShowContainerCounts: true}, `{"a":[1,2,3],"b":{},"c":[{"d":1}]}`))
+	want := "{ // 3 fields\n" +
+		"  \"a\": [ // 3 elements\n    1,\n    2,\n    3\n  ],\n" +
+		"  \"b\": {},\n" +
+		"  \"c\": [ // 1 element\n    { // 1 field\n      \"d\": 1\n    }\n  ]\n}"
+	if got != want {
+		t.Errorf("Format with ShowContainerCounts = %q, want %q", got, want)
+	}
+
+	got = uncolored(formatString(t, &Formatter{ShowContainerCounts: true}, `[1,2]`))
+	if want := `[1,2]`; got != want {
+		t.Errorf("compact Format with ShowContainerCounts = %q, want %q", got, want)
+	}
+
+	f := &Formatter{Indent: "  ", ShowContainerCounts: true, DimColor: color.New(color.FgMagenta)}
+	if s, want := colored(formatString(t, f, `[[1]]`), "35"), " // 1 element // 1 element"; s != want {
+		t.Errorf("Format with ShowContainerCounts colors %q with DimColor, want %q", s, want)
+	}
+}
+
+func TestSetAttributes(t *testing.T) {
+	f := &Formatter{}
+	f.SetAttributes(TokenString, color.Italic, color.Underline)
+	f.SetAttributes(TokenNumber, color.Bold)
+	f.SetAttributes(TokenNumber, color.Underline)
+	got := formatString(t, f, `["x",1,true]`)
+	if s := uncolored(got); s != `["x",1,true]` {
+		t.Errorf("Format = %s", s)
+	}
+
+	want := colorSpecOf(DefaultStringColor)
+	want.Italic, want.Underline = true, true
+	if spec := colorOf(t, got, `x`); spec != want {
+		t.Errorf("string is %+v, want %+v", spec, want)
+	}
+	want = colorSpecOf(DefaultNumberColor)
+	want.Bold, want.Underline = false, true
+	if spec := colorOf(t, got, `1`); spec != want {
+		t.Errorf("number is %+v, want %+v", spec, want)
+	}
+	if spec, want := colorOf(t, got, `true`), colorSpecOf(DefaultTrueColor); spec != want {
+		t.Errorf("true is %+v, want %+v", spec, want)
+	}
+	// the quotes of strings match their text
+	want = colorSpecOf(DefaultStringQuoteColor)
+	want.Italic, want.Underline = true, true
+	if spec := colorOf(t, got, `"`); spec != want {
+		t.Errorf("string quote is %+v, want %+v", spec, want)
+	}
+
+	// bold field names, including their quotes, in the default
+	// foreground
+	f = &Formatter{FieldColor: color.New(color.FgBlue), FieldQuoteColor: color.New(color.FgBlue), StringColor: color.New(color.FgGreen)}
+	f.SetAttributes(TokenField, color.Bold)
+	got = formatString(t, f, `{"a":"b"}`)
+	if s, want := colored(got, "34;1"), `"a"`; s != want {
+		t.Errorf("SetAttributes(TokenField, Bold) displays %q bold, want %q", s, want)
+	}
+	if s, want := colored(got, "32"), `"b"`; s != want {
+		t.Errorf("SetAttributes(TokenField, Bold) colors %q as strings, want %q", s, want)
+	}
+
+	// the quotes can be set on their own
+	f.SetAttributes(TokenFieldQuote, color.Underline)
+	got = formatString(t, f, `{"a":"b"}`)
+	if s, want := colored(got, "34;4"), `""`; s != want {
+		t.Errorf("SetAttributes(TokenFieldQuote, Underline) displays %q underlined, want %q", s, want)
+	}
+	if s, want := colored(got, "34;1"), `a`; s != want {
+		t.Errorf("SetAttributes(TokenFieldQuote, Underline) displays %q bold, want %q", s, want)
+	}
+}
+
+func TestReplaceAttributes(t *testing.T) {
+	tests := []struct {
+		params string
+		attrs  []color.Attribute
+		want   string
+	}{
+		{"", []color.Attribute{color.Bold}, "1"},
+		{"1;34", nil, "34"},
+		{"1;34;4", []color.Attribute{color.Italic}, "34;3"},
+		{"38;5;208;1", []color.Attribute{color.Underline}, "38;5;208;4"},
+		{"48;2;1;2;3;2", []color.Attribute{color.Bold}, "48;2;1;2;3;1"},
+		{"91;103;7", nil, "91;103"},
+	}
+	for _, tt := range tests {
+		if got := replaceAttributes(tt.params, tt.attrs); got != tt.want {
+			t.Errorf("replaceAttributes(%q, %v) = %q, want %q", tt.params, tt.attrs, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAllowlist(t *testing.T) {
+	src := `{"user":{"name":"ann","email":"a@example.com"},"tags":["a",1],"id":7}`
+	buf := &bytes.Buffer{}
+	err := (&Formatter{}).FormatAllowlist(buf, []byte(src), []string{"/user/name", "/tags"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"user":{"name":"ann","email":"…"},"tags":["a",1],"id":#}`
+	if got := uncolored(buf.String()); got != want {
+		t.Errorf("FormatAllowlist = %q, want %q", got, want)
+	}
+
+	src = `{"a/b":{"c":[true,null,{"d":"x"}]},"e":"{\"f\":1}","g":[[1],[2]]}`
+	tests := []struct {
+		f       *Formatter
+		allowed []string
+		want    string
+	}{
+		{&Formatter{}, []string{""}, src},
+		{&Formatter{}, nil, `{"a/b":{"c":[bool,null,{"d":"…"}]},"e":"…","g":[[#],[#]]}`},
+		{&Formatter{}, []string{"/a~1b/c/2", "/g/1"}, `{"a/b":{"c":[bool,null,{"d":"x"}]},"e":"…","g":[[#],[2]]}`},
+		{&Formatter{UnwrapEmbeddedJSON: true}, []string{"/g"}, `{"a/b":{"c":[bool,null,{"d":"…"}]},"e":"…","g":[[1],[2]]}`},
+		{&Formatter{UnwrapEmbeddedJSON: true}, []string{"/e"}, `{"a/b":{"c":[bool,null,{"d":"…"}]},"e":"{\"f\":1}","g":[[#],[#]]}`},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		err := tt.f.FormatAllowlist(buf, []byte(src), tt.allowed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := uncolored(buf.String()); got != tt.want {
+			t.Errorf("FormatAllowlist(%q) = %q, want %q", tt.allowed, got, tt.want)
+		}
+	}
+
+	if err := (&Formatter{}).FormatAllowlist(&bytes.Buffer{}, []byte(src), []string{"a"}); err == nil {
+		t.Error("FormatAllowlist with an invalid pointer succeeded")
+	}
+}
+
+func TestAlignNumbers(t *testing.T) {
+	got := uncolored(formatString(t, &Formatter{Indent: "  ", AlignNumbers: true}, `{"a":[1.5,-20,300.25,4e2],"b":[1,"x"]}`))
+	want := "{\n" +
+		"  \"a\": [\n      1.5,\n    -20,\n    300.25,\n      4e2\n  ],\n" +
+		"  \"b\": [\n    1,\n    \"x\"\n  ]\n}"
+	if got != want {
+		t.Errorf("Format with AlignNumbers = %q, want %q", got, want)
+	}
+
+	// FloatPrecision and NumberNotation are taken into account
+	f := &Formatter{Indent: "  ", AlignNumbers: true, FloatPrecision: 1, NumberNotation: DecimalNotation}
+	got = uncolored(formatString(t, f, `[1.25,1e2]`))
+	if want := "[\n    1.2,\n  100\n]"; got != want {
+		t.Errorf("Format with AlignNumbers = %q, want %q", got, want)
+	}
+
+	// the padding is colored with SpaceColor
+	f = &Formatter{Indent: "  ", AlignNumbers: true, SpaceColor: color.New(color.BgBlue)}
+	got = formatString(t, f, `[1,10]`)
+	if s, want := colored(got, "44"), "\n   \n  \n"; s != want {
+		t.Errorf("Format with AlignNumbers colors %q with SpaceColor, want %q", s, want)
+	}
+
+	got = uncolored(formatString(t, &Formatter{AlignNumbers: true}, `[1,10]`))
+	if want := `[1,10]`; got != want {
+		t.Errorf("compact Format with AlignNumbers = %q, want %q", got, want)
+	}
+}
+
+func TestRootLabel(t *testing.T) {
+	got := formatString(t, &Formatter{Indent: "  ", RootLabel: "before"}, `{"a":1}`)
+	if s, want := uncolored(got), "before\n{\n  \"a\": 1\n}"; s != want {
+		t.Errorf("Format with RootLabel = %q, want %q", s, want)
+	}
+	if spec, want := colorOf(t, got, "before"), colorSpecOf(DefaultRootLabelColor); spec != want {
+		t.Errorf("label is %+v, want %+v", spec, want)
+	}
+
+	got = uncolored(formatString(t, &Formatter{RootLabel: "doc", FocusPath: "/a/0", ShowBreadcrumb: true}, `{"a":[1]}`))
+	if want := "doc ▸ a ▸ [0]\n{\"a\":[1]}"; got != want {
+		t.Errorf("Format with RootLabel and ShowBreadcrumb = %q, want %q", got, want)
+	}
+
+	// the layouts other than a single JSON document leave out the
+	// lines added before or after the document
+	whole := &Formatter{RootLabel: "doc", MarkdownFence: true, ShowSummary: true, AppendLegend: true, ShowBreadcrumb: true}
+	layouts := map[string]func(f *Formatter, dst io.Writer) error{
+		"FormatFlat": func(f *Formatter, dst io.Writer) error {
+			return f.FormatFlat(dst, []byte(sample))
+		},
+		"FormatArrayAsLines": func(f *Formatter, dst io.Writer) error {
+			return f.FormatArrayAsLines(dst, []byte(`[{"a":1},[2]]`))
+		},
+		"FormatTable": func(f *Formatter, dst io.Writer) error {
+			return f.FormatTable(dst, []byte(`[{"a":1},{"a":2}]`))
+		},
+		"FormatSideBySide": func(f *Formatter, dst io.Writer) error {
+			return f.FormatSideBySide(dst, []byte(`{"a":1}`), []byte(`{"a":2}`), 40)
+		},
+		"FormatForLogger": func(f *Formatter, dst io.Writer) error {
+			s, err := f.FormatForLogger([]byte(sample))
+			io.WriteString(dst, s)
+			return err
+		},
+	}
+	for name, layout := range layouts {
+		want, buf := &bytes.Buffer{}, &bytes.Buffer{}
+		if err := layout(&Formatter{}, want); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := layout(whole, buf); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := uncolored(buf.String()); got != uncolored(want.String()) {
+			t.Errorf("%s with whole-document options = %q, want %q", name, got, uncolored(want.String()))
+		}
+	}
+}
+
+func TestHighlightReplacementChars(t *testing.T) {
+	src := "{\"k\ufffd\":\"a\ufffdb\",\"c\":\"\\ufffd\",\"d\":\"x\"}"
+	got := formatString(t, &Formatter{HighlightReplacementChars: true}, src)
+	want := "{\"k\ufffd\":\"a\ufffdb\",\"c\":\"\ufffd\",\"d\":\"x\"}"
+	if s := uncolored(got); s != want {
+		t.Errorf("Format with HighlightReplacementChars = %q, want %q", s, want)
+	}
+
+	// only the bytes of the replacement characters are highlighted
+	replacement := colorSpecOf(DefaultReplacementCharColor)
+	var highlighted []byte
+	cells, _ := render(got)
+	for _, c := range cells {
+		if c.spec == replacement {
+			highlighted = append(highlighted, c.b)
+		}
+	}
+	if s := strings.Repeat("\ufffd", 3); string(highlighted) != s {
+		t.Errorf("Format with HighlightReplacementChars highlights %q, want %q", highlighted, s)
+	}
+}
+
+func TestColorWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cw := NewColorWriterWithFormatter(buf, &Formatter{})
+	enc := json.NewEncoder(cw)
+	enc.Encode(map[string]int{"a": 1})
+	enc.Encode([]string{"x"})
+	if buf.Len() > 0 {
+		t.Errorf("ColorWriter wrote %q before Close", buf.String())
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := uncolored(buf.String()), "{\"a\":1}\n[\"x\"]\n"; got != want {
+		t.Errorf("ColorWriter wrote %q, want %q", got, want)
+	}
+	if _, err := cw.Write([]byte("1")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+
+	// a document split across Write calls is colorized as a whole
+	buf.Reset()
+	cw = NewColorWriterWithFormatter(buf, &Formatter{})
+	for _, s := range []string{`{"a":`, `[1,`, `"x"]}`} {
+		if _, err := io.WriteString(cw, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if spec, want := colorOf(t, buf.String(), `"x"`), colorSpecOf(color.New(color.FgGreen)); spec != want {
+		t.Errorf("string written across Write calls is %+v, want %+v", spec, want)
+	}
+	if got, want := uncolored(buf.String()), "{\"a\":[1,\"x\"]}\n"; got != want {
+		t.Errorf("ColorWriter wrote %q, want %q", got, want)
+	}
+}
+
+func TestIndentOverrides(t *testing.T) {
+	f := &Formatter{Indent: "    ", IndentOverrides: map[string]string{"/a": " ", "/a/b": "\t"}}
+	got := uncolored(formatString(t, f, `{"a":{"b":[1],"c":[2]},"d":[3]}`))
+	want := "{\n" +
+		"    \"a\": {\n" +
+		"     \"b\": [\n     \t1\n     ],\n" +
+		"     \"c\": [\n      2\n     ]\n" +
+		"    },\n" +
+		"    \"d\": [\n        3\n    ]\n}"
+	if got != want {
+		t.Errorf("Format with IndentOverrides = %q, want %q", got, want)
+	}
+
+	f = &Formatter{Prefix: "> ", IndentOverrides: map[string]string{"": "  "}}
+	if got, want := uncolored(formatString(t, f, `[1]`)), "> [\n>   1\n> ]"; got != want {
+		t.Errorf("Format with IndentOverrides and Prefix = %q, want %q", got, want)
+	}
+
+	f = &Formatter{Indent: "  ", IndentOverrides: map[string]string{"a": " "}}
+	if err := f.Format(ioutil.Discard, []byte(`{"a":[1]}`)); err == nil {
+		t.Error("Format with invalid IndentOverrides pointer succeeded")
+	}
+}
+
+func TestMaxColors(t *testing.T) {
+	for n := 1; n <= 4; n++ {
+		got := formatString(t, &Formatter{Indent: "  ", MaxColors: n}, sample)
+		if s, want := uncolored(got), indented(t, sample, "", "  "); s != want {
+			t.Errorf("Format with MaxColors %d = %q, want %q", n, s, want)
+		}
+		colors := map[ColorSpec]bool{}
+		cells, _ := render(got)
+		for _, c := range cells {
+			if c.spec != (ColorSpec{}) {
+				colors[c.spec] = true
+			}
+		}
+		if len(colors) > n {
+			t.Errorf("Format with MaxColors %d uses %d colors", n, len(colors))
+		}
+	}
+}
+
+func TestAnnotateDetectedFormats(t *testing.T) {
+	f := &Formatter{Indent: "  ", AnnotateDetectedFormats: true, Annotations: map[string]string{"/b": "given"}}
+	got := uncolored(formatString(t, f, `{"a":"2024-01-01T00:00:00Z","b":"2024-01-01T00:00:00Z","c":"x"}`))
+	want := "{\n" +
+		"  \"a\": \"2024-01-01T00:00:00Z\",  // RFC3339\n" +
+		"  \"b\": \"2024-01-01T00:00:00Z\",  // given\n" +
+		"  \"c\": \"x\"\n}"
+	if got != want {
+		t.Errorf("Format with AnnotateDetectedFormats = %q, want %q", got, want)
+	}
+
+	// each detector annotates its values, while other strings,
+	// field names and compact output are left alone
+	f = &Formatter{Indent: "  ", AnnotateDetectedFormats: true}
+	for _, tt := range []struct {
+		value, format string
+	}{
+		{`"123e4567-e89b-12d3-a456-426614174000"`, "UUID"},
+		{`"2024-01-01T00:00:00.5+01:00"`, "RFC3339"},
+		{`"https://example.com/a?b=c"`, "URL"},
+		{`"aGVsbG8sIHdvcmxkIQ=="`, "base64"},
+		{`"123e4567-e89b-12d3-a456-42661417400g"`, ""},
+		{`"2024-01-01"`, ""},
+		{`"/a/b"`, ""},
+		{`"abcdefghijklmnopqrst"`, ""},
+		{`20240101`, ""},
+	} {
+		want := "[\n  " + tt.value + "\n]"
+		if len(tt.format) > 0 {
+			want = "[\n  " + tt.value + "  // " + tt.format + "\n]"
+		}
+		if got := uncolored(formatString(t, f, "["+tt.value+"]")); got != want {
+			t.Errorf("Format(%s) with AnnotateDetectedFormats = %q, want %q", tt.value, got, want)
+		}
+	}
+	src := `{"https://example.com/":"https://example.com/"}`
+	if got, want := uncolored(formatString(t, f, src)), "{\n  \"https://example.com/\": \"https://example.com/\"  // URL\n}"; got != want {
+		t.Errorf("Format(%s) with AnnotateDetectedFormats = %q, want %q", src, got, want)
+	}
+	if got := uncolored(formatString(t, &Formatter{AnnotateDetectedFormats: true}, src)); got != src {
+		t.Errorf("compact Format(%s) with AnnotateDetectedFormats = %q", src, got)
+	}
+}
+
+func TestFormatAndLint(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lints, err := (&Formatter{}).FormatAndLint(buf, []byte(`{"a":1.50,"b":[-0],"a":2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := uncolored(buf.String()), `{"a":1.50,"b":[-0],"a":2}`; got != want {
+		t.Errorf("FormatAndLint wrote %q, want %q", got, want)
+	}
+	want := []struct {
+		pointer  string
+		severity LintSeverity
+	}{
+		{"/a", LintInfo},
+		{"/b/0", LintInfo},
+		{"/a", LintError},
+	}
+	if len(lints) != len(want) {
+		t.Fatalf("FormatAndLint = %+v, want %d lints", lints, len(want))
+	}
+	for i, l := range lints {
+		if l.Pointer != want[i].pointer || l.Severity != want[i].severity || l.Message == "" {
+			t.Errorf("lint %d is %+v, want %s at %s", i, l, want[i].severity, want[i].pointer)
+		}
+	}
+
+	deep := strings.Repeat("[", 34) + strings.Repeat("]", 34)
+	long := `"` + strings.Repeat("x", 64*1024+1) + `"`
+	for _, tt := range []struct {
+		src      string
+		pointer  string
+		severity LintSeverity
+	}{
+		{`[1E+06]`, "/0", LintInfo},
+		{`{"a":{"b":0.0}}`, "/a/b", LintInfo},
+		{deep, "/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0/0", LintWarning},
+		{`{"a~b":` + long + `}`, "/a~0b", LintWarning},
+	} {
+		lints, err := (&Formatter{}).FormatAndLint(ioutil.Discard, []byte(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lints) != 1 || lints[0].Pointer != tt.pointer || lints[0].Severity != tt.severity {
+			t.Errorf("FormatAndLint(%.40s) = %+v, want a %s at %s", tt.src, lints, tt.severity, tt.pointer)
+		}
+	}
+
+	for _, src := range []string{`[1,2.5,-1e1]`, `{"a":{"a":1}}`, `"x"`} {
+		lints, err := (&Formatter{}).FormatAndLint(ioutil.Discard, []byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(lints) > 0 {
+			t.Errorf("FormatAndLint(%s) = %+v, want no lints", src, lints)
+		}
+	}
+
+	// the issues found before an error are returned with it
+	lints, err = (&Formatter{}).FormatAndLint(ioutil.Discard, []byte(`[-0,`))
+	if err == nil || len(lints) != 1 {
+		t.Errorf("FormatAndLint of invalid input = %+v, %v", lints, err)
+	}
+}
+
+func TestOutputEscape(t *testing.T) {
+	got := formatString(t, &Formatter{OutputEscape: html.EscapeString}, `{"<a>":"x&y"}`)
+	if s, want := uncolored(got), `{&#34;&lt;a&gt;&#34;:&#34;x&amp;y&#34;}`; s != want {
+		t.Errorf("Format with OutputEscape = %q, want %q", s, want)
+	}
+	if spec, want := colorOf(t, got, "&amp;"), colorSpecOf(DefaultStringColor); spec != want {
+		t.Errorf("escaped string is %+v, want %+v", spec, want)
+	}
+}
+
+func TestFormatShape(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`{"id":1,"tags":["a","b","c"],"owner":{"a":1,"b":2},"ok":true,"s":"x","n":null}`,
+			`{"id": #, "tags": [3], "owner": {2}, "ok": bool, "s": "…", "n": null}`},
+		{`[{"a":1,"b":2},{"a":3,"b":4},{"c":5,"d":6},null]`, `[{2}×3, null]`},
+		{`[1,2,"a","b",[],[1],{}]`, `[#×2, "…"×2, [0], [1], {0}]`},
+		{`{"a":[],"b":{}}`, `{"a": [0], "b": {0}}`},
+		{`[]`, `[]`},
+		{`42`, `#`},
+	}
+	for _, tt := range tests {
+		b, err := (&Formatter{}).FormatShape([]byte(tt.src))
+		if err != nil {
+			t.Fatalf("FormatShape(%s): %v", tt.src, err)
+		}
+		if got := uncolored(string(b)); got != tt.want {
+			t.Errorf("FormatShape(%s) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	// the summaries are colored by the type of the value
+	f := &Formatter{
+		FieldColor:  color.New(color.FgBlue),
+		NumberColor: color.New(color.FgRed),
+		StringColor: color.New(color.FgYellow),
+		NullColor:   color.New(color.FgCyan),
+	}
+	b, err := f.FormatShape([]byte(`{"a":1,"b":"x","c":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for params, want := range map[string]string{"34": "abc", "31": "#", "33": "…", "36": "null"} {
+		if s := colored(string(b), params); s != want {
+			t.Errorf("FormatShape colors %q with %s, want %q", s, params, want)
+		}
+	}
+	if _, active := render(string(b)); active != "" {
+		t.Errorf("FormatShape leaves %q in effect", active)
+	}
+
+	if b, err := (&Formatter{}).FormatShape([]byte(`[1,`)); err == nil {
+		t.Errorf("FormatShape of invalid input = %q, want error", b)
+	}
+}
+
+func TestHighlightKeyWhitespace(t *testing.T) {
+	got := formatString(t, &Formatter{HighlightKeyWhitespace: true}, `{"  id ":" x ","a b":1}`)
+	if s, want := uncolored(got), `{"··id·":" x ","a b":1}`; s != want {
+		t.Errorf("Format with HighlightKeyWhitespace = %q, want %q", s, want)
+	}
+	if spec, want := colorOf(t, got, "··"), colorSpecOf(DefaultTrailingSpaceColor); spec != want {
+		t.Errorf("key whitespace is %+v, want %+v", spec, want)
+	}
+	if spec, want := colorOf(t, got, "id"), colorSpecOf(DefaultFieldColor); spec != want {
+		t.Errorf("key is %+v, want %+v", spec, want)
+	}
+
+	got = uncolored(formatString(t, &Formatter{HighlightKeyWhitespace: true}, `{"  ":1," ":{" a":2}}`))
+	if want := `{"··":1,"·":{"·a":2}}`; got != want {
+		t.Errorf("Format with HighlightKeyWhitespace = %q, want %q", got, want)
+	}
+	if got := uncolored(formatString(t, &Formatter{}, `{" id ":1}`)); got != `{" id ":1}` {
+		t.Errorf("Format highlights key whitespace by default: %q", got)
+	}
+}
+
+func TestNumberLocale(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"de", `[1.234.567,89,-1.000,12,3e4]`},
+		{"en", `[1,234,567.89,-1,000,12,3e4]`},
+		{"fr-CH", "[1\u202f234\u202f567.89,-1\u202f000,12,3e4]"},
+		{"DE_ch", `[1’234’567.89,-1’000,12,3e4]`},
+		{"pt-PT", `[1.234.567,89,-1.000,12,3e4]`},
+		{"xx", `[1234567.89,-1000,12,3e4]`},
+	}
+	for _, tt := range tests {
+		got := uncolored(formatString(t, &Formatter{NumberLocale: tt.locale}, `[1234567.89,-1000,12,3e4]`))
+		if got != tt.want {
+			t.Errorf("Format with NumberLocale %q = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+
+	// numbers are aligned on the locale's decimal separator
+	got := uncolored(formatString(t, &Formatter{Indent: " ", NumberLocale: "de", AlignNumbers: true}, `[1234.5,2.25]`))
+	if want := "[\n 1.234,5,\n     2,25\n]"; got != want {
+		t.Errorf("Format with NumberLocale and AlignNumbers = %q, want %q", got, want)
+	}
+	if spec, want := colorOf(t, formatString(t, &Formatter{NumberLocale: "de", NumberColor: color.New(color.FgRed)}, `1234.5`), "1.234,5"), colorSpecOf(color.New(color.FgRed)); spec != want {
+		t.Errorf("localized number is %+v, want %+v", spec, want)
+	}
+}
+
+func TestHighlightStringNumbers(t *testing.T) {
+	got := formatString(t, &Formatter{HighlightStringNumbers: true}, `["42","4.2e1","not a number","x42",42]`)
+	stringNumber := colorSpecOf(DefaultStringNumberColor)
+	for _, s := range []string{`"42"`, `"4.2e1"`} {
+		if spec := colorOf(t, got, s); spec != stringNumber {
+			t.Errorf("%s is %+v, want %+v", s, spec, stringNumber)
+		}
+	}
+	for _, s := range []string{`"not a number"`, `"x42"`} {
+		if spec, want := colorOf(t, got, s), colorSpecOf(DefaultStringColor); spec != want {
+			t.Errorf("%s is %+v, want %+v", s, spec, want)
+		}
+	}
+	if spec, want := colorOf(t, got, `42]`), colorSpecOf(DefaultNumberColor); spec != want {
+		t.Errorf("42 is %+v, want %+v", spec, want)
+	}
+
+	for _, s := range []string{`"-0.5"`, `"1E+2"`} {
+		got := formatString(t, &Formatter{HighlightStringNumbers: true, StringNumberColor: color.New(color.FgRed)}, s)
+		if spec, want := colorOf(t, got, s), colorSpecOf(color.New(color.FgRed)); spec != want {
+			t.Errorf("%s is %+v, want %+v", s, spec, want)
+		}
+	}
+	for _, s := range []string{`"01"`, `"1."`, `"+1"`, `" 1"`, `""`, `"NaN"`} {
+		got := formatString(t, &Formatter{HighlightStringNumbers: true}, s)
+		if spec, want := colorOf(t, got, s), colorSpecOf(DefaultStringColor); spec != want {
+			t.Errorf("%s is %+v, want %+v", s, spec, want)
+		}
+	}
+	if got := formatString(t, &Formatter{}, `"42"`); colorOf(t, got, `"42"`) != colorSpecOf(DefaultStringColor) {
+		t.Error("Format highlights numeric strings by default")
+	}
+}
+
+func TestArrayInlineFirstElement(t *testing.T) {
+	f := &Formatter{Indent: "  ", ArrayInlineFirstElement: true}
+	got := uncolored(formatString(t, f, `{"a":[1,2],"b":[],"c":[[3],{"d":4}]}`))
+	want := "{\n" +
+		"  \"a\": [1,\n    2\n  ],\n" +
+		"  \"b\": [],\n" +
+		"  \"c\": [[3\n    ],\n    {\n      \"d\": 4\n    }\n  ]\n}"
+	if got != want {
+		t.Errorf("Format with ArrayInlineFirstElement = %q, want %q", got, want)
+	}
+
+	// arrays collapsed by ShouldExpand stay inline, and elements
+	// hidden by MaxArrayElements and lines given a Prefix follow
+	// the first element as usual
+	src := `{"a":[1,2,3],"b":[[4,5],6]}`
+	tests := []struct {
+		name string
+		f    *Formatter
+		want string
+	}{
+		{"ShouldExpand", &Formatter{ShouldExpand: func(path []string, kind byte, n, w int) bool { return w > 6 }},
+			"{\n  \"a\": [1,\n    2,\n    3\n  ],\n  \"b\": [[4,5],\n    6\n  ]\n}"},
+		{"MaxArrayElements", &Formatter{MaxArrayElements: 1},
+			"{\n  \"a\": [1,\n    … (2 more)\n  ],\n  \"b\": [[4,\n      … (1 more)\n    ],\n    … (1 more)\n  ]\n}"},
+		{"Prefix", &Formatter{Prefix: "> "},
+			"> {\n>   \"a\": [1,\n>     2,\n>     3\n>   ],\n>   \"b\": [[4,\n>       5\n>     ],\n>     6\n>   ]\n> }"},
+	}
+	for _, tt := range tests {
+		tt.f.Indent = "  "
+		tt.f.ArrayInlineFirstElement = true
+		if got := uncolored(formatString(t, tt.f, src)); got != tt.want {
+			t.Errorf("Format with ArrayInlineFirstElement and %s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := uncolored(formatString(t, &Formatter{ArrayInlineFirstElement: true}, src)); got != src {
+		t.Errorf("compact Format with ArrayInlineFirstElement = %q, want %q", got, src)
+	}
+}
+
+func TestHighlightBooleanStrings(t *testing.T) {
+	f := &Formatter{HighlightBooleanStrings: true, HighlightStringNumbers: true, BooleanStrings: map[string]bool{"1": true, "yes": true}}
+	got := formatString(t, f, `["YES","1","no","x"]`)
+	tests := []struct {
+		s    string
+		want SprintfFuncer
+	}{
+		{`"YES"`, DefaultTrueColor},
+		{`"1"`, DefaultTrueColor},
+		{`"no"`, DefaultStringColor},
+		{`"x"`, DefaultStringColor},
+	}
+	for _, tt := range tests {
+		if spec, want := colorOf(t, got, tt.s), colorSpecOf(tt.want); spec != want {
+			t.Errorf("%s is %+v, want %+v", tt.s, spec, want)
+		}
+	}
+
+	// the default set is used without BooleanStrings
+	got = formatString(t, &Formatter{HighlightBooleanStrings: true}, `["true","No","maybe","false",true]`)
+	tests = []struct {
+		s    string
+		want SprintfFuncer
+	}{
+		{`"true"`, DefaultTrueColor},
+		{`"No"`, DefaultFalseColor},
+		{`"maybe"`, DefaultStringColor},
+		{`"false"`, DefaultFalseColor},
+	}
+	for _, tt := range tests {
+		if spec, want := colorOf(t, got, tt.s), colorSpecOf(tt.want); spec != want {
+			t.Errorf("%s is %+v, want %+v", tt.s, spec, want)
+		}
+	}
+
+	got = formatString(t, &Formatter{HighlightBooleanStrings: true}, `["off"]`)
+	if spec, want := colorOf(t, got, `"off"`), colorSpecOf(DefaultFalseColor); spec != want {
+		t.Errorf(`"off" is %+v, want %+v`, spec, want)
+	}
+
+	f = &Formatter{HighlightBooleanStrings: true, TrueColor: color.New(color.FgRed), FalseColor: color.New(color.FgBlue)}
+	got = formatString(t, f, `["yes","off"]`)
+	if spec, want := colorOf(t, got, `"yes"`), colorSpecOf(color.New(color.FgRed)); spec != want {
+		t.Errorf(`"yes" is %+v, want %+v`, spec, want)
+	}
+	if spec, want := colorOf(t, got, `"off"`), colorSpecOf(color.New(color.FgBlue)); spec != want {
+		t.Errorf(`"off" is %+v, want %+v`, spec, want)
+	}
+	if got := formatString(t, &Formatter{}, `"yes"`); colorOf(t, got, `"yes"`) != colorSpecOf(DefaultStringColor) {
+		t.Error("Format highlights boolean strings by default")
+	}
+}
+
+func TestWrapLongValues(t *testing.T) {
+	f := &Formatter{Indent: "  ", WrapLongValues: 8}
+	got := uncolored(formatString(t, f, `{"a":"short","b":"much longer","c":{"d":123456789}}`))
+	want := "{\n" +
+		"  \"a\": \"short\",\n" +
+		"  \"b\":\n    \"much longer\",\n" +
+		"  \"c\": {\n    \"d\":\n      123456789\n  }\n}"
+	if got != want {
+		t.Errorf("Format with WrapLongValues = %q, want %q", got, want)
+	}
+
+	got = uncolored(formatString(t, &Formatter{WrapLongValues: 1}, `{"a":"xyz"}`))
+	if want := `{"a":"xyz"}`; got != want {
+		t.Errorf("compact Format with WrapLongValues = %q, want %q", got, want)
+	}
+
+	// values as wide as the threshold stay after the colon
+	for width, want := range map[int]string{
+		7: "> {\n>  \"a\": \"short\"\n> }",
+		6: "> {\n>  \"a\":\n>   \"short\"\n> }",
+	} {
+		f := &Formatter{Prefix: "> ", Indent: " ", WrapLongValues: width}
+		if got := uncolored(formatString(t, f, `{"a":"short"}`)); got != want {
+			t.Errorf("Format with WrapLongValues %d = %q, want %q", width, got, want)
+		}
+	}
+
+	// array elements are not moved
+	got = uncolored(formatString(t, &Formatter{Indent: " ", WrapLongValues: 1}, `["xyz"]`))
+	if want := "[\n \"xyz\"\n]"; got != want {
+		t.Errorf("Format of array with WrapLongValues = %q, want %q", got, want)
+	}
+}
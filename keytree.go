@@ -0,0 +1,127 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FormatKeyTree appends to dst a colorized tree of the field names of
+// the JSON-encoded src without their values, with one line per field
+// indented by f's Prefix and Indent, or DefaultIndent if both are
+// empty, according to its nesting, as a table of contents for
+// finding where a value lives in a large document.  Arrays are
+// displayed after their field names as their number of elements,
+// for example [3], and the elements of arrays that are objects or
+// arrays are displayed below them by index, for example [0], with
+// their own field names or elements below them.  Scalar values are
+// not displayed.  Note that the output is therefore not valid JSON.
+func (f *Formatter) FormatKeyTree(dst io.Writer, src []byte) error {
+	fs := newFormatterState(f.dataOnly(), dst)
+	defer fs.closeColor()
+	dec := fs.decoder(src)
+
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	root, err := readKeyTree(dec, t)
+	if err != nil {
+		return err
+	}
+
+	prefix, indent := f.Prefix, f.Indent
+	if len(prefix) == 0 && len(indent) == 0 {
+		indent = DefaultIndent
+	}
+
+	lines := 0
+	var printNode func(n *keyTreeNode, depth int)
+	printNode = func(n *keyTreeNode, depth int) {
+		if lines > 0 {
+			fs.printSpace("\n", true)
+		}
+		lines++
+		fs.printSpace(prefix+strings.Repeat(indent, depth), true)
+		if n.index >= 0 {
+			fs.print(TokenArray, "[")
+			fs.print(TokenNumber, strconv.Itoa(n.index))
+			fs.print(TokenArray, "]")
+		} else {
+			fs.print(TokenField, n.key)
+		}
+		if n.array {
+			fs.printSpace(" ", true)
+			fs.print(TokenArray, fmt.Sprintf("[%d]", n.size))
+		}
+		for _, c := range n.children {
+			printNode(c, depth+1)
+		}
+	}
+
+	if root.array {
+		// the outermost array is displayed as its number of
+		// elements above them
+		fs.print(TokenArray, fmt.Sprintf("[%d]", root.size))
+		lines++
+		for _, c := range root.children {
+			printNode(c, 1)
+		}
+		return nil
+	}
+	for _, c := range root.children {
+		printNode(c, 0)
+	}
+	return nil
+}
+
+// keyTreeNode is a field or array element displayed by FormatKeyTree,
+// identified by its field name key, or by index if index is not
+// negative.  If the value is an array, array is true and size is its
+// number of elements.  children are the fields of the value if it is
+// an object, or its elements that are objects or arrays.
+type keyTreeNode struct {
+	key      string
+	index    int
+	array    bool
+	size     int
+	children []*keyTreeNode
+}
+
+// readKeyTree consumes the value beginning with token t, returning
+// the node holding its fields or elements.
+func readKeyTree(dec tokenReader, t json.Token) (*keyTreeNode, error) {
+	n := &keyTreeNode{index: -1}
+	open, ok := t.(json.Delim)
+	if !ok {
+		return n, nil
+	}
+	n.array = open == json.Delim('[')
+	for i := 0; dec.More(); i++ {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, index := "", i
+		if !n.array {
+			key, index = objectKey(t), -1
+			t, err = dec.Token()
+			if err != nil {
+				return nil, err
+			}
+		}
+		c, err := readKeyTree(dec, t)
+		if err != nil {
+			return nil, err
+		}
+		c.key, c.index = key, index
+		if _, container := t.(json.Delim); !n.array || container {
+			n.children = append(n.children, c)
+		}
+		n.size++
+	}
+	_, err := dec.Token()
+	return n, err
+}
@@ -0,0 +1,58 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatKeyTree(t *testing.T) {
+	tests := []struct {
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{&Formatter{}, `"x"`, ``},
+		{&Formatter{}, `{"a":1,"b":"x"}`, "a\nb"},
+		{&Formatter{}, `{"a":{"b":{"c":1}},"d":null}`, "a\n  b\n    c\nd"},
+		{&Formatter{Indent: "\t"}, `{"tags":[1,2,3],"items":[{"id":1},[true],"x"]}`,
+			"tags [3]\nitems [3]\n\t[0]\n\t\tid\n\t[1] [1]"},
+		{&Formatter{}, `[{"a":1},{"b":[]}]`, "[2]\n  [0]\n    a\n  [1]\n    b [0]"},
+		{&Formatter{Prefix: "# ", Indent: "- "}, `{"a":{"b":1}}`, "# a\n# - b"},
+		{&Formatter{LeftMargin: 1}, `{"a":{"b":1}}`, " a\n   b"},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		err := tt.f.FormatKeyTree(buf, []byte(tt.src))
+		if err != nil {
+			t.Fatalf("FormatKeyTree(%s): %v", tt.src, err)
+		}
+		if got := uncolored(buf.String()); got != tt.want {
+			t.Errorf("FormatKeyTree(%s) = %q, want %q", tt.src, got, tt.want)
+		}
+		if _, active := render(buf.String()); active != "" {
+			t.Errorf("FormatKeyTree(%s) leaves %q in effect", tt.src, active)
+		}
+	}
+}
+
+func TestFormatKeyTreeOutput(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := &Formatter{FieldColor: color.New(color.FgBlue), ArrayColor: color.New(color.FgMagenta), NumberColor: color.New(color.FgRed)}
+	err := f.FormatKeyTree(buf, []byte(`{"a":[{"b":1}],"c":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for params, want := range map[string]string{"34": "abc", "35": "[1][]", "31": "0"} {
+		if s := colored(buf.String(), params); s != want {
+			t.Errorf("FormatKeyTree colors %q with %s, want %q", s, params, want)
+		}
+	}
+
+	for _, src := range []string{`{"a":`, `{"a":[1,}`, ``} {
+		if err := (&Formatter{}).FormatKeyTree(&bytes.Buffer{}, []byte(src)); err == nil {
+			t.Errorf("FormatKeyTree(%s) succeeded", src)
+		}
+	}
+}
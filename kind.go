@@ -0,0 +1,130 @@
+package jsoncolor
+
+import "fmt"
+
+// TokenKind identifies the kind of a token in colorized output.
+// Each kind corresponds to one of Formatter's color fields.
+type TokenKind int
+
+const (
+	// TokenSpace is whitespace.
+	TokenSpace TokenKind = iota
+	// TokenComma is the comma character ',' delimiting object
+	// and array fields.
+	TokenComma
+	// TokenColon is the colon character ':' separating object
+	// field names and values.
+	TokenColon
+	// TokenObject is an object delimiter character '{' or '}'.
+	TokenObject
+	// TokenArray is an array delimiter character '[' or ']'.
+	TokenArray
+	// TokenFieldQuote is a quote '"' surrounding an object field
+	// name.
+	TokenFieldQuote
+	// TokenField is an object field name.
+	TokenField
+	// TokenStringQuote is a quote '"' surrounding a string value.
+	TokenStringQuote
+	// TokenString is a string value.
+	TokenString
+	// TokenTrue is a 'true' boolean value.
+	TokenTrue
+	// TokenFalse is a 'false' boolean value.
+	TokenFalse
+	// TokenNumber is a number value.
+	TokenNumber
+	// TokenNull is a null value.
+	TokenNull
+	// TokenMore is an indicator summarizing elements omitted
+	// from truncated output.
+	TokenMore
+	// TokenUnparseable is a placeholder for an invalid value or
+	// field name, see Formatter.OnUnparseable.
+	TokenUnparseable
+	// TokenComment is a comment annotating a value, see
+	// Formatter.Annotations.
+	TokenComment
+	// TokenOffset is the input offset of a token, see
+	// Formatter.DebugOffsets.
+	TokenOffset
+	// TokenTrailingSpace is a run of trailing spaces in a string
+	// value or of spaces surrounding a field name, see
+	// Formatter.ShowTrailingSpaceInStrings and
+	// Formatter.HighlightKeyWhitespace.
+	TokenTrailingSpace
+	// TokenVisibleWhitespace is a glyph displaying a whitespace
+	// character in a string, see
+	// Formatter.VisibleStringWhitespace.
+	TokenVisibleWhitespace
+	// TokenUnit is the human-readable form of a number, see
+	// Formatter.InferUnits.
+	TokenUnit
+	// TokenEllipsis is an ellipsis marking omitted or truncated
+	// output, see Formatter.Ellipsis.
+	TokenEllipsis
+	// TokenMarkupTag is the name and delimiters of an HTML or XML
+	// tag in a string, see Formatter.DetectMarkupInStrings.
+	TokenMarkupTag
+	// TokenMarkupAttribute is the name of an attribute of an HTML
+	// or XML tag in a string, see
+	// Formatter.DetectMarkupInStrings.
+	TokenMarkupAttribute
+	// TokenEmbedded is the marker preceding an object or array
+	// unwrapped from a string value, see
+	// Formatter.UnwrapEmbeddedJSON.
+	TokenEmbedded
+	// TokenExponent is the exponent of a number in exponent form,
+	// see Formatter.ExponentColor.
+	TokenExponent
+	// TokenBadge is the badge preceding a value, colored as the
+	// value, see Formatter.ValueBadges.
+	TokenBadge
+	// TokenRootLabel is the label preceding the output, see
+	// Formatter.RootLabel.
+	TokenRootLabel
+	// TokenReplacementChar is a Unicode replacement character in a
+	// string value or field name, see
+	// Formatter.HighlightReplacementChars.
+	TokenReplacementChar
+
+	numTokenKinds
+)
+
+var tokenKindNames = []string{
+	TokenSpace:             "space",
+	TokenComma:             "comma",
+	TokenColon:             "colon",
+	TokenObject:            "object",
+	TokenArray:             "array",
+	TokenFieldQuote:        "field quote",
+	TokenField:             "field",
+	TokenStringQuote:       "string quote",
+	TokenString:            "string",
+	TokenTrue:              "true",
+	TokenFalse:             "false",
+	TokenNumber:            "number",
+	TokenNull:              "null",
+	TokenMore:              "more",
+	TokenUnparseable:       "unparseable",
+	TokenComment:           "comment",
+	TokenOffset:            "offset",
+	TokenTrailingSpace:     "trailing space",
+	TokenVisibleWhitespace: "visible whitespace",
+	TokenUnit:              "unit",
+	TokenEllipsis:          "ellipsis",
+	TokenMarkupTag:         "markup tag",
+	TokenMarkupAttribute:   "markup attribute",
+	TokenEmbedded:          "embedded",
+	TokenExponent:          "exponent",
+	TokenBadge:             "badge",
+	TokenRootLabel:         "root label",
+	TokenReplacementChar:   "replacement char",
+}
+
+func (k TokenKind) String() string {
+	if k >= 0 && int(k) < len(tokenKindNames) {
+		return tokenKindNames[k]
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(k))
+}
@@ -0,0 +1,477 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// replayReader is a tokenReader returning previously read tokens
+// before those of dec.
+type replayReader struct {
+	tokens  []json.Token
+	offsets []int64
+	offset  int64
+	dec     tokenReader
+}
+
+func (r *replayReader) Token() (json.Token, error) {
+	if len(r.tokens) > 0 {
+		t := r.tokens[0]
+		r.tokens, r.offset = r.tokens[1:], r.offsets[0]
+		r.offsets = r.offsets[1:]
+		return t, nil
+	}
+	t, err := r.dec.Token()
+	if x, ok := r.dec.(inputOffsetter); ok {
+		r.offset = x.InputOffset()
+	}
+	return t, err
+}
+
+// InputOffset returns the input offset of dec after the most
+// recently returned token was read.
+func (r *replayReader) InputOffset() int64 {
+	return r.offset
+}
+
+func (r *replayReader) More() bool {
+	if len(r.tokens) > 0 {
+		return !isCloseDelim(r.tokens[0])
+	}
+	return r.dec.More()
+}
+
+// unread causes tokens, read at the given input offsets, to be
+// returned before any remaining tokens.
+func (r *replayReader) unread(tokens []json.Token, offsets []int64) {
+	r.tokens = append(tokens, r.tokens...)
+	r.offsets = append(offsets, r.offsets...)
+}
+
+// measureContainer reads the remaining tokens of the container whose
+// opening delimiter open has been read from r and then unreads them,
+// returning the container's number of elements or fields and the
+// number of runes in its compact JSON encoding.
+func measureContainer(r *replayReader, open json.Delim) (children, width int, err error) {
+	type level struct {
+		object bool
+		key    bool
+		n      int
+	}
+
+	var tokens []json.Token
+	var offsets []int64
+	defer func() { r.unread(tokens, offsets) }()
+
+	// the opening delimiter
+	width = 1
+	object := open == json.Delim('{')
+	stack := []*level{{object: object, key: object}}
+
+	value := func() {
+		l := stack[len(stack)-1]
+		if l.object && l.key {
+			l.n++
+			width++ // colon
+		} else if !l.object {
+			l.n++
+		}
+		if l.object {
+			l.key = !l.key
+		}
+	}
+
+	for len(stack) > 0 {
+		t, err := r.Token()
+		if err != nil {
+			return 0, 0, err
+		}
+		tokens = append(tokens, t)
+		offsets = append(offsets, r.offset)
+
+		switch x := t.(type) {
+		case json.Delim:
+			width++
+			if x == json.Delim('{') || x == json.Delim('[') {
+				value()
+				object := x == json.Delim('{')
+				stack = append(stack, &level{object: object, key: object})
+				continue
+			}
+			l := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if l.n > 1 {
+				width += l.n - 1 // commas
+			}
+			children = l.n
+		default:
+			width += scalarWidth(t)
+			value()
+		}
+	}
+
+	return children, width, nil
+}
+
+// scalarWidth returns the number of runes in the JSON encoding of the
+// scalar value or field name t.
+func scalarWidth(t json.Token) int {
+	switch x := t.(type) {
+	case string:
+		b, _ := json.Marshal(x)
+		return utf8.RuneCount(b)
+	case json.Number:
+		return len(x)
+	case bool:
+		if x {
+			return len("true")
+		}
+		return len("false")
+	case nil:
+		return len("null")
+	case byteSliceSummary:
+		return utf8.RuneCountInString(x.String())
+	}
+	return 0
+}
+
+// numberColumn reads the remaining tokens of the array whose opening
+// delimiter has been read from r and then unreads them, returning the
+// greatest width of the integer parts of its elements as displayed if
+// they are all numbers, or zero otherwise.
+func (fs *formatterState) numberColumn(r *replayReader) (int, error) {
+	var tokens []json.Token
+	var offsets []int64
+	defer func() { r.unread(tokens, offsets) }()
+
+	width := 0
+	for {
+		t, err := r.Token()
+		if err != nil {
+			return 0, err
+		}
+		tokens = append(tokens, t)
+		offsets = append(offsets, r.offset)
+		if isCloseDelim(t) {
+			return width, nil
+		}
+		n, ok := t.(json.Number)
+		if !ok {
+			// the remaining elements are read when displayed
+			return 0, nil
+		}
+		if w := fs.f.integerWidth(n.String()); w > width {
+			width = w
+		}
+	}
+}
+
+// integerWidth returns the number of runes in the integer part of the
+// displayed text of the number n, which precedes any decimal point or
+// exponent.
+func (f *Formatter) integerWidth(n string) int {
+	text := f.numberText(n)
+	decimal := "."
+	if l, ok := f.numberLocale(); ok {
+		decimal = l.decimal
+	}
+	if i := strings.Index(text, decimal); i >= 0 {
+		text = text[:i]
+	}
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		text = text[:i]
+	}
+	return utf8.RuneCountInString(text)
+}
+
+// LayoutContext describes a point between the elements of an
+// expanded object or array at which BreakFunc decides whether to
+// break the line.
+type LayoutContext struct {
+	// Path is the path to the container, made up of the field
+	// name or array index identifying it within each enclosing
+	// container.
+	Path []string
+	// Depth is the nesting depth of the container, which is one
+	// for the outermost value.
+	Depth int
+	// Close is true if the point precedes the container's
+	// closing delimiter rather than one of its elements.
+	Close bool
+	// Kind is the kind of the following element, or of the
+	// value of the following field of an object, such as
+	// TokenString, TokenObject or TokenArray.  If Close is true,
+	// it is the kind of the container.
+	Kind TokenKind
+	// Column is the number of runes already on the current line.
+	Column int
+	// Width is the number of runes in the compact JSON encoding of
+	// the following element, including the field name of a field,
+	// or one for the closing delimiter.
+	Width int
+}
+
+// breakLine prints the line break following the opening delimiter or
+// an element of an expanded object or array using print.  If
+// BreakFunc is set, the decision to break is deferred until the
+// following token is known, see resolveBreak.
+func (fs *formatterState) breakLine(print func(s string)) {
+	if fs.f.BreakFunc == nil || fs.compact {
+		print("\n")
+		return
+	}
+	fs.pendingBreak = print
+}
+
+// printNewline prints the newline s following the opening delimiter
+// of a container or an element omitted from it.
+func (fs *formatterState) printNewline(s string) {
+	fs.printSpace(s, false)
+}
+
+// resolveBreak prints the pending line break preceding the token t,
+// read from r, if BreakFunc decides to break the line, otherwise it
+// prints a space between elements and causes the following
+// indentation to be omitted.
+func (fs *formatterState) resolveBreak(r *replayReader, t json.Token) error {
+	print := fs.pendingBreak
+	fs.pendingBreak = nil
+	frame := fs.frame()
+
+	path := fs.path()
+	ctx := LayoutContext{
+		Path:   path[:len(path)-1],
+		Depth:  len(path),
+		Close:  isCloseDelim(t),
+		Column: fs.column,
+		Width:  1,
+	}
+	if ctx.Close {
+		ctx.Kind = valueTokenKind(t)
+	} else {
+		v := t
+		if frame.inField() {
+			var err error
+			v, err = r.Token()
+			if err != nil {
+				return err
+			}
+			offset := r.offset
+			defer r.unread([]json.Token{v}, []int64{offset})
+			ctx.Width = scalarWidth(t) + 1
+		} else {
+			ctx.Width = 0
+		}
+		ctx.Kind = valueTokenKind(v)
+		if x, ok := v.(json.Delim); ok {
+			_, width, err := measureContainer(r, x)
+			if err != nil {
+				return err
+			}
+			ctx.Width += width
+		} else {
+			ctx.Width += scalarWidth(v)
+		}
+	}
+
+	if fs.f.BreakFunc(ctx) {
+		print("\n")
+		return nil
+	}
+	if !ctx.Close && frame.index > 0 {
+		fs.printSeparator(" ")
+	}
+	fs.skipIndent = true
+	return nil
+}
+
+// valueTokenKind returns the kind of the token printing the scalar
+// value t or the opening or closing delimiter of a container.
+func valueTokenKind(t json.Token) TokenKind {
+	switch x := t.(type) {
+	case json.Delim:
+		if x == json.Delim('{') || x == json.Delim('}') {
+			return TokenObject
+		}
+		return TokenArray
+	case objectSummary:
+		return TokenObject
+	case arrayOutline:
+		return TokenArray
+	case unparseable:
+		return TokenUnparseable
+	case string:
+		return TokenString
+	case json.Number:
+		return TokenNumber
+	case bool:
+		if x {
+			return TokenTrue
+		}
+		return TokenFalse
+	case byteSliceSummary:
+		return TokenMore
+	}
+	return TokenNull
+}
+
+// objectFields reads the remaining tokens of the object whose opening
+// delimiter has been read from r and then unreads them, returning
+// the object's field names and whether its values are all scalars.
+func objectFields(r *replayReader) (keys []string, scalars bool, err error) {
+	var tokens []json.Token
+	var offsets []int64
+	defer func() { r.unread(tokens, offsets) }()
+
+	scalars = true
+	depth, key := 1, true
+	for depth > 0 {
+		t, err := r.Token()
+		if err != nil {
+			return nil, false, err
+		}
+		tokens = append(tokens, t)
+		offsets = append(offsets, r.offset)
+
+		switch x := t.(type) {
+		case json.Delim:
+			if x == json.Delim('{') || x == json.Delim('[') {
+				scalars = scalars && depth > 1
+				depth++
+			} else {
+				depth--
+			}
+		case string:
+			if depth == 1 && key {
+				keys = append(keys, x)
+			}
+		}
+		if depth == 1 {
+			key = !key
+		}
+	}
+
+	return keys, scalars, nil
+}
+
+// startColumns causes fs to buffer the fields of the object being
+// displayed, one cell per field, until endColumns is called, which
+// writes them packed into ObjectColumns columns.
+func (fs *formatterState) startColumns() {
+	emit := fs.emit
+	fs.cells = nil
+	fs.compact, fs.lineStart = true, false
+	fs.emit = func(kind TokenKind, st style, s string) {
+		if n := len(fs.cells); n > 0 {
+			fs.cells[n-1] = append(fs.cells[n-1], emittedToken{kind, st, s})
+		}
+	}
+
+	fs.endColumns = func() {
+		fs.emit = emit
+		fs.compact, fs.lineStart = false, true
+
+		n := fs.f.ObjectColumns
+		cellWidths := make([]int, len(fs.cells))
+		widths := make([]int, n)
+		for i, cell := range fs.cells {
+			for _, t := range cell {
+				cellWidths[i] += utf8.RuneCountInString(t.s)
+			}
+			if cellWidths[i] > widths[i%n] {
+				widths[i%n] = cellWidths[i]
+			}
+		}
+
+		for i, cell := range fs.cells {
+			if i%n == 0 {
+				fs.printIndent()
+			}
+			for _, t := range cell {
+				fs.write(t.kind, t.st, t.s)
+			}
+			if i%n == n-1 || i == len(fs.cells)-1 {
+				fs.printSpace("\n", false)
+				continue
+			}
+			fs.printSpace(strings.Repeat(" ", widths[i%n]-cellWidths[i]+1), false)
+		}
+		fs.cells = nil
+	}
+}
+
+// reorderFields reads the remaining tokens of the object whose
+// opening delimiter has been read from r and then unreads them with
+// the object's fields in the order given by passing its field names
+// to order.  Fields whose names are not returned by order follow in
+// their original order.
+func reorderFields(r *replayReader, order func(keys []string) []string) error {
+	type field struct {
+		tokens  []json.Token
+		offsets []int64
+	}
+
+	var fields []*field
+	var keys []string
+	byKey := map[string][]*field{}
+
+	var close json.Token
+	var closeOffset int64
+	depth, key := 1, true
+	for {
+		t, err := r.Token()
+		if err != nil {
+			return err
+		}
+		if x, ok := t.(json.Delim); ok {
+			if x == json.Delim('{') || x == json.Delim('[') {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			close, closeOffset = t, r.offset
+			break
+		}
+		if depth == 1 && key {
+			k, _ := t.(string)
+			if x, ok := t.(unparseable); ok {
+				k = string(x)
+			}
+			fields = append(fields, &field{})
+			if _, ok := byKey[k]; !ok {
+				keys = append(keys, k)
+			}
+			byKey[k] = append(byKey[k], fields[len(fields)-1])
+		}
+		f := fields[len(fields)-1]
+		f.tokens = append(f.tokens, t)
+		f.offsets = append(f.offsets, r.offset)
+		if depth == 1 {
+			key = !key
+		}
+	}
+
+	var tokens []json.Token
+	var offsets []int64
+	add := func(k string) {
+		for _, f := range byKey[k] {
+			tokens = append(tokens, f.tokens...)
+			offsets = append(offsets, f.offsets...)
+		}
+		delete(byKey, k)
+	}
+	for _, k := range order(append([]string(nil), keys...)) {
+		add(k)
+	}
+	for _, k := range keys {
+		add(k)
+	}
+	tokens = append(tokens, close)
+	offsets = append(offsets, closeOffset)
+
+	r.unread(tokens, offsets)
+	return nil
+}
@@ -0,0 +1,211 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestObjectColumns(t *testing.T) {
+	tests := []struct {
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{&Formatter{Indent: "  ", ObjectColumns: 2}, `{"a":1,"bb":"x","c":true}`,
+			"{\n  \"a\": 1,   \"bb\": \"x\",\n  \"c\": true\n}"},
+		{&Formatter{Indent: "  ", ObjectColumns: 2, GreppableSeparators: true}, `{"a":1,"bb":"x","c":true}`,
+			"{\n  \"a\": 1,   \"bb\": \"x\",\n  \"c\": true\n}"},
+		{&Formatter{Indent: "  ", ObjectColumns: 3}, `{"a":1,"d":{"e":null,"f":2}}`,
+			"{\n  \"a\": 1,\n  \"d\": {\n    \"e\": null, \"f\": 2\n  }\n}"},
+		{&Formatter{ObjectColumns: 2}, `{"a":1,"b":2}`, `{"a":1,"b":2}`},
+	}
+	for _, tt := range tests {
+		got := uncolored(formatString(t, tt.f, tt.src))
+		if got != tt.want {
+			t.Errorf("Format(%s) with ObjectColumns %d = %q, want %q", tt.src, tt.f.ObjectColumns, got, tt.want)
+		}
+	}
+}
+
+func TestShouldExpand(t *testing.T) {
+	type call struct {
+		path     string
+		kind     byte
+		children int
+		width    int
+	}
+	var calls []call
+	f := &Formatter{
+		Indent: "  ",
+		ShouldExpand: func(path []string, kind byte, children, width int) bool {
+			calls = append(calls, call{strings.Join(path, "/"), kind, children, width})
+			return width > 12
+		},
+	}
+	src := `{"a":[1,2,3],"b":{"c":"xyz","d":[true,null]},"e":[],"f":["longer string"]}`
+	got := uncolored(formatString(t, f, src))
+	want := "{\n" +
+		"  \"a\": [1,2,3],\n" +
+		"  \"b\": {\n" +
+		"    \"c\": \"xyz\",\n" +
+		"    \"d\": [true,null]\n" +
+		"  },\n" +
+		"  \"e\": [],\n" +
+		"  \"f\": [\n" +
+		"    \"longer string\"\n" +
+		"  ]\n" +
+		"}"
+	if got != want {
+		t.Errorf("Format with ShouldExpand = %q, want %q", got, want)
+	}
+
+	// empty containers and those nested inside inline containers
+	// are not measured
+	wantCalls := []call{
+		{"", '{', 4, len(src)},
+		{"a", '[', 3, 7},
+		{"b", '{', 2, 27},
+		{"b/d", '[', 2, 11},
+		{"f", '[', 1, 17},
+	}
+	if len(calls) != len(wantCalls) {
+		t.Fatalf("ShouldExpand called with %+v, want %+v", calls, wantCalls)
+	}
+	for i := range calls {
+		if calls[i] != wantCalls[i] {
+			t.Errorf("ShouldExpand call %d = %+v, want %+v", i, calls[i], wantCalls[i])
+		}
+	}
+
+	calls = nil
+	f.Indent = ""
+	if got := uncolored(formatString(t, f, src)); got != src || len(calls) > 0 {
+		t.Errorf("compact Format with ShouldExpand = %q calling it %d times, want %q", got, len(calls), src)
+	}
+}
+
+func TestExpandObjectsWithKey(t *testing.T) {
+	src := `[{"type":"a","x":{"y":1}},{"id":1},{"id":1,"z":{"type":2}},{},{"a":{"b":1}}]`
+	tests := []struct {
+		f    *Formatter
+		want string
+	}{
+		{&Formatter{ExpandObjectsWithKey: "type"},
+			`[{"type":"a","x":{"y":1}},{… (1 field)},{… (2 fields)},{},{… (1 field)}]`},
+		{&Formatter{ExpandObjectsWithKey: "type", Indent: "  "},
+			"[\n  {\n    \"type\": \"a\",\n    \"x\": {\n      \"y\": 1\n    }\n  },\n  {… (1 field)},\n  {… (2 fields)},\n  {},\n  {… (1 field)}\n]"},
+		{&Formatter{ExpandObjectsWithKey: "id"},
+			`[{… (2 fields)},{"id":1},{"id":1,"z":{"type":2}},{},{… (1 field)}]`},
+	}
+	for _, tt := range tests {
+		if got := uncolored(formatString(t, tt.f, src)); got != tt.want {
+			t.Errorf("Format with ExpandObjectsWithKey %q = %q, want %q", tt.f.ExpandObjectsWithKey, got, tt.want)
+		}
+	}
+
+	// the outermost object is always displayed in full
+	if got := uncolored(formatString(t, &Formatter{ExpandObjectsWithKey: "type"}, `{"a":{"b":1}}`)); got != `{"a":{… (1 field)}}` {
+		t.Errorf("Format with ExpandObjectsWithKey = %q", got)
+	}
+	got := formatString(t, &Formatter{ExpandObjectsWithKey: "type"}, `[{"b":1}]`)
+	if c := colorOf(t, got, "…"); c != colorSpecOf(DefaultMoreColor) {
+		t.Errorf("summary displayed as %+v, want it in MoreColor", c)
+	}
+}
+
+func TestKeyOrder(t *testing.T) {
+	var paths []string
+	first := func(path []string, keys []string) []string {
+		paths = append(paths, strings.Join(path, "/")+":"+strings.Join(keys, ","))
+		return []string{"id", "name", "missing"}
+	}
+	tests := []struct {
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{&Formatter{KeyOrder: first}, `{"b":1,"name":"x","a":{"c":2,"id":3},"id":4}`,
+			`{"id":4,"name":"x","b":1,"a":{"id":3,"c":2}}`},
+		{&Formatter{KeyOrder: first}, `[{"x":1,"id":2,"x":3,"name":[]}]`,
+			`[{"id":2,"name":[],"x":1,"x":3}]`},
+		{&Formatter{KeyOrder: first, Indent: "  "}, `{"a":1,"id":{"x":[1]}}`,
+			"{\n  \"id\": {\n    \"x\": [\n      1\n    ]\n  },\n  \"a\": 1\n}"},
+		{&Formatter{KeyOrder: func([]string, []string) []string { return nil }}, `{"b":1,"a":2}`,
+			`{"b":1,"a":2}`},
+		{&Formatter{}, `{"b":1,"id":2}`, `{"b":1,"id":2}`},
+	}
+	for _, tt := range tests {
+		got := uncolored(formatString(t, tt.f, tt.src))
+		if got != tt.want {
+			t.Errorf("Format(%s) with KeyOrder = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+	want := []string{
+		":b,name,a,id", "a:c,id",
+		"0:x,id,name",
+		":a,id", "id:x",
+	}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("KeyOrder calls = %q, want %q", paths, want)
+	}
+}
+
+func TestBreakFunc(t *testing.T) {
+	var contexts []LayoutContext
+	fill := func(ctx LayoutContext) bool {
+		contexts = append(contexts, ctx)
+		return ctx.Close || ctx.Column+ctx.Width > 12
+	}
+	tests := []struct {
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{&Formatter{Indent: "  ", BreakFunc: func(LayoutContext) bool { return true }}, `{"a":[1,"xy"],"b":{}}`,
+			"{\n  \"a\": [\n    1,\n    \"xy\"\n  ],\n  \"b\": {}\n}"},
+		{&Formatter{Indent: "  ", BreakFunc: fill}, `[1,22,333,4444,55555]`,
+			"[1, 22, 333,\n  4444, 55555\n]"},
+		{&Formatter{BreakFunc: fill}, `[1,22,333,4444,55555]`, `[1,22,333,4444,55555]`},
+	}
+	for _, tt := range tests {
+		if got := uncolored(formatString(t, tt.f, tt.src)); got != tt.want {
+			t.Errorf("Format(%s) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	contexts = nil
+	f := &Formatter{Indent: "  ", BreakFunc: fill}
+	src := `{"a":[1,"xy"],"b":{"c":null}}`
+	if got, want := uncolored(formatString(t, f, src)), "{\n  \"a\": [1,\n    \"xy\"\n  ],\n  \"b\": {\n    \"c\": null\n  }\n}"; got != want {
+		t.Errorf("Format(%s) = %q, want %q", src, got, want)
+	}
+	want := []LayoutContext{
+		{Path: []string{}, Depth: 1, Kind: TokenArray, Column: 1, Width: 12},
+		{Path: []string{"a"}, Depth: 2, Kind: TokenNumber, Column: 8, Width: 1},
+		{Path: []string{"a"}, Depth: 2, Kind: TokenString, Column: 10, Width: 4},
+		{Path: []string{"a"}, Depth: 2, Close: true, Kind: TokenArray, Column: 8, Width: 1},
+		{Path: []string{}, Depth: 1, Kind: TokenObject, Column: 4, Width: 14},
+		{Path: []string{"b"}, Depth: 2, Kind: TokenNull, Column: 8, Width: 8},
+		{Path: []string{"b"}, Depth: 2, Close: true, Kind: TokenObject, Column: 13, Width: 1},
+		{Path: []string{}, Depth: 1, Close: true, Kind: TokenObject, Column: 3, Width: 1},
+	}
+	if !reflect.DeepEqual(contexts, want) {
+		t.Errorf("BreakFunc called with %+v, want %+v", contexts, want)
+	}
+
+	// summarized byte slices are measured as displayed
+	contexts = nil
+	f.SummarizeByteSlices = true
+	buf := &bytes.Buffer{}
+	if err := f.FormatGoValue(buf, []interface{}{make([]byte, 100), 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := uncolored(buf.String()), "[\n  bytes(len=100),\n  1\n]"; got != want {
+		t.Errorf("FormatGoValue = %q, want %q", got, want)
+	}
+	if ctx := contexts[0]; ctx.Kind != TokenMore || ctx.Width != len("bytes(len=100)") {
+		t.Errorf("BreakFunc called with %+v for a summarized byte slice", ctx)
+	}
+}
@@ -0,0 +1,54 @@
+package jsoncolor
+
+import "strings"
+
+// legendEntry is an entry in a Formatter's legend.
+type legendEntry struct {
+	kind  TokenKind
+	color SprintfFuncer
+	label string
+}
+
+// legend returns the entries of f's legend.
+func (f *Formatter) legend() []legendEntry {
+	entries := []legendEntry{
+		{TokenField, f.fieldColor(), "field"},
+		{TokenString, f.stringColor(), "string"},
+		{TokenNumber, f.numberColor(), "number"},
+		{TokenTrue, f.trueColor(), "true"},
+		{TokenFalse, f.falseColor(), "false"},
+		{TokenNull, f.nullColor(), "null"},
+	}
+	if f.NegativeNumberColor != nil {
+		entries = append(entries, legendEntry{TokenNumber, f.NegativeNumberColor, "negative"})
+	}
+	if f.omits() {
+		entries = append(entries, legendEntry{TokenMore, f.moreColor(), "omitted"})
+	}
+	if len(f.FocusPath) > 0 {
+		entries = append(entries, legendEntry{TokenSpace, f.dimColor(), "unfocused"})
+	}
+	return entries
+}
+
+// Legend returns a single line naming each kind of value in the color
+// f uses for it, such as field names, strings and numbers, so that
+// viewers can tell what each color of f's current palette means.
+func (f *Formatter) Legend() string {
+	var labels []string
+	for _, e := range f.legend() {
+		labels = append(labels, f.sprintfFunc(e.color)("%s", e.label))
+	}
+	return strings.Join(labels, "  ")
+}
+
+// printLegend prints f's legend on a new line.
+func (fs *formatterState) printLegend() {
+	fs.write(TokenSpace, fs.styles[TokenSpace], "\n")
+	for i, e := range fs.f.legend() {
+		if i > 0 {
+			fs.write(TokenSpace, fs.styles[TokenSpace], "  ")
+		}
+		fs.write(e.kind, newStyle(e.color), e.label)
+	}
+}
@@ -0,0 +1,39 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestLegend(t *testing.T) {
+	f := &Formatter{NumberColor: color.New(color.FgRed)}
+	legend := f.Legend()
+	if got, want := uncolored(legend), "field  string  number  true  false  null"; got != want {
+		t.Errorf("Legend() = %q, want %q", got, want)
+	}
+	if c := colorOf(t, legend, "number"); c != (ColorSpec{Foreground: color.FgRed}) {
+		t.Errorf("Legend() displays number as %+v, want it in NumberColor", c)
+	}
+	if c, want := colorOf(t, legend, "field"), colorSpecOf(DefaultFieldColor); c != want {
+		t.Errorf("Legend() displays field as %+v, want %+v", c, want)
+	}
+
+	f = &Formatter{MaxArrayElements: 1, FocusPath: "/a"}
+	if got := uncolored(f.Legend()); !strings.HasSuffix(got, "  null  omitted  unfocused") {
+		t.Errorf("Legend() with MaxArrayElements and FocusPath = %q", got)
+	}
+}
+
+func TestAppendLegend(t *testing.T) {
+	f := &Formatter{Indent: "  ", AppendLegend: true}
+	got := formatString(t, f, `{"a":1}`)
+	want := "{\n  \"a\": 1\n}\n" + f.Legend()
+	if uncolored(got) != uncolored(want) {
+		t.Errorf("Format with AppendLegend = %q, want %q", uncolored(got), uncolored(want))
+	}
+	if c, want := colorOf(t, got, "string"), colorSpecOf(DefaultStringColor); c != want {
+		t.Errorf("appended legend displays string as %+v, want %+v", c, want)
+	}
+}
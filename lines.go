@@ -0,0 +1,188 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// annotatedLine is a line of output buffered so that the comment
+// annotating it can be aligned with those of neighbouring lines.
+type annotatedLine struct {
+	tokens  []emittedToken
+	newline *emittedToken
+	width   int
+
+	// comment is the annotation displayed at the end of the line
+	// and group is the frame whose annotated lines are aligned
+	// with one another.
+	comment string
+	style   style
+	group   *frame
+}
+
+// emittedToken is the text s of a token of the given kind written
+// using style st.
+type emittedToken struct {
+	kind TokenKind
+	st   style
+	s    string
+}
+
+// emitted returns the tokens fs writes formatting src, for writing by
+// the formatterState of a Format method laying out several values,
+// such as FormatTable.
+func (fs *formatterState) emitted(src []byte) ([]emittedToken, error) {
+	var tokens []emittedToken
+	fs.emit = func(kind TokenKind, st style, s string) {
+		tokens = append(tokens, emittedToken{kind, st, s})
+	}
+	err := fs.format(nil, src, false)
+	return tokens, err
+}
+
+// emittedWidth returns the number of runes written by tokens.
+func emittedWidth(tokens []emittedToken) int {
+	n := 0
+	for _, t := range tokens {
+		n += utf8.RuneCountInString(t.s)
+	}
+	return n
+}
+
+// emittedText returns the text written by tokens.
+func emittedText(tokens []emittedToken) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteString(t.s)
+	}
+	return b.String()
+}
+
+// truncateEmitted returns tokens truncated to write at most width
+// runes, ending with ellipsis if they were truncated.
+func truncateEmitted(tokens []emittedToken, width int, ellipsis emittedToken) []emittedToken {
+	if emittedWidth(tokens) <= width {
+		return tokens
+	}
+	var truncated []emittedToken
+	n := width - utf8.RuneCountInString(ellipsis.s)
+	for _, t := range tokens {
+		if n <= 0 {
+			break
+		}
+		if w := utf8.RuneCountInString(t.s); w > n {
+			t.s = string([]rune(t.s)[:n])
+		}
+		truncated = append(truncated, t)
+		n -= utf8.RuneCountInString(t.s)
+	}
+	return append(truncated, ellipsis)
+}
+
+// bufferLines causes fs to buffer its output a line at a time until
+// the returned function is called, which writes the buffered lines
+// followed by their aligned annotations, replacing the middle lines
+// with an indicator if there are more than MaxLines.
+func (fs *formatterState) bufferLines() func() {
+	emit := fs.emit
+	var lines []*annotatedLine
+	fs.line = &annotatedLine{}
+
+	fs.emit = func(kind TokenKind, st style, s string) {
+		t := emittedToken{kind, st, s}
+		if strings.HasSuffix(s, "\n") {
+			fs.line.newline = &t
+			lines = append(lines, fs.line)
+			fs.line = &annotatedLine{}
+			return
+		}
+		fs.line.tokens = append(fs.line.tokens, t)
+		fs.line.width += utf8.RuneCountInString(s)
+	}
+
+	return func() {
+		if len(fs.line.tokens) > 0 {
+			lines = append(lines, fs.line)
+		}
+		fs.emit, fs.line = emit, nil
+
+		widths := map[*frame]int{}
+		for _, l := range lines {
+			if len(l.comment) > 0 && l.width > widths[l.group] {
+				widths[l.group] = l.width
+			}
+		}
+
+		space := fs.styles[TokenSpace]
+		write := func(l *annotatedLine) {
+			for _, t := range l.tokens {
+				emit(t.kind, t.st, t.s)
+			}
+			if len(l.comment) > 0 {
+				emit(TokenSpace, space, strings.Repeat(" ", widths[l.group]-l.width+2))
+				emit(TokenComment, l.style, "// "+l.comment)
+			}
+			if l.newline != nil {
+				emit(l.newline.kind, l.newline.st, l.newline.s)
+			}
+		}
+
+		max := fs.f.MaxLines
+		if max <= 0 || len(lines) <= max {
+			for _, l := range lines {
+				write(l)
+			}
+			return
+		}
+
+		// the indicator takes the place of one of the max lines
+		shown := max - 1
+		hidden := len(lines) - shown
+		head, tail := lines[:max/2], lines[len(lines)-(shown-max/2):]
+		for _, l := range head {
+			write(l)
+		}
+		if len(fs.margin) > 0 {
+			emit(TokenSpace, space, fs.margin)
+		}
+		if fs.f.MoreIndicator != nil {
+			emit(TokenMore, fs.styles[TokenMore], fs.f.MoreIndicator(hidden))
+		} else {
+			ellipsis := fs.f.ellipsis()
+			emit(TokenEllipsis, fs.styles[TokenEllipsis], ellipsis)
+			emit(TokenMore, fs.styles[TokenMore], fmt.Sprintf(" (%d lines hidden) ", hidden))
+			emit(TokenEllipsis, fs.styles[TokenEllipsis], ellipsis)
+		}
+		if len(tail) > 0 || lines[len(lines)-1].newline != nil {
+			emit(TokenSpace, space, "\n")
+		}
+		for _, l := range tail {
+			write(l)
+		}
+	}
+}
+
+// annotate attaches the annotation of the current value t, if any,
+// to the line of output currently being written, aligning it with
+// the other annotations of the values of frame.
+func (fs *formatterState) annotate(frame *frame, t json.Token) {
+	if fs.line == nil || fs.compact {
+		return
+	}
+	comment, ok := fs.f.Annotations[formatPointer(fs.path())]
+	if s, isString := t.(string); !ok && isString && fs.f.AnnotateDetectedFormats {
+		comment = DetectFormat(s)
+		ok = len(comment) > 0
+	}
+	if !ok {
+		return
+	}
+	fs.line.comment = comment
+	fs.line.group = frame
+	fs.line.style = fs.styles[TokenComment]
+	if fs.dim {
+		fs.line.style = fs.dimmed
+	}
+}
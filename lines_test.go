@@ -0,0 +1,41 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMaxLines(t *testing.T) {
+	src := `[1,2,3,4,5,6,7]`
+	tests := []struct {
+		f    *Formatter
+		want string
+	}{
+		{&Formatter{Indent: " ", MaxLines: 9}, "[\n 1,\n 2,\n 3,\n 4,\n 5,\n 6,\n 7\n]"},
+		{&Formatter{Indent: " ", MaxLines: 8}, "[\n 1,\n 2,\n 3,\n… (2 lines hidden) …\n 6,\n 7\n]"},
+		{&Formatter{Indent: " ", MaxLines: 3}, "[\n… (7 lines hidden) …\n]"},
+		{&Formatter{Indent: " ", MaxLines: 2}, "[\n… (8 lines hidden) …"},
+		{&Formatter{Indent: " ", MaxLines: 1}, "… (9 lines hidden) …"},
+		{&Formatter{Indent: " ", MaxLines: 3, LeftMargin: 2}, "  [\n  … (7 lines hidden) …\n  ]"},
+		{&Formatter{Indent: " ", MaxLines: 3, MoreIndicator: func(hidden int) string { return fmt.Sprintf("[%d lines]", hidden) }}, "[\n[7 lines]\n]"},
+	}
+	for _, tt := range tests {
+		got := uncolored(formatString(t, tt.f, src))
+		if got != tt.want {
+			t.Errorf("Format(%s) with MaxLines %d = %q, want %q", src, tt.f.MaxLines, got, tt.want)
+		}
+		// the indicator counts as one of the lines
+		if n := strings.Count(got, "\n") + 1; n > tt.f.MaxLines {
+			t.Errorf("Format(%s) with MaxLines %d displays %d lines", src, tt.f.MaxLines, n)
+		}
+	}
+
+	got := formatString(t, &Formatter{Indent: " ", MaxLines: 3}, src)
+	if c := colorOf(t, got, "…"); c != colorSpecOf(DefaultMoreColor) {
+		t.Errorf("indicator displayed as %+v, want it in MoreColor", c)
+	}
+	if got := uncolored(formatString(t, &Formatter{MaxLines: 1}, src)); got != src {
+		t.Errorf("compact Format(%s) with MaxLines 1 = %q, want %q", src, got, src)
+	}
+}
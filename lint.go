@@ -0,0 +1,191 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LintSeverity is the severity of a Lint.
+type LintSeverity int
+
+const (
+	// LintInfo is the severity of stylistic issues, such as
+	// numbers that are not normalized.
+	LintInfo LintSeverity = iota
+	// LintWarning is the severity of issues that may cause
+	// problems for some consumers, such as very deep nesting.
+	LintWarning
+	// LintError is the severity of issues whose meaning differs
+	// between consumers, such as duplicate field names.
+	LintError
+)
+
+var lintSeverityNames = []string{
+	LintInfo:    "info",
+	LintWarning: "warning",
+	LintError:   "error",
+}
+
+func (s LintSeverity) String() string {
+	if s >= 0 && int(s) < len(lintSeverityNames) {
+		return lintSeverityNames[s]
+	}
+	return fmt.Sprintf("LintSeverity(%d)", int(s))
+}
+
+// Lint is an issue found by FormatAndLint in the value identified by
+// a JSON Pointer.
+type Lint struct {
+	// Pointer is the JSON Pointer (see RFC 6901) identifying the
+	// value, such as "/items/3".
+	Pointer string
+	// Severity is how serious the issue is.
+	Severity LintSeverity
+	// Message describes the issue.
+	Message string
+}
+
+// Limits above which FormatAndLint reports nesting and strings.
+const (
+	maxLintDepth        = 32
+	maxLintStringLength = 64 * 1024
+)
+
+// FormatAndLint is like Format but also returns the issues found in
+// src while formatting it, in the order in which they appear.  These
+// are duplicate field names, objects and arrays nested more than 32
+// levels deep, strings longer than 64 KiB and numbers that are not
+// normalized, such as 1.50, -0 and 1E+06, whose normalized forms are
+// 1.5, 0 and 1e6.  Issues are found in all of src even if f's
+// settings omit parts of it from the output.  If src is invalid, the
+// issues found before the error are returned with it.
+func (f *Formatter) FormatAndLint(dst io.Writer, src []byte) ([]Lint, error) {
+	fs := newFormatterState(f, dst)
+	r := &lintingReader{dec: fs.decoder(src)}
+	err := fs.formatTokens(r, false)
+	return r.lints, err
+}
+
+// lintingReader is a tokenReader recording the issues found in the
+// tokens read from dec.
+type lintingReader struct {
+	dec   tokenReader
+	lints []Lint
+
+	// stack holds, for each enclosing container, the segment of
+	// the path of its current element.
+	stack []lintFrame
+}
+
+type lintFrame struct {
+	object bool
+	n      int
+	key    string
+	keys   map[string]bool
+}
+
+func (r *lintingReader) Token() (json.Token, error) {
+	t, err := r.dec.Token()
+	if err != nil {
+		return t, err
+	}
+
+	if isCloseDelim(t) {
+		r.stack = r.stack[:len(r.stack)-1]
+		return t, nil
+	}
+
+	if n := len(r.stack); n > 0 {
+		f := &r.stack[n-1]
+		field := f.object && f.n%2 == 0
+		if field {
+			f.key = objectKey(t)
+		} else if !f.object {
+			f.key = strconv.Itoa(f.n)
+		}
+		f.n++
+		if field {
+			if f.keys[f.key] {
+				r.add(LintError, "duplicate field %q", f.key)
+			}
+			f.keys[f.key] = true
+			return t, nil
+		}
+	}
+
+	switch x := t.(type) {
+	case json.Delim:
+		if len(r.stack) == maxLintDepth {
+			r.add(LintWarning, "nested more than %d levels deep", maxLintDepth)
+		}
+		r.stack = append(r.stack, lintFrame{object: x == json.Delim('{'), keys: map[string]bool{}})
+	case string:
+		if len(x) > maxLintStringLength {
+			r.add(LintWarning, "string is %d bytes long", len(x))
+		}
+	case json.Number:
+		if s := normalizeNumber(x.String()); s != x.String() {
+			r.add(LintInfo, "number %s is not normalized, expected %s", x, s)
+		}
+	}
+
+	return t, nil
+}
+
+func (r *lintingReader) More() bool {
+	return r.dec.More()
+}
+
+// InputOffset returns the input offset of dec, if it has one.
+func (r *lintingReader) InputOffset() int64 {
+	if x, ok := r.dec.(inputOffsetter); ok {
+		return x.InputOffset()
+	}
+	return 0
+}
+
+// add records an issue with the current value.
+func (r *lintingReader) add(severity LintSeverity, format string, a ...interface{}) {
+	path := make([]string, len(r.stack))
+	for i, f := range r.stack {
+		path[i] = f.key
+	}
+	r.lints = append(r.lints, Lint{
+		Pointer:  formatPointer(path),
+		Severity: severity,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+// normalizeNumber returns the normalized form of the number n, which
+// has no trailing zeros in its fraction, a lowercase exponent without
+// a plus sign or leading zeros, and no minus sign if it is zero.
+// Non-standard numbers such as NaN are returned unchanged.
+func normalizeNumber(n string) string {
+	if !json.Valid([]byte(n)) {
+		return n
+	}
+	mantissa, exp := n, ""
+	if i := strings.IndexAny(n, "eE"); i >= 0 {
+		mantissa, exp = n[:i], n[i+1:]
+	}
+	if strings.IndexByte(mantissa, '.') >= 0 {
+		mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), ".")
+	}
+	if strings.Trim(mantissa, "-0") == "" {
+		return "0"
+	}
+
+	neg := strings.HasPrefix(exp, "-")
+	exp = strings.TrimLeft(strings.TrimLeft(exp, "+-"), "0")
+	if len(exp) == 0 {
+		return mantissa
+	}
+	if neg {
+		exp = "-" + exp
+	}
+	return mantissa + "e" + exp
+}
@@ -0,0 +1,84 @@
+package jsoncolor
+
+import "strings"
+
+// numberLocale is the digit grouping and decimal separators of a
+// locale, see NumberLocale.
+type numberLocale struct {
+	group, decimal string
+}
+
+// numberLocales are the locales supported by NumberLocale, keyed by
+// lowercase language tag.
+var numberLocales = map[string]numberLocale{
+	"en":    {",", "."},
+	"ja":    {",", "."},
+	"ko":    {",", "."},
+	"zh":    {",", "."},
+	"de":    {".", ","},
+	"es":    {".", ","},
+	"it":    {".", ","},
+	"nl":    {".", ","},
+	"pt":    {".", ","},
+	"tr":    {".", ","},
+	"fr":    {" ", ","},
+	"cs":    {" ", ","},
+	"fi":    {" ", ","},
+	"nb":    {" ", ","},
+	"pl":    {" ", ","},
+	"ru":    {" ", ","},
+	"sv":    {" ", ","},
+	"uk":    {" ", ","},
+	"de-ch": {"’", "."},
+	"fr-ch": {" ", "."},
+	"pt-br": {".", ","},
+}
+
+// numberLocale returns the locale named by NumberLocale, trying the
+// entire tag, such as de-CH, before its language, such as de.
+func (f *Formatter) numberLocale() (numberLocale, bool) {
+	if len(f.NumberLocale) == 0 {
+		return numberLocale{}, false
+	}
+	tag := strings.ToLower(strings.Replace(f.NumberLocale, "_", "-", -1))
+	if l, ok := numberLocales[tag]; ok {
+		return l, true
+	}
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		tag = tag[:i]
+	}
+	l, ok := numberLocales[tag]
+	return l, ok
+}
+
+// format returns the number text with the digits of its integer part
+// grouped in threes and its decimal point replaced by the separators
+// of l.  Non-standard numbers such as NaN are returned unchanged.
+func (l numberLocale) format(text string) string {
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	if len(text) == 0 || text[0] < '0' || text[0] > '9' {
+		return sign + text
+	}
+	end := strings.IndexAny(text, ".eE")
+	if end < 0 {
+		end = len(text)
+	}
+	digits, rest := text[:end], text[end:]
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i := 0; i < len(digits); i++ {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(l.group)
+		}
+		b.WriteByte(digits[i])
+	}
+	if strings.HasPrefix(rest, ".") {
+		rest = l.decimal + rest[1:]
+	}
+	b.WriteString(rest)
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package jsoncolor
+
+import "bytes"
+
+// FormatForLogger is like Format but returns the colorized form of
+// the JSON-encoded src as a single line suitable for embedding in a
+// line of a structured logger's output, such as the value of a log
+// field.  The output is compact, without the prefix, indentation,
+// margin, fence, label, breadcrumb, summary or legend given by f's
+// fields, and contains no newlines or other control characters
+// except for the escape characters beginning escape sequences, so
+// that loggers writing values verbatim do not break the line.
+// Loggers that encode values as JSON, or that escape control
+// characters, escape the escape sequences too and should be given
+// Plain output instead.  Strings and decorations such as those of
+// InferUnits may contain spaces, causing some loggers to quote the
+// value.
+func (f *Formatter) FormatForLogger(src []byte) (string, error) {
+	g := f.dataOnly()
+	g.setIndent("", "")
+	g.LeftMargin = 0
+
+	buf := &bytes.Buffer{}
+	err := g.Format(buf, src)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,117 @@
+package jsoncolor
+
+import "strings"
+
+// printMarkup prints the JSON-encoded string s as a string value,
+// highlighting the HTML and XML tags it contains.
+func (fs *formatterState) printMarkup(s string) {
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != '<' {
+			continue
+		}
+		end := markupTagEnd(s, i)
+		if end < 0 {
+			continue
+		}
+		fs.printText(TokenString, s[start:i])
+		fs.printMarkupTag(s[i:end])
+		start = end
+		i = end - 1
+	}
+	fs.printText(TokenString, s[start:])
+}
+
+// markupTagEnd returns the offset in s of the end of the tag
+// beginning at offset i, or -1 if there is no tag at i.
+func markupTagEnd(s string, i int) int {
+	j := i + 1
+	if j < len(s) && (s[j] == '/' || s[j] == '!' || s[j] == '?') {
+		j++
+	}
+	if j >= len(s) || !isMarkupNameByte(s[j]) {
+		return -1
+	}
+	for ; j < len(s); j++ {
+		switch s[j] {
+		case '<':
+			return -1
+		case '>':
+			return j + 1
+		}
+	}
+	return -1
+}
+
+func isMarkupNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '_' || c == ':' || c == '.'
+}
+
+// printMarkupTag prints the tag t, coloring its name and delimiters
+// and the names of its attributes.
+func (fs *formatterState) printMarkupTag(t string) {
+	if strings.HasPrefix(t, "<!--") {
+		fs.print(TokenMarkupTag, t)
+		return
+	}
+
+	name := 1
+	if t[name] == '/' || t[name] == '!' || t[name] == '?' {
+		name++
+	}
+	for name < len(t) && isMarkupNameByte(t[name]) {
+		name++
+	}
+	end := len(t) - 1
+	if end > name && (t[end-1] == '/' || t[end-1] == '?') {
+		end--
+	}
+	fs.print(TokenMarkupTag, t[:name])
+
+	// attributes
+	body := t[name:end]
+	for i := 0; i < len(body); {
+		j := i
+		switch {
+		case isMarkupNameByte(body[i]):
+			for j < len(body) && isMarkupNameByte(body[j]) {
+				j++
+			}
+			fs.print(TokenMarkupAttribute, body[i:j])
+		case strings.HasPrefix(body[i:], `\"`):
+			// a quoted value, escaped in the JSON encoding
+			if k := strings.Index(body[i+2:], `\"`); k >= 0 {
+				j = i + 2 + k + 2
+			} else {
+				j = len(body)
+			}
+			fs.print(TokenString, body[i:j])
+		case body[i] == '\'':
+			if k := strings.IndexByte(body[i+1:], '\''); k >= 0 {
+				j = i + 1 + k + 1
+			} else {
+				j = len(body)
+			}
+			fs.print(TokenString, body[i:j])
+		default:
+			// whitespace, equals signs and escaped characters
+			for j < len(body) && !isMarkupNameByte(body[j]) && body[j] != '\'' {
+				if body[j] == '\\' {
+					if strings.HasPrefix(body[j:], `\"`) {
+						break
+					}
+					j++
+				}
+				j++
+			}
+			if j == i {
+				j++
+			}
+			fs.print(TokenString, body[i:j])
+		}
+		i = j
+	}
+
+	fs.print(TokenMarkupTag, t[end:])
+}
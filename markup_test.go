@@ -0,0 +1,46 @@
+package jsoncolor
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestDetectMarkupInStrings(t *testing.T) {
+	tests := []struct {
+		name       string
+		f          *Formatter
+		src        string
+		tags, attr string
+	}{
+		{"tags", &Formatter{DetectMarkupInStrings: true}, `["<a href=\"/x\" id='y'>hi</a> 1 < 2"]`, `<a></a>`, `hrefid`},
+		{"self-closing", &Formatter{DetectMarkupInStrings: true}, `["<br/><?xml v='1'?><!-- c -->"]`, `<br/><?xml?><!-- c -->`, `v`},
+		{"not tags", &Formatter{DetectMarkupInStrings: true}, `["a < b","<","<1","< a>","<a"]`, ``, ``},
+		{"field names", &Formatter{DetectMarkupInStrings: true}, `{"<a>":1}`, ``, ``},
+		{"escaped", &Formatter{DetectMarkupInStrings: true, EscapeHTML: true}, `["<a href=\"/\">"]`, ``, ``},
+		{"off", &Formatter{}, `["<a href=\"/\">"]`, ``, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.f.MarkupTagColor = color.New(color.FgRed)
+			tt.f.MarkupAttributeColor = color.New(color.FgBlue)
+			got := formatString(t, tt.f, tt.src)
+			if !tt.f.EscapeHTML && uncolored(got) != tt.src {
+				t.Errorf("Format(%s) = %q, want %q", tt.src, uncolored(got), tt.src)
+			}
+			if s := colored(got, "31"); s != tt.tags {
+				t.Errorf("Format(%s) colors %q with MarkupTagColor, want %q", tt.src, s, tt.tags)
+			}
+			if s := colored(got, "34"); s != tt.attr {
+				t.Errorf("Format(%s) colors %q with MarkupAttributeColor, want %q", tt.src, s, tt.attr)
+			}
+		})
+	}
+
+	// attribute values and text keep the string color
+	f := &Formatter{DetectMarkupInStrings: true, StringColor: color.New(color.FgGreen)}
+	got := formatString(t, f, `"<a href=\"/x\">hi</a>"`)
+	if s, want := colored(got, "32"), `" =\"/x\"hi"`; s != want {
+		t.Errorf("StringColor colors %q, want %q", s, want)
+	}
+}
@@ -0,0 +1,49 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// FormatMergePatch is like Format but colorizes src as a JSON Merge
+// Patch (see RFC 7386), in which a field whose value is null deletes
+// the field from the patched document.  The names and null values
+// of such fields use DeletedColor.  Since a patch replaces arrays
+// in their entirety, null values inside arrays and the objects they
+// contain are data rather than deletions and use their usual
+// colors.
+func (f *Formatter) FormatMergePatch(dst io.Writer, src []byte) error {
+	values, err := leafValues(src)
+	if err != nil {
+		return err
+	}
+
+	deleted := f.deletedColor()
+
+	// deletion reports whether the value with JSON Pointer p
+	// deletes a field, which it does if it is null and all of
+	// its enclosing containers are objects.
+	deletion := func(p string) bool {
+		if len(p) == 0 || values[p] != "null" {
+			return false
+		}
+		for len(p) > 0 {
+			p = p[:strings.LastIndexByte(p, '/')]
+			if values[p] != "{" {
+				return false
+			}
+		}
+		return true
+	}
+
+	fs := newFormatterState(f, dst)
+	fs.colorValue = func(path []string, t json.Token, field bool) SprintfFuncer {
+		if deletion(formatPointer(path)) {
+			return deleted
+		}
+		return nil
+	}
+
+	return fs.format(dst, src, false)
+}
@@ -0,0 +1,52 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatMergePatch(t *testing.T) {
+	tests := []struct {
+		src, deleted string
+	}{
+		{`{"a":null,"b":1}`, `"a"null`},
+		{`{"a":{"b":null,"c":{"d":null}}}`, `"b"null"d"null`},
+		// nulls in arrays, and in objects inside arrays, are data
+		{`{"a":[null,{"b":null}],"c":null}`, `"c"null`},
+		{`[null,{"a":null}]`, ``},
+		{`null`, ``},
+		{`{"a":"null","b":false}`, ``},
+	}
+	f := &Formatter{
+		DeletedColor: color.New(color.FgMagenta),
+		NullColor:    color.New(color.FgCyan),
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		if err := f.FormatMergePatch(buf, []byte(tt.src)); err != nil {
+			t.Fatalf("FormatMergePatch(%s): %v", tt.src, err)
+		}
+		got := buf.String()
+		if uncolored(got) != tt.src {
+			t.Errorf("FormatMergePatch(%s) = %q, want %q", tt.src, uncolored(got), tt.src)
+		}
+		if s := colored(got, "35"); s != tt.deleted {
+			t.Errorf("FormatMergePatch(%s) colors %q with DeletedColor, want %q", tt.src, s, tt.deleted)
+		}
+	}
+
+	// data nulls keep NullColor
+	buf := &bytes.Buffer{}
+	if err := f.FormatMergePatch(buf, []byte(`{"a":[null],"b":null}`)); err != nil {
+		t.Fatal(err)
+	}
+	if s := colored(buf.String(), "36"); s != "null" {
+		t.Errorf("FormatMergePatch colors %q with NullColor, want %q", s, "null")
+	}
+
+	if err := f.FormatMergePatch(&bytes.Buffer{}, []byte(`{"a":`)); err == nil {
+		t.Error("FormatMergePatch of invalid input succeeded")
+	}
+}
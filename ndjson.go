@@ -0,0 +1,56 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// FormatArrayAsLines appends to dst the elements of the JSON-encoded
+// src, which must be an array, as newline-delimited JSON, with each
+// element followed by a newline and colorized in compact form as if
+// f's Prefix and Indent fields were empty.  This is useful for
+// exporting arrays to tools that read one value per line.
+func (f *Formatter) FormatArrayAsLines(dst io.Writer, src []byte) error {
+	errNotArray := fmt.Errorf("jsoncolor: cannot format as lines, input is not an array")
+
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if x, ok := t.(json.Delim); !ok || x != json.Delim('[') {
+		return errNotArray
+	}
+
+	g := f.dataOnly()
+	g.setIndent("", "")
+
+	sprintfSpace := f.sprintfFunc(f.spaceColor())
+
+	for dec.More() {
+		var v json.RawMessage
+		err = dec.Decode(&v)
+		if err != nil {
+			return err
+		}
+		err = g.Format(dst, v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(dst, sprintfSpace("\n"))
+	}
+
+	_, err = dec.Token()
+	if err != nil {
+		return err
+	}
+	if _, err = dec.Token(); err != io.EOF {
+		return errNotArray
+	}
+
+	return nil
+}
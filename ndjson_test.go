@@ -0,0 +1,42 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatArrayAsLines(t *testing.T) {
+	src := ` [ {"a": [1, 2]}, "x", null, [], 1.5 ] `
+	f := &Formatter{Indent: "  ", Prefix: ">", ShowSummary: true, FieldColor: color.New(color.FgRed)}
+	buf := &bytes.Buffer{}
+	if err := f.FormatArrayAsLines(buf, []byte(src)); err != nil {
+		t.Fatalf("FormatArrayAsLines(%s): %v", src, err)
+	}
+	got := buf.String()
+	if want := "{\"a\":[1,2]}\n\"x\"\nnull\n[]\n1.5\n"; uncolored(got) != want {
+		t.Errorf("FormatArrayAsLines(%s) = %q, want %q", src, uncolored(got), want)
+	}
+
+	// each line is colorized as compact Format of its element
+	want := ""
+	for _, elem := range []string{`{"a":[1,2]}`, `"x"`, `null`, `[]`, `1.5`} {
+		want += formatString(t, &Formatter{FieldColor: f.FieldColor}, elem) + f.spaceColor().SprintfFunc()("\n")
+	}
+	if got != want {
+		t.Errorf("FormatArrayAsLines(%s) = %q, want %q", src, got, want)
+	}
+
+	buf.Reset()
+	if err := f.FormatArrayAsLines(buf, []byte(`[]`)); err != nil || buf.Len() != 0 {
+		t.Errorf("FormatArrayAsLines([]) = %q, %v, want no output", buf.String(), err)
+	}
+
+	for _, src := range []string{`{"a":1}`, `1`, `"[1]"`, `[1] [2]`, `[1`, `[1,}`, ``} {
+		buf.Reset()
+		if err := f.FormatArrayAsLines(buf, []byte(src)); err == nil {
+			t.Errorf("FormatArrayAsLines(%q) succeeded, want error", src)
+		}
+	}
+}
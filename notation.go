@@ -0,0 +1,67 @@
+package jsoncolor
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// NumberNotation is a notation in which numbers are displayed.
+type NumberNotation int
+
+const (
+	// PreserveNotation displays numbers as they appear in the
+	// input.
+	PreserveNotation NumberNotation = iota
+	// DecimalNotation displays numbers without an exponent, such
+	// as 1000 rather than 1e3.
+	DecimalNotation
+	// ScientificNotation displays numbers with an exponent, such
+	// as 1e3 rather than 1000.
+	ScientificNotation
+)
+
+// formatNotation returns the number n in the given notation, or n
+// unchanged if it is already in that notation or is not finite.
+func formatNotation(n string, notation NumberNotation) string {
+	switch notation {
+	case DecimalNotation:
+		if !strings.ContainsAny(n, "eE") {
+			return n
+		}
+	case ScientificNotation:
+	default:
+		return n
+	}
+	v, err := strconv.ParseFloat(n, 64)
+	if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+		return n
+	}
+	if notation == DecimalNotation {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(v, 'e', -1, 64)
+	// shorten exponents such as e+03 to e3
+	i := strings.IndexByte(s, 'e')
+	mantissa, exponent := s[:i], s[i+1:]
+	sign := ""
+	if exponent[0] == '-' {
+		sign = "-"
+	}
+	exponent = strings.TrimLeft(exponent[1:], "0")
+	if exponent == "" {
+		exponent = "0"
+	}
+	return mantissa + "e" + sign + exponent
+}
+
+// isLargeInt reports whether n is an integer whose magnitude exceeds
+// limit.
+func isLargeInt(n string, limit uint64) bool {
+	digits := strings.TrimPrefix(n, "-")
+	if len(digits) == 0 || strings.Trim(digits, "0123456789") != "" {
+		return false
+	}
+	v, err := strconv.ParseUint(digits, 10, 64)
+	return err != nil || v > limit
+}
@@ -0,0 +1,85 @@
+package jsoncolor
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestNumberNotation(t *testing.T) {
+	tests := []struct {
+		n          string
+		decimal    string
+		scientific string
+	}{
+		{"1e3", "1000", "1e3"},
+		{"1000", "1000", "1e3"},
+		{"1E+03", "1000", "1e3"},
+		{"-2.5e-3", "-0.0025", "-2.5e-3"},
+		{"0.0025", "0.0025", "2.5e-3"},
+		{"123.456", "123.456", "1.23456e2"},
+		{"0", "0", "0e0"},
+		{"1e400", "1e400", "1e400"},
+	}
+	for _, tt := range tests {
+		if got := formatNotation(tt.n, PreserveNotation); got != tt.n {
+			t.Errorf("formatNotation(%s, PreserveNotation) = %s, want %s", tt.n, got, tt.n)
+		}
+		if got := formatNotation(tt.n, DecimalNotation); got != tt.decimal {
+			t.Errorf("formatNotation(%s, DecimalNotation) = %s, want %s", tt.n, got, tt.decimal)
+		}
+		if got := formatNotation(tt.n, ScientificNotation); got != tt.scientific {
+			t.Errorf("formatNotation(%s, ScientificNotation) = %s, want %s", tt.n, got, tt.scientific)
+		}
+	}
+
+	got := uncolored(formatString(t, &Formatter{NumberNotation: DecimalNotation}, `{"a":1e3,"b":[1000,"1e3"]}`))
+	if want := `{"a":1000,"b":[1000,"1e3"]}`; got != want {
+		t.Errorf("Format with DecimalNotation = %s, want %s", got, want)
+	}
+	got = uncolored(formatString(t, &Formatter{NumberNotation: ScientificNotation}, `{"a":1e3,"b":[1000,"1000"]}`))
+	if want := `{"a":1e3,"b":[1e3,"1000"]}`; got != want {
+		t.Errorf("Format with ScientificNotation = %s, want %s", got, want)
+	}
+}
+
+func TestExponentColor(t *testing.T) {
+	f := &Formatter{
+		NumberColor:         color.New(color.FgBlue),
+		NegativeNumberColor: color.New(color.FgRed),
+		ExponentColor:       color.New(color.FgMagenta),
+	}
+	tests := []struct {
+		f        *Formatter
+		src      string
+		mantissa string
+		exponent string
+	}{
+		{f, `[1.5e-10]`, "1.5", "e-10"},
+		{f, `[2E5]`, "2", "E5"},
+		{f, `[3e+07]`, "3", "e+07"},
+		{f, `[1.5,100]`, "1.5100", ""},
+		{f, `["1e5"]`, "", ""},
+		{&Formatter{NumberColor: f.NumberColor, ExponentColor: f.ExponentColor, NumberNotation: ScientificNotation}, `[1500]`, "1.5", "e3"},
+		{&Formatter{NumberColor: f.NumberColor}, `[1.5e-10]`, "1.5e-10", ""},
+	}
+	for _, tt := range tests {
+		got := formatString(t, tt.f, tt.src)
+		if s := colored(got, "34"); s != tt.mantissa {
+			t.Errorf("Format(%s) colors %q as numbers, want %q", tt.src, s, tt.mantissa)
+		}
+		if s := colored(got, "35"); s != tt.exponent {
+			t.Errorf("Format(%s) colors %q with ExponentColor, want %q", tt.src, s, tt.exponent)
+		}
+	}
+
+	// the exponent keeps its color when the rest of the number
+	// uses another
+	got := formatString(t, f, `[-1.5e-10]`)
+	if s := colored(got, "31"); s != "-1.5" {
+		t.Errorf("Format([-1.5e-10]) colors %q with NegativeNumberColor, want %q", s, "-1.5")
+	}
+	if s := colored(got, "35"); s != "e-10" {
+		t.Errorf("Format([-1.5e-10]) colors %q with ExponentColor, want %q", s, "e-10")
+	}
+}
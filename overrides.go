@@ -0,0 +1,60 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// FormatOverrides is like Format but colorizes actual relative to the
+// document defaults it overrides, such as a layered configuration
+// file, so that reviewers can see which values were overridden.
+// Values whose JSON Pointer does not exist in defaults use
+// AddedColor, values that differ from those of defaults use
+// ChangedColor and values equal to those of defaults use DimColor.
+// An object or array that exists in defaults but contains changed
+// or added values is itself considered changed.  Field names use
+// the color of the value they hold.  Delimiters use their usual
+// colors.
+func (f *Formatter) FormatOverrides(dst io.Writer, actual, defaults []byte) error {
+	values, err := leafValues(actual)
+	if err != nil {
+		return err
+	}
+	base, err := leafValues(defaults)
+	if err != nil {
+		return err
+	}
+
+	added, changed, unchanged := f.addedColor(), f.changedColor(), f.dimColor()
+
+	colors := map[string]SprintfFuncer{}
+	for p, v := range values {
+		b, ok := base[p]
+		switch {
+		case !ok:
+			colors[p] = added
+		case v != b:
+			colors[p] = changed
+		default:
+			if _, ok := colors[p]; !ok {
+				colors[p] = unchanged
+			}
+			continue
+		}
+		// mark the enclosing containers present in defaults
+		for len(p) > 0 {
+			p = p[:strings.LastIndexByte(p, '/')]
+			if _, ok := base[p]; ok {
+				colors[p] = changed
+			}
+		}
+	}
+
+	fs := newFormatterState(f, dst)
+	fs.colorValue = func(path []string, t json.Token, field bool) SprintfFuncer {
+		return colors[formatPointer(path)]
+	}
+
+	return fs.format(dst, actual, false)
+}
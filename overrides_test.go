@@ -0,0 +1,42 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatOverrides(t *testing.T) {
+	actual := `{"a":1,"b":"x","c":{"d":true,"e":null},"f":[1,2],"g":{"h":0}}`
+	defaults := `{"a":1,"b":"y","c":{"d":true},"f":[1],"g":{"h":0}}`
+	f := &Formatter{
+		AddedColor:   color.New(color.FgMagenta),
+		ChangedColor: color.New(color.FgCyan),
+		DimColor:     color.New(color.FgRed),
+	}
+	buf := &bytes.Buffer{}
+	if err := f.FormatOverrides(buf, []byte(actual), []byte(defaults)); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if uncolored(got) != actual {
+		t.Errorf("FormatOverrides = %q, want %q", uncolored(got), actual)
+	}
+	// values and the quoted field names holding them
+	if s, want := colored(got, "35"), `"e"null2`; s != want {
+		t.Errorf("AddedColor colors %q, want %q", s, want)
+	}
+	if s, want := colored(got, "36"), `"b""x""c""f"`; s != want {
+		t.Errorf("ChangedColor colors %q, want %q", s, want)
+	}
+	if s, want := colored(got, "31"), `"a"1"d"true1"g""h"0`; s != want {
+		t.Errorf("DimColor colors %q, want %q", s, want)
+	}
+
+	for _, tt := range []struct{ actual, defaults string }{{`[1,`, `[]`}, {`[]`, `{`}} {
+		if err := f.FormatOverrides(&bytes.Buffer{}, []byte(tt.actual), []byte(tt.defaults)); err == nil {
+			t.Errorf("FormatOverrides(%s, %s) succeeded", tt.actual, tt.defaults)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sprintfFunc returns c's SprintfFunc, quantized as by MaxColors
+// and restricted to the attributes permitted by PagerSafe if it is
+// true, or fmt.Sprintf if f writes output without color.
+func (f *Formatter) sprintfFunc(c SprintfFuncer) sprintfFunc {
+	if f.noColor {
+		return fmt.Sprintf
+	}
+	sprintf := c.SprintfFunc()
+	if p := f.palette(); p != nil {
+		quantized := sprintf
+		sprintf = func(format string, a ...interface{}) string {
+			return p.remap(quantized(format, a...))
+		}
+	}
+	if !f.PagerSafe {
+		return sprintf
+	}
+	return func(format string, a ...interface{}) string {
+		return pagerSafe(sprintf(format, a...))
+	}
+}
+
+// pagerSafe returns s with the SGR parameters not permitted by
+// PagerSafe removed from its escape sequences, followed by a reset
+// if s leaves any attributes set.
+func pagerSafe(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+
+	var b strings.Builder
+	set := false
+	for {
+		start := strings.Index(s, "\x1b[")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], 'm')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(s[:start])
+
+		var kept []string
+		params := strings.Split(s[start+2:end], ";")
+		for i := 0; i < len(params); i++ {
+			n, err := strconv.Atoi(params[i])
+			if err != nil && len(params[i]) > 0 {
+				continue
+			}
+			switch {
+			case n == 38 || n == 48:
+				// skip extended 256 and 24-bit colors
+				if i+1 < len(params) && params[i+1] == "5" {
+					i += 2
+				} else if i+1 < len(params) && params[i+1] == "2" {
+					i += 4
+				}
+			case n == 0:
+				// omit resets when no attributes are set
+				if set || len(kept) > 0 {
+					kept = append(kept, "0")
+				}
+				set = false
+			case pagerSafeParam(n):
+				kept = append(kept, strconv.Itoa(n))
+				set = true
+			}
+		}
+		if len(kept) > 0 {
+			b.WriteString("\x1b[" + strings.Join(kept, ";") + "m")
+		}
+		s = s[end+1:]
+	}
+	b.WriteString(s)
+	if set {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
+
+// pagerSafeParam reports whether the SGR parameter n is permitted by
+// PagerSafe.
+func pagerSafeParam(n int) bool {
+	switch {
+	case n == 1, n == 4, n == 7:
+		// bold, underline and reverse video
+		return true
+	case n >= 22 && n <= 27:
+		// attribute resets
+		return true
+	case n >= 30 && n <= 37, n == 39, n >= 90 && n <= 97:
+		// foreground colors
+		return true
+	case n >= 40 && n <= 47, n == 49, n >= 100 && n <= 107:
+		// background colors
+		return true
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package jsoncolor
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestPagerSafe(t *testing.T) {
+	src := `{"a":[1,"x",true,null]}`
+	f := &Formatter{
+		PagerSafe:    true,
+		AppendLegend: true,
+		FieldColor:   color.New(color.FgBlue, color.Faint, color.Italic),
+		StringColor:  color.New(color.FgGreen, color.BlinkSlow, color.Underline),
+		NumberColor:  escapes("\x1b[38;5;208m"),
+		TrueColor:    escapes("\x1b[38;2;1;2;3;1m"),
+		NullColor:    color.New(color.Faint),
+		SpaceColor:   color.New(color.CrossedOut, color.Concealed),
+	}
+	got := formatString(t, f, src)
+	if want := src + "\n" + uncolored(f.Legend()); uncolored(got) != want {
+		t.Errorf("Format(%s) = %q, want %q", src, uncolored(got), want)
+	}
+	for _, m := range escapeRE.FindAllString(got, -1) {
+		for _, p := range strings.Split(m[2:len(m)-1], ";") {
+			n, err := strconv.Atoi(p)
+			if err != nil || n != 0 && !pagerSafeParam(n) {
+				t.Errorf("Format emits unsupported escape %q", m)
+				break
+			}
+		}
+	}
+	if _, active := render(got); active != "" {
+		t.Errorf("Format leaves %q in effect", active)
+	}
+	line := got[:strings.Index(got, "\n")]
+	if s := colored(line, "34"); s != "a" {
+		t.Errorf("FieldColor colors %q, want %q", s, "a")
+	}
+	if s := colored(line, "32;4"); s != "x" {
+		t.Errorf("StringColor colors %q, want %q", s, "x")
+	}
+	if s := colored(line, "1"); s != "{:[,,true,]}" {
+		t.Errorf("bold colors %q, want %q", s, "{:[,,true,]}")
+	}
+
+	// every colored token is followed by a reset
+	for _, tok := range []string{"a", "x", "true"} {
+		i := strings.Index(got, tok+"\x1b[0m")
+		if i < 0 {
+			t.Errorf("Format(%s) = %q, want %q followed by a reset", src, got, tok)
+		}
+	}
+
+	tests := []struct{ in, want string }{
+		{"plain", "plain"},
+		{"\x1b[2;3;31mx\x1b[0m", "\x1b[31mx\x1b[0m"},
+		{"\x1b[2mx\x1b[0m", "x"},
+		{"\x1b[38;5;1;1mx", "\x1b[1mx\x1b[0m"},
+		{"\x1b[48;2;1;2;3;4mx\x1b[0m", "\x1b[4mx\x1b[0m"},
+		{"\x1b[91;107mx\x1b[0m", "\x1b[91;107mx\x1b[0m"},
+	}
+	for _, tt := range tests {
+		if got := pagerSafe(tt.in); got != tt.want {
+			t.Errorf("pagerSafe(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package jsoncolor
+
+import (
+	"strconv"
+	"strings"
+)
+
+// palette maps the escape sequences of colors to those of at most
+// max colors, see MaxColors.
+type palette struct {
+	max int
+	// reps are the SGR parameters of the colors the others are
+	// mapped to.
+	reps []string
+	// mapped maps the SGR parameters of the colors seen so far to
+	// the parameters of the colors they are mapped to.
+	mapped map[string]string
+}
+
+// palette returns the palette quantizing the colors f uses to at
+// most MaxColors colors, or nil if MaxColors is not positive or f
+// writes output without color.
+func (f *Formatter) palette() *palette {
+	if f.MaxColors <= 0 || f.noColor {
+		return nil
+	}
+	p := &palette{max: f.MaxColors, mapped: map[string]string{}}
+	for _, c := range f.usedColors() {
+		if c == nil {
+			continue
+		}
+		params, ok := sgrParams(c.SprintfFunc()("%s", affixMarker))
+		if !ok || isReset(params) {
+			continue
+		}
+		if _, ok := p.mapped[params]; !ok {
+			p.mapped[params] = params
+			p.reps = append(p.reps, params)
+		}
+	}
+
+	// repeatedly merge the two closest colors, keeping the one
+	// used first, until at most max remain
+	for len(p.reps) > p.max {
+		bi, bj, best := 0, 1, -1
+		for i := range p.reps {
+			for j := i + 1; j < len(p.reps); j++ {
+				d := sgrDistance(p.reps[i], p.reps[j])
+				if best < 0 || d < best {
+					bi, bj, best = i, j, d
+				}
+			}
+		}
+		from, to := p.reps[bj], p.reps[bi]
+		for k, v := range p.mapped {
+			if v == from {
+				p.mapped[k] = to
+			}
+		}
+		p.reps = append(p.reps[:bj], p.reps[bj+1:]...)
+	}
+	return p
+}
+
+// remap returns s with the parameters of its SGR escape sequences
+// replaced by those of the colors they are mapped to.  Colors not
+// seen before are added to the palette while it has fewer than max
+// colors and are otherwise mapped to the nearest of its colors.
+func (p *palette) remap(s string) string {
+	if p == nil || !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	var b strings.Builder
+	for {
+		start := strings.Index(s, "\x1b[")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], 'm')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(s[:start])
+		b.WriteString("\x1b[" + p.lookup(s[start+2:end]) + "m")
+		s = s[end+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// lookup returns the SGR parameters of the color params is mapped
+// to.
+func (p *palette) lookup(params string) string {
+	if isReset(params) {
+		return params
+	}
+	if to, ok := p.mapped[params]; ok {
+		return to
+	}
+	to := params
+	if len(p.reps) < p.max {
+		p.reps = append(p.reps, params)
+	} else {
+		best := -1
+		for _, r := range p.reps {
+			if d := sgrDistance(params, r); best < 0 || d < best {
+				to, best = r, d
+			}
+		}
+	}
+	p.mapped[params] = to
+	return to
+}
+
+// sgrParams returns the parameters of the SGR escape sequence s
+// begins with.
+func sgrParams(s string) (string, bool) {
+	n := ansiLen(s)
+	if n == 0 || s[n-1] != 'm' {
+		return "", false
+	}
+	return s[2 : n-1], true
+}
+
+// isReset reports whether the SGR parameters params reset all
+// attributes rather than setting a color.
+func isReset(params string) bool {
+	return params == "" || params == "0"
+}
+
+// sgrColor is the foreground and background colors, as RGB values,
+// and the other attributes, as a bit set, set by SGR parameters.
+type sgrColor struct {
+	fg, bg [3]int
+	attrs  uint
+}
+
+// basicColors are the RGB values of the 16 standard and bright
+// colors, as displayed by xterm.
+var basicColors = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// color256 returns the RGB value of color n of the 256 color
+// palette.
+func color256(n int) [3]int {
+	switch {
+	case n < 16:
+		return basicColors[n]
+	case n < 232:
+		n -= 16
+		level := func(i int) int {
+			if i == 0 {
+				return 0
+			}
+			return 55 + 40*i
+		}
+		return [3]int{level(n / 36), level(n / 6 % 6), level(n % 6)}
+	}
+	g := 8 + 10*(n-232)
+	return [3]int{g, g, g}
+}
+
+// parseSGR returns the colors and attributes set by the SGR
+// parameters params, using white on black for default colors.
+func parseSGR(params string) sgrColor {
+	c := sgrColor{fg: basicColors[7], bg: basicColors[0]}
+	ps := strings.Split(params, ";")
+	arg := func(i int) int {
+		if i >= len(ps) {
+			return 0
+		}
+		n, _ := strconv.Atoi(ps[i])
+		if n < 0 || n > 255 {
+			return 0
+		}
+		return n
+	}
+	for i := 0; i < len(ps); i++ {
+		n, err := strconv.Atoi(ps[i])
+		if err != nil {
+			continue
+		}
+		rgb := &c.fg
+		if n >= 40 && n <= 49 || n >= 100 && n <= 107 {
+			rgb = &c.bg
+		}
+		switch {
+		case n == 38 || n == 48:
+			// extended 256 and 24-bit colors
+			if arg(i+1) == 5 {
+				*rgb = color256(arg(i + 2))
+				i += 2
+			} else if arg(i+1) == 2 {
+				*rgb = [3]int{arg(i + 2), arg(i + 3), arg(i + 4)}
+				i += 4
+			}
+		case n == 39:
+			*rgb = basicColors[7]
+		case n == 49:
+			*rgb = basicColors[0]
+		case n >= 30 && n <= 37, n >= 40 && n <= 47:
+			*rgb = basicColors[n%10]
+		case n >= 90 && n <= 97, n >= 100 && n <= 107:
+			*rgb = basicColors[n%10+8]
+		case n >= 1 && n <= 9:
+			c.attrs |= 1 << uint(n)
+		}
+	}
+	return c
+}
+
+// attributeDistance is the distance sgrDistance adds for each
+// attribute set by only one of two colors.
+const attributeDistance = 3 * 64 * 64
+
+// sgrDistance returns the distance between the colors set by the SGR
+// parameters a and b, which is the squared Euclidean distance between
+// their foreground RGB values plus that between their background RGB
+// values plus attributeDistance for each attribute, such as bold or
+// underline, set by only one of them.
+func sgrDistance(a, b string) int {
+	ca, cb := parseSGR(a), parseSGR(b)
+	d := 0
+	for i := 0; i < 3; i++ {
+		fg, bg := ca.fg[i]-cb.fg[i], ca.bg[i]-cb.bg[i]
+		d += fg*fg + bg*bg
+	}
+	for x := ca.attrs ^ cb.attrs; x != 0; x &= x - 1 {
+		d += attributeDistance
+	}
+	return d
+}
@@ -0,0 +1,89 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// parsePointer splits the JSON Pointer p (see RFC 6901) into its
+// unescaped reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return []string{}, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("jsoncolor: invalid JSON Pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		t = strings.Replace(t, "~1", "/", -1)
+		t = strings.Replace(t, "~0", "~", -1)
+		tokens[i] = t
+	}
+	return tokens, nil
+}
+
+// hasPathPrefix reports whether path begins with prefix.
+func hasPathPrefix(path, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatPointer returns the JSON Pointer (see RFC 6901) made up of
+// the reference tokens in path.
+func formatPointer(path []string) string {
+	var b strings.Builder
+	for _, t := range path {
+		b.WriteByte('/')
+		t = strings.Replace(t, "~", "~0", -1)
+		t = strings.Replace(t, "/", "~1", -1)
+		b.WriteString(t)
+	}
+	return b.String()
+}
+
+// indentOverride is a parsed entry of Formatter.IndentOverrides.
+type indentOverride struct {
+	path   []string
+	indent string
+}
+
+// parseIndentOverrides parses overrides, returning its entries
+// ordered longest pointer first.
+func parseIndentOverrides(overrides map[string]string) ([]indentOverride, error) {
+	parsed := make([]indentOverride, 0, len(overrides))
+	for p, indent := range overrides {
+		path, err := parsePointer(p)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, indentOverride{path: path, indent: indent})
+	}
+	sort.Slice(parsed, func(i, j int) bool {
+		return len(parsed[i].path) > len(parsed[j].path)
+	})
+	return parsed, nil
+}
+
+// indentLevel returns the indentation of level i of a line whose
+// value has the given path, which is that of the object or array
+// path[:i] beginning the level, see IndentOverrides.
+func (fs *formatterState) indentLevel(path []string, i int) string {
+	if i < len(path) {
+		path = path[:i]
+	}
+	for _, o := range fs.indentOverrides {
+		if hasPathPrefix(path, o.path) {
+			return o.indent
+		}
+	}
+	return fs.f.Indent
+}
@@ -0,0 +1,129 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatProperties appends to dst a colorized form of the
+// JSON-encoded src in the style of a properties file, with one line
+// per field such as name = "value", where src must be an object
+// whose field values are all scalars.  Field names are displayed
+// without quotes, escaped as java.util.Properties escapes keys so
+// that each ends at the = sign following it, and padded so that
+// the = signs align.  Values are colored as usual.  f's Prefix and
+// Indent fields are ignored.
+func (f *Formatter) FormatProperties(dst io.Writer, src []byte) error {
+	errNotProperties := fmt.Errorf("jsoncolor: cannot format as properties, input is not an object of scalar values")
+
+	g := f.dataOnly()
+	g.setIndent("", "")
+	g.FocusPath = ""
+
+	fs := newFormatterState(g, dst)
+	defer fs.closeColor()
+	if g.MaxLines > 0 {
+		defer fs.bufferLines()()
+	}
+	dec := fs.decoder(src)
+
+	token := func() (json.Token, error) {
+		t, err := dec.Token()
+		if err == nil {
+			err = fs.checkDeadline()
+		}
+		return t, err
+	}
+
+	t, err := token()
+	if err != nil {
+		return err
+	}
+	if x, ok := t.(json.Delim); !ok || x != json.Delim('{') {
+		return errNotProperties
+	}
+
+	var keys []string
+	var values []json.Token
+	width := 0
+	for dec.More() {
+		t, err := token()
+		if err != nil {
+			return err
+		}
+		k := objectKey(t)
+		v, err := token()
+		if err != nil {
+			return err
+		}
+		if _, ok := v.(json.Delim); ok {
+			return errNotProperties
+		}
+		keys = append(keys, k)
+		values = append(values, v)
+		if w := utf8.RuneCountInString(propertiesKey(k)); w > width {
+			width = w
+		}
+	}
+	_, err = token()
+	if err != nil {
+		return err
+	}
+	_, err = token()
+	if err == nil {
+		return errNotProperties
+	}
+	if err != io.EOF {
+		return err
+	}
+
+	frame := fs.enterFrame(json.Delim('{'), len(keys) == 0)
+	for i, k := range keys {
+		frame.index++
+		frame.key = k
+		if i > 0 {
+			fs.printSpace("\n", true)
+		}
+		key := propertiesKey(k)
+		fs.print(TokenField, key)
+		pad := width - utf8.RuneCountInString(key) + 1
+		fs.printSpace(strings.Repeat(" ", pad), true)
+		fs.print(TokenColon, "=")
+		fs.printSpace(" ", true)
+		err = fs.printLeaf(values[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// propertiesKey returns the field name k escaped as
+// java.util.Properties escapes the keys it stores: the characters
+// ending a key or beginning a comment and backslashes are preceded
+// by a backslash, and tabs, newlines, carriage returns and form
+// feeds are written as \t, \n, \r and \f.
+func propertiesKey(k string) string {
+	var b strings.Builder
+	for _, c := range k {
+		switch c {
+		case '\\', '=', ':', '#', '!', ' ':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatProperties(t *testing.T) {
+	tests := []struct {
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{&Formatter{}, `{}`, ``},
+		{&Formatter{}, `{"a":1}`, `a = 1`},
+		{&Formatter{Indent: "  "}, `{"name":"x","port":8080,"debug":false,"tls":null}`,
+			"name  = \"x\"\nport  = 8080\ndebug = false\ntls   = null"},
+		{&Formatter{}, `{"ü":1,"ab":2}`, "ü  = 1\nab = 2"},
+		{&Formatter{LeftMargin: 2}, `{"a":1,"bcd":2}`, "  a   = 1\n  bcd = 2"},
+		{&Formatter{}, `{"a b":1,"c=d:e":2,"#!\\":3,"\t\n\r\f":4}`,
+			"a\\ b     = 1\nc\\=d\\:e  = 2\n\\#\\!\\\\   = 3\n\\t\\n\\r\\f = 4"},
+		{&Formatter{MaxLines: 3}, `{"a":1,"b":2,"c":3,"d":4,"e":5}`, "a = 1\n… (3 lines hidden) …\ne = 5"},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		err := tt.f.FormatProperties(buf, []byte(tt.src))
+		if err != nil {
+			t.Fatalf("FormatProperties(%s): %v", tt.src, err)
+		}
+		if got := uncolored(buf.String()); got != tt.want {
+			t.Errorf("FormatProperties(%s) = %q, want %q", tt.src, got, tt.want)
+		}
+		if _, active := render(buf.String()); active != "" {
+			t.Errorf("FormatProperties(%s) leaves %q in effect", tt.src, active)
+		}
+	}
+}
+
+func TestFormatPropertiesErrors(t *testing.T) {
+	for _, src := range []string{`[]`, `"x"`, `{"a":{}}`, `{"a":1,"b":[2]}`, `{"a":`, `{} {}`, `{"a":1} 2`} {
+		err := (&Formatter{}).FormatProperties(&bytes.Buffer{}, []byte(src))
+		if err == nil {
+			t.Errorf("FormatProperties(%s) succeeded", src)
+		}
+	}
+}
+
+func TestFormatPropertiesOutput(t *testing.T) {
+	buf := &bytes.Buffer{}
+	f := &Formatter{FieldColor: color.New(color.FgBlue), NumberColor: color.New(color.FgRed), AppendLegend: true}
+	err := f.FormatProperties(buf, []byte(`{"a b":1,"c":"x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for params, want := range map[string]string{"34": `a\ bc`, "31": "1"} {
+		if s := colored(buf.String(), params); s != want {
+			t.Errorf("FormatProperties colors %q with %s, want %q", s, params, want)
+		}
+	}
+	if got, want := uncolored(buf.String()), "a\\ b = 1\nc    = \"x\""; got != want {
+		t.Errorf("FormatProperties with AppendLegend = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package jsoncolor
+
+// ColorRun is a run of the plain output of FormatRuns made up of
+// tokens of a single kind.
+type ColorRun struct {
+	// Start and End are the byte offsets of the start and end of
+	// the run in the plain output.
+	Start, End int
+	// Kind is the kind of the tokens making up the run.
+	Kind TokenKind
+}
+
+// FormatRuns is like Format but returns the JSON-encoded src
+// formatted without color along with the runs of the output made up
+// of each kind of token, so that renderers can apply their own
+// styling without parsing the output.  The runs are in order and
+// cover the entire output, adjacent tokens of the same kind being
+// combined into a single run.
+func (f *Formatter) FormatRuns(src []byte) (plain []byte, runs []ColorRun, err error) {
+	fs := newFormatterState(f, nil)
+	fs.emit = func(kind TokenKind, st style, s string) {
+		start := len(plain)
+		plain = append(plain, s...)
+		if n := len(runs); n > 0 && runs[n-1].Kind == kind {
+			runs[n-1].End = len(plain)
+			return
+		}
+		runs = append(runs, ColorRun{Start: start, End: len(plain), Kind: kind})
+	}
+
+	err = fs.format(nil, src, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return plain, runs, nil
+}
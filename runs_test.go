@@ -0,0 +1,84 @@
+package jsoncolor
+
+import (
+	"testing"
+)
+
+func TestFormatRuns(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Formatter
+		src  string
+	}{
+		{"compact", &Formatter{}, sample},
+		{"indent", &Formatter{Indent: "  "}, sample},
+		{"margin", &Formatter{Indent: "  ", LeftMargin: 4}, sample},
+		{"scalar", &Formatter{}, `"hello"`},
+		{"empty", &Formatter{Indent: "  "}, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, runs, err := tt.f.FormatRuns([]byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := uncolored(formatString(t, tt.f, tt.src)); string(plain) != want {
+				t.Errorf("plain output is %q, want %q", plain, want)
+			}
+			end := 0
+			for i, r := range runs {
+				if r.Start != end {
+					t.Errorf("run %d %+v starts at %d, want %d", i, r, r.Start, end)
+				}
+				if r.End <= r.Start {
+					t.Errorf("run %d %+v is empty", i, r)
+				}
+				if i > 0 && runs[i-1].Kind == r.Kind {
+					t.Errorf("runs %d and %d are both of kind %v", i-1, i, r.Kind)
+				}
+				end = r.End
+			}
+			if end != len(plain) {
+				t.Errorf("runs end at %d, want %d", end, len(plain))
+			}
+		})
+	}
+}
+
+func TestFormatRunsKinds(t *testing.T) {
+	plain, runs, err := (&Formatter{}).FormatRuns([]byte(`{"a":[1,"x",true,null]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range runs {
+		got = append(got, r.Kind.String()+" "+string(plain[r.Start:r.End]))
+	}
+	want := []string{
+		`object {`,
+		`field quote "`,
+		`field a`,
+		`field quote "`,
+		`colon :`,
+		`array [`,
+		`number 1`,
+		`comma ,`,
+		`string quote "`,
+		`string x`,
+		`string quote "`,
+		`comma ,`,
+		`true true`,
+		`comma ,`,
+		`null null`,
+		`array ]`,
+		`object }`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("runs are %q, want %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("run %d is %q, want %q", i, got[i], want[i])
+		}
+	}
+}
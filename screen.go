@@ -0,0 +1,45 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FormatAt is like Format but positions the output at the given row
+// and column of a terminal screen, counting from 1, rather than at
+// the cursor, for redrawing a fixed region of the screen such as a
+// pane of a dashboard.  Each line of output is preceded by a cursor
+// positioning escape sequence moving to its row and the given
+// column, and newlines are omitted.  Set MaxLines to limit the
+// number of rows used, allowing one more row for the indicator
+// reporting hidden lines.  The output is only meaningful to terminals
+// supporting ANSI cursor positioning, such as those emulating a
+// VT100.
+func (f *Formatter) FormatAt(dst io.Writer, src []byte, row, col int) error {
+	fs := newFormatterState(f, dst)
+
+	emit := fs.emit
+	lineStart := true
+	fs.emit = func(kind TokenKind, st style, s string) {
+		for len(s) > 0 {
+			if lineStart {
+				fmt.Fprintf(dst, "\x1b[%d;%dH", row, col)
+				lineStart = false
+			}
+			i := strings.IndexByte(s, '\n')
+			if i < 0 {
+				emit(kind, st, s)
+				return
+			}
+			if i > 0 {
+				emit(kind, st, s[:i])
+			}
+			row++
+			lineStart = true
+			s = s[i+1:]
+		}
+	}
+
+	return fs.format(dst, src, false)
+}
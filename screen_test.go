@@ -0,0 +1,62 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+// cursorRE matches cursor positioning escape sequences.
+var cursorRE = regexp.MustCompile("\x1b\\[(\\d+);(\\d+)H")
+
+func TestFormatAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		f     *Formatter
+		src   string
+		lines []string
+	}{
+		{"compact", &Formatter{}, `[1,2]`, []string{`[1,2]`}},
+		{"indent", &Formatter{Indent: "  "}, `{"a":[1]}`, []string{
+			`{`,
+			`  "a": [`,
+			`    1`,
+			`  ]`,
+			`}`,
+		}},
+		{"max lines", &Formatter{Indent: "  ", MaxLines: 3}, `[1,2,3,4,5]`, []string{
+			`[`,
+			`… (5 lines hidden) …`,
+			`]`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := tt.f.FormatAt(buf, []byte(tt.src), 5, 10); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.String()
+			locs := cursorRE.FindAllStringSubmatchIndex(got, -1)
+			if len(locs) != len(tt.lines) {
+				t.Fatalf("FormatAt = %q, want %d cursor movements", got, len(tt.lines))
+			}
+			for i, loc := range locs {
+				if want := fmt.Sprintf("\x1b[%d;10H", 5+i); got[loc[0]:loc[1]] != want {
+					t.Errorf("line %d moves with %q, want %q", i, got[loc[0]:loc[1]], want)
+				}
+				end := len(got)
+				if i+1 < len(locs) {
+					end = locs[i+1][0]
+				}
+				if line := uncolored(got[loc[1]:end]); line != tt.lines[i] {
+					t.Errorf("line %d = %q, want %q", i, line, tt.lines[i])
+				}
+			}
+			if i := bytes.IndexByte(buf.Bytes(), '\n'); i >= 0 {
+				t.Errorf("FormatAt = %q, want no newlines", got)
+			}
+		})
+	}
+}
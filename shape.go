@@ -0,0 +1,162 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// FormatShape returns a colorized one-line summary of the shape of
+// the outermost value of the JSON-encoded src, giving a quick
+// overview of a large document.  Values are summarized as in
+// OutlineMode, using [n] for an array of n elements, Ellipsis in
+// quotes for strings, # for numbers, bool for booleans and null for
+// null, along with {n} for an object of n fields.  An outermost
+// object is displayed with the summaries of its fields, such as
+// {"id": #, "tags": [3], "owner": {2}}, and an outermost array with
+// the summaries of its elements, with runs of elements of the same
+// shape collapsed, such as [{2}×998, null] for an array of 998
+// objects of two fields followed by null.  Only the outermost value
+// and the number of elements of the values nested directly inside
+// it are inspected.
+func (f *Formatter) FormatShape(src []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fs := newFormatterState(f, buf)
+	dec := fs.decoder(src)
+
+	t, err := dec.Token()
+	if err == nil {
+		err = fs.formatShape(dec, t)
+	}
+	fs.closeColor()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// objectShape is a token replacing an object with the given number
+// of fields in the output of FormatShape.
+type objectShape int
+
+// formatShape prints the summary of the shape of the value beginning
+// with token t.
+func (fs *formatterState) formatShape(dec tokenReader, t json.Token) error {
+	open, ok := t.(json.Delim)
+	if !ok {
+		fs.printShape(t)
+		return nil
+	}
+	object := open == json.Delim('{')
+	if object {
+		fs.printObject(open)
+	} else {
+		fs.printArray(open)
+	}
+
+	// prev is the shape of the elements of the current run of
+	// n elements of the same shape
+	var prev json.Token
+	n := 0
+	endRun := func() {
+		if n > 1 {
+			fs.print(TokenMore, fmt.Sprintf("×%d", n))
+		}
+	}
+
+	for i := 0; dec.More(); i++ {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if object {
+			if i > 0 {
+				fs.printComma()
+				fs.printSpace(" ", true)
+			}
+			err = fs.printField(objectKey(t))
+			if err != nil {
+				return err
+			}
+			fs.printColon()
+			fs.printSpace(" ", true)
+			t, err = dec.Token()
+			if err != nil {
+				return err
+			}
+		}
+		t, err = elementShape(dec, t)
+		if err != nil {
+			return err
+		}
+		if object {
+			fs.printShape(t)
+			continue
+		}
+		if n > 0 && shapeKey(t) == shapeKey(prev) {
+			n++
+			continue
+		}
+		endRun()
+		if i > 0 {
+			fs.printComma()
+			fs.printSpace(" ", true)
+		}
+		fs.printShape(t)
+		prev, n = t, 1
+	}
+	endRun()
+
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if object {
+		fs.printObject(t.(json.Delim))
+	} else {
+		fs.printArray(t.(json.Delim))
+	}
+	return nil
+}
+
+// elementShape consumes the value beginning with token t, returning
+// the token summarizing it, which is an objectShape or arrayOutline
+// for objects and arrays and t itself otherwise.
+func elementShape(dec tokenReader, t json.Token) (json.Token, error) {
+	x, ok := t.(json.Delim)
+	if !ok {
+		return t, nil
+	}
+	n, err := countElements(dec)
+	if err != nil {
+		return nil, err
+	}
+	if x == json.Delim('{') {
+		// countElements counts field names and values alike
+		return objectShape(n / 2), nil
+	}
+	return arrayOutline(n), nil
+}
+
+// shapeKey returns a value equal for tokens returned by elementShape
+// that have the same summary and color.
+func shapeKey(t json.Token) interface{} {
+	switch t.(type) {
+	case objectShape, arrayOutline:
+		return t
+	}
+	return valueTokenKind(t)
+}
+
+// printShape prints the summary of the token t returned by
+// elementShape.
+func (fs *formatterState) printShape(t json.Token) {
+	switch x := t.(type) {
+	case objectShape:
+		fs.print(TokenObject, fmt.Sprintf("{%d}", int(x)))
+	case unparseable:
+		fs.print(TokenUnparseable, fs.f.ellipsis())
+	default:
+		fs.formatOutline(t)
+	}
+}
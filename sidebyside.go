@@ -0,0 +1,183 @@
+package jsoncolor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatSideBySide appends to dst a colorized side-by-side comparison
+// of the JSON-encoded a and b, displayed in two columns separated by
+// a gutter within a total of width characters, including f's Prefix,
+// which is written once at the start of each line.  Values that differ
+// between the documents, as identified by FormatDiffANSI, use
+// ChangedColor in both columns.  Lines common to both documents are
+// displayed alongside each other, with blank lines padding the
+// other column where lines were added or removed, and lines whose
+// text differs are marked in the gutter.  Lines too long for their
+// column are truncated.  If f's output is compact, DefaultIndent is
+// used instead.
+func (f *Formatter) FormatSideBySide(dst io.Writer, a, b []byte, width int) error {
+	const gutter = 3
+	col := (width - gutter - utf8.RuneCountInString(f.Prefix)) / 2
+	if col < 1 {
+		return fmt.Errorf("jsoncolor: width %d too small for side-by-side comparison", width)
+	}
+
+	g := f.dataOnly()
+	g.LeftMargin = 0
+	if len(g.Prefix) == 0 && len(g.Indent) == 0 {
+		g.Indent = DefaultIndent
+	}
+
+	left, err := g.diffLines(b, a)
+	if err != nil {
+		return err
+	}
+	right, err := g.diffLines(a, b)
+	if err != nil {
+		return err
+	}
+
+	fs := newFormatterState(f.dataOnly(), dst)
+	defer fs.closeColor()
+	ellipsis := emittedToken{TokenEllipsis, fs.styles[TokenEllipsis], f.ellipsis()}
+	// the gutter marking lines that differ is part of the space
+	// between the columns
+	changed := newStyle(f.changedColor())
+
+	for i, p := range alignLines(left, right) {
+		var l, r []emittedToken
+		if p[0] >= 0 {
+			l = left[p[0]]
+		}
+		if p[1] >= 0 {
+			r = right[p[1]]
+		}
+
+		if i > 0 {
+			fs.printSpace("\n", true)
+		}
+		fs.write(TokenSpace, style{}, f.Prefix)
+
+		l = truncateEmitted(l, col, ellipsis)
+		for _, t := range l {
+			fs.write(t.kind, t.st, t.s)
+		}
+		fs.printSpace(strings.Repeat(" ", col-emittedWidth(l)), true)
+		if p[0] >= 0 && p[1] >= 0 && emittedText(left[p[0]]) == emittedText(right[p[1]]) {
+			fs.printSpace(" │ ", true)
+		} else {
+			fs.printSpace(" ", true)
+			fs.write(TokenSpace, changed, "≠")
+			fs.printSpace(" ", true)
+		}
+		for _, t := range truncateEmitted(r, col, ellipsis) {
+			fs.write(t.kind, t.st, t.s)
+		}
+	}
+
+	return nil
+}
+
+// alignLines returns pairs of indexes of the lines of a and b to
+// display alongside each other, matching up the longest common
+// subsequence of their text.  Other lines are paired with the
+// unmatched lines between the same matches of the other document
+// where possible, and otherwise with -1 for a blank line.
+func alignLines(a, b [][]emittedToken) [][2]int {
+	n, m := len(a), len(b)
+	pa, pb := make([]string, n), make([]string, m)
+	for i := range a {
+		pa[i] = emittedText(a[i])
+	}
+	for j := range b {
+		pb[j] = emittedText(b[j])
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence
+	// of pa[i:] and pb[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if pa[i] == pb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var pairs [][2]int
+	var dels, ins []int
+	gap := func() {
+		for k := 0; k < len(dels) || k < len(ins); k++ {
+			p := [2]int{-1, -1}
+			if k < len(dels) {
+				p[0] = dels[k]
+			}
+			if k < len(ins) {
+				p[1] = ins[k]
+			}
+			pairs = append(pairs, p)
+		}
+		dels, ins = dels[:0], ins[:0]
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && pa[i] == pb[j]:
+			gap()
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case j >= m || i < n && lcs[i+1][j] >= lcs[i][j+1]:
+			dels = append(dels, i)
+			i++
+		default:
+			ins = append(ins, j)
+			j++
+		}
+	}
+	gap()
+
+	return pairs
+}
+
+// diffLines returns the tokens of each line of the colorized form of
+// cur relative to prev, see FormatDiffANSI, without f's Prefix.
+func (f *Formatter) diffLines(prev, cur []byte) ([][]emittedToken, error) {
+	fs, err := f.diffState(nil, prev)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := fs.emitted(cur)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := [][]emittedToken{nil}
+	for _, t := range tokens {
+		for i, s := range strings.Split(t.s, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			line := lines[len(lines)-1]
+			if len(line) == 0 && t.kind == TokenSpace && s == f.Prefix {
+				continue
+			}
+			if len(s) > 0 {
+				t.s = s
+				lines[len(lines)-1] = append(lines[len(lines)-1], t)
+			}
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,97 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatSideBySide(t *testing.T) {
+	a := `{"a":1,"b":[1,2],"c":"abcdefghij"}`
+	b := `{"a":2,"b":[1],"d":true,"c":"abcdefghij"}`
+	tests := []struct {
+		name  string
+		f     *Formatter
+		width int
+		want  string
+	}{
+		{"equal", &Formatter{}, 21, "" +
+			"{         │ {\n" +
+			"  \"a\": 1  │   \"a\": 1\n" +
+			"}         │ }"},
+		{"changes", &Formatter{}, 41, "" +
+			"{                   │ {\n" +
+			"  \"a\": 1,           ≠   \"a\": 2,\n" +
+			"  \"b\": [            │   \"b\": [\n" +
+			"    1,              ≠     1\n" +
+			"    2               ≠ \n" +
+			"  ],                │   ],\n" +
+			"                    ≠   \"d\": true,\n" +
+			"  \"c\": \"abcdefghij\" │   \"c\": \"abcdefghij\"\n" +
+			"}                   │ }"},
+		{"truncated", &Formatter{Indent: " "}, 23, "" +
+			"{          │ {\n" +
+			" \"a\": 1,   ≠  \"a\": 2,\n" +
+			" \"b\": [    │  \"b\": [\n" +
+			"  1,       ≠   1\n" +
+			"  2        ≠ \n" +
+			" ],        │  ],\n" +
+			"           ≠  \"d\": tru…\n" +
+			" \"c\": \"ab… │  \"c\": \"ab…\n" +
+			"}          │ }"},
+		{"margin and prefix", &Formatter{LeftMargin: 2, Prefix: "> "}, 15, "" +
+			"  > {     │ {\n" +
+			"  > \"a\":… ≠ \"a\":…\n" +
+			"  > \"b\":… │ \"b\":…\n" +
+			"  > 1,    ≠ 1\n" +
+			"  > 2     ≠ \n" +
+			"  > ],    │ ],\n" +
+			"  >       ≠ \"d\":…\n" +
+			"  > \"c\":… │ \"c\":…\n" +
+			"  > }     │ }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, r := a, b
+			if tt.name == "equal" {
+				l, r = `{"a":1}`, `{"a":1}`
+			}
+			buf := &bytes.Buffer{}
+			err := tt.f.FormatSideBySide(buf, []byte(l), []byte(r), tt.width)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := uncolored(buf.String()); got != tt.want {
+				t.Errorf("FormatSideBySide = %q, want %q", got, tt.want)
+			}
+			if _, active := render(buf.String()); active != "" {
+				t.Errorf("FormatSideBySide leaves %q in effect", active)
+			}
+		})
+	}
+}
+
+func TestFormatSideBySideChangedColor(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := (&Formatter{}).FormatSideBySide(buf, []byte(`[1,2]`), []byte(`[1,3]`), 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := colorSpecOf(DefaultChangedColor)
+	cells, _ := render(buf.String())
+	var got []byte
+	for _, c := range cells {
+		if c.spec == changed {
+			got = append(got, c.b)
+		}
+	}
+	if want := "2≠3"; string(got) != want {
+		t.Errorf("FormatSideBySide uses ChangedColor for %q, want %q", got, want)
+	}
+}
+
+func TestFormatSideBySideWidth(t *testing.T) {
+	err := (&Formatter{}).FormatSideBySide(&bytes.Buffer{}, []byte(`1`), []byte(`1`), 4)
+	if err == nil {
+		t.Error("FormatSideBySide with width 4 succeeded")
+	}
+}
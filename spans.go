@@ -0,0 +1,143 @@
+package jsoncolor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Span is a run of colorized output text sharing a single color.
+type Span struct {
+	// Text is the output text of the span.
+	Text string
+	// Color is the color of Text.
+	Color ColorSpec
+}
+
+// ColorSpec describes a color independently of any particular
+// rendering, in terms of the SGR attributes the color would emit on
+// a terminal.
+type ColorSpec struct {
+	// Foreground is the foreground color, such as color.FgRed
+	// or color.FgHiRed, or zero for the default foreground.
+	Foreground color.Attribute
+	// Background is the background color, such as color.BgRed
+	// or color.BgHiRed, or zero for the default background.
+	Background color.Attribute
+	// Bold specifies bold or increased intensity, SGR 1.
+	Bold bool
+	// Faint specifies faint or decreased intensity, SGR 2.
+	Faint bool
+	// Italic specifies italics, SGR 3.
+	Italic bool
+	// Underline specifies underlining, SGR 4.
+	Underline bool
+	// BlinkSlow specifies slow blinking, SGR 5.
+	BlinkSlow bool
+	// BlinkRapid specifies rapid blinking, SGR 6.
+	BlinkRapid bool
+	// ReverseVideo specifies swapped foreground and background
+	// colors, SGR 7.
+	ReverseVideo bool
+	// Concealed specifies hidden text, SGR 8.
+	Concealed bool
+	// CrossedOut specifies struck-through text, SGR 9.
+	CrossedOut bool
+}
+
+// FormatSpans is like Format but returns the colorized form of the
+// JSON-encoded src as a sequence of spans rather than as text
+// containing color escape sequences, for rendering by user
+// interfaces other than terminals.  Adjacent tokens sharing the
+// same color are combined into a single span.  Colors created
+// using color.New are described even if color output has been
+// disabled by color.NoColor.
+func (f *Formatter) FormatSpans(src []byte) ([]Span, error) {
+	var spans []Span
+
+	fs := newFormatterState(f, nil)
+	fs.emit = func(kind TokenKind, st style, s string) {
+		var spec ColorSpec
+		if st.color != nil {
+			spec = colorSpecOf(st.color)
+		}
+		if n := len(spans); n > 0 && spans[n-1].Color == spec {
+			spans[n-1].Text += s
+			return
+		}
+		spans = append(spans, Span{Text: s, Color: spec})
+	}
+
+	err := fs.format(nil, src, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return spans, nil
+}
+
+// colorSpecOf returns the ColorSpec describing the escape sequences
+// c emits before colorized text.
+func colorSpecOf(c SprintfFuncer) ColorSpec {
+	if x, ok := c.(*color.Color); ok {
+		y := *x
+		y.EnableColor()
+		c = &y
+	}
+
+	var spec ColorSpec
+
+	s := c.SprintfFunc()("%s", "x")
+	for strings.HasPrefix(s, "\x1b[") {
+		end := strings.IndexByte(s, 'm')
+		if end < 0 {
+			break
+		}
+		params := strings.Split(s[2:end], ";")
+		s = s[end+1:]
+
+		for i := 0; i < len(params); i++ {
+			n, _ := strconv.Atoi(params[i])
+			a := color.Attribute(n)
+			switch {
+			case a == color.Reset:
+				spec = ColorSpec{}
+			case a == color.Bold:
+				spec.Bold = true
+			case a == color.Faint:
+				spec.Faint = true
+			case a == color.Italic:
+				spec.Italic = true
+			case a == color.Underline:
+				spec.Underline = true
+			case a == color.BlinkSlow:
+				spec.BlinkSlow = true
+			case a == color.BlinkRapid:
+				spec.BlinkRapid = true
+			case a == color.ReverseVideo:
+				spec.ReverseVideo = true
+			case a == color.Concealed:
+				spec.Concealed = true
+			case a == color.CrossedOut:
+				spec.CrossedOut = true
+			case a >= color.FgBlack && a <= color.FgWhite,
+				a >= color.FgHiBlack && a <= color.FgHiWhite:
+				spec.Foreground = a
+			case a >= color.BgBlack && a <= color.BgWhite,
+				a >= color.BgHiBlack && a <= color.BgHiWhite:
+				spec.Background = a
+			case n == 38 || n == 48:
+				// skip extended 256 and 24-bit colors
+				// which cannot be described
+				if i+1 < len(params) && params[i+1] == "5" {
+					i += 2
+				} else if i+1 < len(params) && params[i+1] == "2" {
+					i += 4
+				}
+			}
+		}
+	}
+
+	return spec
+}
@@ -0,0 +1,79 @@
+package jsoncolor
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatSpans(t *testing.T) {
+	tests := []struct {
+		name string
+		f    *Formatter
+		src  string
+	}{
+		{"compact", &Formatter{}, sample},
+		{"indent", &Formatter{Indent: "  "}, sample},
+		{"prefix", &Formatter{Prefix: "> ", Indent: "\t"}, sample},
+		{"custom colors", &Formatter{Indent: "  ", StringColor: color.New(color.FgRed, color.Underline), NullColor: color.New(color.BgCyan, color.Italic)}, sample},
+		{"scalar", &Formatter{}, `"hello"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans, err := tt.f.FormatSpans([]byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cells, _ := render(formatString(t, tt.f, tt.src))
+			offset := 0
+			for i, s := range spans {
+				if len(s.Text) == 0 {
+					t.Errorf("span %d is empty", i)
+				}
+				if i > 0 && spans[i-1].Color == s.Color {
+					t.Errorf("spans %d and %d are both %+v", i-1, i, s.Color)
+				}
+				for j := 0; j < len(s.Text); j++ {
+					k := offset + j
+					if k >= len(cells) {
+						t.Fatalf("span %d %q extends past the end of the output", i, s.Text)
+					}
+					if cells[k].b != s.Text[j] || cells[k].spec != s.Color {
+						t.Fatalf("byte %d of span %d %q is %q displayed as %+v, want %q displayed as %+v",
+							j, i, s.Text, cells[k].b, cells[k].spec, s.Text[j], s.Color)
+					}
+				}
+				offset += len(s.Text)
+			}
+			if offset != len(cells) {
+				t.Errorf("spans are %d bytes long, want %d", offset, len(cells))
+			}
+		})
+	}
+
+	_, err := (&Formatter{}).FormatSpans([]byte(`{"a":`))
+	if err == nil {
+		t.Error("FormatSpans of truncated input succeeded")
+	}
+}
+
+func TestColorSpecOf(t *testing.T) {
+	disabled := color.New(color.FgRed)
+	disabled.DisableColor()
+	tests := []struct {
+		c    SprintfFuncer
+		want ColorSpec
+	}{
+		{color.New(), ColorSpec{}},
+		{color.New(color.FgBlue, color.Bold), ColorSpec{Foreground: color.FgBlue, Bold: true}},
+		{color.New(color.FgHiRed, color.BgHiWhite, color.Underline), ColorSpec{Foreground: color.FgHiRed, Background: color.BgHiWhite, Underline: true}},
+		{color.New(color.Faint, color.CrossedOut), ColorSpec{Faint: true, CrossedOut: true}},
+		{disabled, ColorSpec{Foreground: color.FgRed}},
+		{escapes("\x1b[38;5;200;1m"), ColorSpec{Bold: true}},
+	}
+	for _, tt := range tests {
+		if got := colorSpecOf(tt.c); got != tt.want {
+			t.Errorf("colorSpecOf(%q) = %+v, want %+v", tt.c.SprintfFunc()("%s", "x"), got, tt.want)
+		}
+	}
+}
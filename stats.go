@@ -0,0 +1,103 @@
+package jsoncolor
+
+import "io"
+
+// Stats describes the output written by FormatWithStats.
+type Stats struct {
+	// Tokens is the number of tokens of each kind written, such
+	// as the quotes and text of strings and runs of whitespace.
+	Tokens map[TokenKind]int
+	// ColorSwitches is the number of tokens whose color differs
+	// from that of the preceding token.
+	ColorSwitches int
+	// ANSIBytes is the number of bytes of color escape sequences
+	// written.
+	ANSIBytes int
+	// MaxDepth is the maximum nesting depth of objects and arrays,
+	// which is zero for a scalar value.
+	MaxDepth int
+}
+
+// FormatWithStats is like Format but additionally returns statistics
+// about the output, for tuning colors and measuring the overhead of
+// color escape sequences.
+func (f *Formatter) FormatWithStats(dst io.Writer, src []byte) (Stats, error) {
+	stats := Stats{Tokens: map[TokenKind]int{}}
+
+	w := &statsWriter{w: dst}
+	fs := newFormatterState(f, w)
+
+	emit := fs.emit
+	started, color := false, ""
+	fs.emit = func(kind TokenKind, st style, s string) {
+		w.first = nil
+		emit(kind, st, s)
+
+		stats.Tokens[kind]++
+		stats.ANSIBytes += w.n - len(s)
+		w.n = 0
+		if depth := len(fs.frames) - 1; depth > stats.MaxDepth {
+			stats.MaxDepth = depth
+		}
+		c := st.prefix
+		if !st.affixed {
+			c = escapePrefix(w.first)
+		}
+		if started && c != color {
+			stats.ColorSwitches++
+		}
+		started, color = true, c
+	}
+
+	err := fs.format(w, src, false)
+	// the escape sequence closing the last color
+	stats.ANSIBytes += w.n
+	return stats, err
+}
+
+// statsWriter is a writer counting the bytes written to w by each
+// token and retaining the first write.
+type statsWriter struct {
+	w     io.Writer
+	n     int
+	first []byte
+}
+
+func (w *statsWriter) Write(p []byte) (int, error) {
+	if w.first == nil {
+		w.first = append([]byte(nil), p...)
+	}
+	n, err := w.w.Write(p)
+	w.n += n
+	return n, err
+}
+
+// escapePrefix returns the escape sequences at the start of b.
+func escapePrefix(b []byte) string {
+	s := string(b)
+	i := 0
+	for i < len(s) {
+		l := ansiLen(s[i:])
+		if l == 0 {
+			break
+		}
+		i += l
+	}
+	return s[:i]
+}
+
+// ansiLen returns the length of the ANSI escape sequence at the start
+// of s, or 0 if s does not begin with one.
+func ansiLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' || s[1] != '[' {
+		return 0
+	}
+	i := 2
+	for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+		i++
+	}
+	if i < len(s) {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,118 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatWithStats(t *testing.T) {
+	src := `{"a":[1,"x",true],"b":{"c":null}}`
+	f := &Formatter{}
+	buf := &bytes.Buffer{}
+	stats, err := f.FormatWithStats(buf, []byte(src))
+	if err != nil {
+		t.Fatalf("FormatWithStats(%s): %v", src, err)
+	}
+	if got, want := buf.String(), formatString(t, f, src); got != want {
+		t.Errorf("FormatWithStats(%s) = %q, want %q", src, got, want)
+	}
+	want := Stats{
+		Tokens: map[TokenKind]int{
+			TokenObject:      4,
+			TokenFieldQuote:  6,
+			TokenField:       3,
+			TokenColon:       3,
+			TokenArray:       2,
+			TokenNumber:      1,
+			TokenComma:       3,
+			TokenStringQuote: 2,
+			TokenString:      1,
+			TokenTrue:        1,
+			TokenNull:        1,
+		},
+		// field names share the color of their quotes, and
+		// delimiters and colons are bold
+		ColorSwitches: 14,
+		ANSIBytes:     buf.Len() - len(src),
+		MaxDepth:      2,
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("FormatWithStats(%s) = %+v, want %+v", src, stats, want)
+	}
+
+	// with every token in the same color, only the first is
+	// preceded by a switch
+	same := color.New(color.FgRed)
+	f = &Formatter{
+		SpaceColor: same, CommaColor: same, ColonColor: same,
+		ObjectColor: same, ArrayColor: same, FieldQuoteColor: same,
+		FieldColor: same, StringQuoteColor: same, StringColor: same,
+		TrueColor: same, FalseColor: same, NumberColor: same, NullColor: same,
+	}
+	stats, err = f.FormatWithStats(&bytes.Buffer{}, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ColorSwitches != 0 {
+		t.Errorf("FormatWithStats(%s) in one color counted %d switches, want 0", src, stats.ColorSwitches)
+	}
+
+	// alternating colors switch on every token
+	f = &Formatter{ArrayColor: color.New(color.FgRed), NumberColor: color.New(color.FgBlue), CommaColor: color.New(color.FgGreen)}
+	stats, err = f.FormatWithStats(&bytes.Buffer{}, []byte(`[1,2,3]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ColorSwitches != 6 {
+		t.Errorf("FormatWithStats([1,2,3]) counted %d switches, want 6", stats.ColorSwitches)
+	}
+
+	// plain output has no overhead
+	buf.Reset()
+	stats, err = f.Plain().FormatWithStats(buf, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.ANSIBytes != 0 || stats.ColorSwitches != 0 || buf.String() != src {
+		t.Errorf("Plain().FormatWithStats(%s) = %q, %+v, want no overhead", src, buf.String(), stats)
+	}
+
+	stats, err = f.FormatWithStats(&bytes.Buffer{}, []byte(`"x"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.MaxDepth != 0 {
+		t.Errorf("FormatWithStats(\"x\") MaxDepth = %d, want 0", stats.MaxDepth)
+	}
+
+	if _, err = f.FormatWithStats(&bytes.Buffer{}, []byte(`[1,`)); err == nil {
+		t.Error("FormatWithStats([1,) succeeded, want error")
+	}
+}
+
+func TestANSILen(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"", 0},
+		{"x", 0},
+		{"\x1b", 0},
+		{"\x1b[0m", 4},
+		{"\x1b[38;5;208mx", 11},
+		{"\x1b[mx", 3},
+		{"\x1b[1", 3},
+		{"x\x1b[0m", 0},
+	}
+	for _, tt := range tests {
+		if got := ansiLen(tt.s); got != tt.want {
+			t.Errorf("ansiLen(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+	if got, want := escapePrefix([]byte("\x1b[1m\x1b[31mx\x1b[0m")), "\x1b[1m\x1b[31m"; got != want {
+		t.Errorf("escapePrefix = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// rootSummary accumulates the description of the outermost value
+// displayed by ShowSummary.
+type rootSummary struct {
+	kind     string
+	children int
+	size     int64
+}
+
+// valueKind returns the name of the type of the value beginning with
+// the token t.
+func valueKind(t json.Token) string {
+	switch x := t.(type) {
+	case json.Delim:
+		if x == json.Delim('{') {
+			return "object"
+		}
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return "value"
+}
+
+// String returns a description such as "object, 5 fields, 1.2 KiB".
+func (s *rootSummary) String() string {
+	parts := []string{s.kind}
+	switch s.kind {
+	case "object", "array":
+		noun := "field"
+		if s.kind == "array" {
+			noun = "element"
+		}
+		if s.children != 1 {
+			noun += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", s.children, noun))
+	}
+	if s.size >= 0 {
+		size := formatBytes(float64(s.size))
+		if len(size) == 0 {
+			size = fmt.Sprintf("%d B", s.size)
+		}
+		parts = append(parts, size)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printSummary prints a line describing the outermost value.
+func (fs *formatterState) printSummary() {
+	if fs.summary == nil {
+		return
+	}
+	fs.write(TokenSpace, fs.styles[TokenSpace], "\n")
+	fs.write(TokenSpace, fs.dimmed, "("+fs.summary.String()+")")
+}
+
+// printCount prints the comment following the opening delimiter open
+// of a container read from r giving its number of fields or
+// elements, see ShowContainerCounts.
+func (fs *formatterState) printCount(r *replayReader, open json.Delim) error {
+	children, _, err := measureContainer(r, open)
+	if err != nil {
+		return err
+	}
+	noun := "element"
+	if open == json.Delim('{') {
+		noun = "field"
+	}
+	if children != 1 {
+		noun += "s"
+	}
+	fs.write(TokenComment, fs.dimmed, fmt.Sprintf(" // %d %s", children, noun))
+	return nil
+}
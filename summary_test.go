@@ -0,0 +1,32 @@
+package jsoncolor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShowSummary(t *testing.T) {
+	big := `["` + strings.Repeat("x", 2000) + `"]`
+	tests := []struct {
+		src, want string
+	}{
+		{`{"a":1,"b":[1,2],"c":{}}`, "(object, 3 fields, 24 B)"},
+		{`[1]`, "(array, 1 element, 3 B)"},
+		{`[]`, "(array, 0 elements, 2 B)"},
+		{`"x"`, "(string, 3 B)"},
+		{`null`, "(null, 4 B)"},
+		{big, "(array, 1 element, 2.0 KiB)"},
+	}
+	for _, tt := range tests {
+		got := uncolored(formatString(t, &Formatter{ShowSummary: true}, tt.src))
+		if want := tt.src + "\n" + tt.want; got != want {
+			t.Errorf("Format(%s) = %q, want %q", tt.src, got, want)
+		}
+	}
+
+	// elements omitted from the output are counted
+	got := uncolored(formatString(t, &Formatter{ShowSummary: true, MaxArrayElements: 1}, `[1,2,3]`))
+	if want := "[1,… (2 more)]\n(array, 3 elements, 7 B)"; got != want {
+		t.Errorf("Format with MaxArrayElements = %q, want %q", got, want)
+	}
+}
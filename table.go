@@ -0,0 +1,203 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FormatTable appends to dst a colorized table of the JSON-encoded
+// src, which must be an array of objects that all have the same set
+// of field names.  The table has a header row containing the field
+// names in the order they appear in the first object followed by
+// one row per object, with each column padded to align.  Each cell
+// contains the compact colorized form of the corresponding field
+// value.  Headers and cells wider than the column's maximum width
+// in f's TableColumnWidths field are truncated.  f's Indent field is
+// ignored.
+func (f *Formatter) FormatTable(dst io.Writer, src []byte) error {
+	keys, rows, err := decodeTable(src)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	g := f.dataOnly()
+	g.setIndent("", "")
+	g.FocusPath = ""
+	g.LeftMargin = 0
+
+	fs := newFormatterState(f.dataOnly(), dst)
+	defer fs.closeColor()
+	space := fs.styles[TokenSpace]
+	ellipsis := emittedToken{TokenEllipsis, fs.styles[TokenEllipsis], f.ellipsis()}
+
+	truncate := func(tokens []emittedToken, k string) []emittedToken {
+		if w := f.TableColumnWidths[k]; w > 0 {
+			return truncateEmitted(tokens, w, ellipsis)
+		}
+		return tokens
+	}
+
+	header := make([][]emittedToken, len(keys))
+	widths := make([]int, len(keys))
+	for i, k := range keys {
+		header[i] = truncate([]emittedToken{{TokenField, fs.styles[TokenField], k}}, k)
+		widths[i] = emittedWidth(header[i])
+	}
+
+	// intWidths are the widths to which the integer parts of the
+	// numbers of columns of numbers are padded, see AlignNumbers.
+	intWidths := make([]int, len(keys))
+	if f.AlignNumbers {
+		for j, k := range keys {
+			for _, row := range rows {
+				n, ok := tableNumber(row[k])
+				if !ok {
+					intWidths[j] = 0
+					break
+				}
+				if w := f.integerWidth(n); w > intWidths[j] {
+					intWidths[j] = w
+				}
+			}
+		}
+	}
+
+	cells := make([][][]emittedToken, len(rows))
+	for i, row := range rows {
+		cells[i] = make([][]emittedToken, len(keys))
+		for j, k := range keys {
+			var cell []emittedToken
+			if intWidths[j] > 0 {
+				n, _ := tableNumber(row[k])
+				if pad := intWidths[j] - f.integerWidth(n); pad > 0 {
+					cell = append(cell, emittedToken{TokenSpace, space, strings.Repeat(" ", pad)})
+				}
+			}
+			tokens, err := newFormatterState(g, nil).emitted(row[k])
+			if err != nil {
+				return err
+			}
+			cells[i][j] = truncate(append(cell, tokens...), k)
+			if w := emittedWidth(cells[i][j]); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+
+	printRow := func(row [][]emittedToken) {
+		fs.write(TokenSpace, style{}, f.Prefix)
+		for j, cell := range row {
+			for _, t := range cell {
+				fs.write(t.kind, t.st, t.s)
+			}
+			if j == len(row)-1 {
+				break
+			}
+			pad := widths[j] - emittedWidth(cell) + 2
+			fs.printSpace(strings.Repeat(" ", pad), true)
+		}
+	}
+
+	printRow(header)
+	for _, row := range cells {
+		fs.printSpace("\n", true)
+		printRow(row)
+	}
+
+	return nil
+}
+
+// decodeTable decodes src as an array of objects that all have the
+// same set of field names, returning the field names in the order
+// they appear in the first object and the raw field values of each
+// object.
+func decodeTable(src []byte) ([]string, []map[string]json.RawMessage, error) {
+	errNotTable := fmt.Errorf("jsoncolor: cannot format as table, input is not an array of objects with uniform fields")
+
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+
+	expectDelim := func(d json.Delim) error {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if x, ok := t.(json.Delim); !ok || x != d {
+			return errNotTable
+		}
+		return nil
+	}
+
+	err := expectDelim('[')
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var keys []string
+	var rows []map[string]json.RawMessage
+
+	for dec.More() {
+		err = expectDelim('{')
+		if err != nil {
+			return nil, nil, err
+		}
+		row := map[string]json.RawMessage{}
+		var rowKeys []string
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			k := t.(string)
+			var v json.RawMessage
+			err = dec.Decode(&v)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, ok := row[k]; !ok {
+				rowKeys = append(rowKeys, k)
+			}
+			row[k] = v
+		}
+		err = expectDelim('}')
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if rows == nil {
+			keys = rowKeys
+		} else if len(rowKeys) != len(keys) {
+			return nil, nil, errNotTable
+		} else {
+			for _, k := range keys {
+				if _, ok := row[k]; !ok {
+					return nil, nil, errNotTable
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	err = expectDelim(']')
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keys, rows, nil
+}
+
+// tableNumber returns the number encoded by the raw value v, if it
+// is a number.
+func tableNumber(v json.RawMessage) (string, bool) {
+	s := string(bytes.TrimSpace(v))
+	if len(s) == 0 || s[0] != '-' && (s[0] < '0' || s[0] > '9') {
+		return "", false
+	}
+	return s, true
+}
@@ -0,0 +1,91 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"html"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatTable(t *testing.T) {
+	src := `[{"id":1,"name":"alpha","tags":["a"]},{"id":200,"name":"b","tags":[]},{"id":-3.5,"name":null,"tags":{"x":1}}]`
+	tests := []struct {
+		name string
+		f    *Formatter
+		src  string
+		want string
+	}{
+		{"empty", &Formatter{}, `[]`, ``},
+		{"table", &Formatter{Indent: "  "}, src,
+			"id    name     tags\n" +
+				"1     \"alpha\"  [\"a\"]\n" +
+				"200   \"b\"      []\n" +
+				"-3.5  null     {\"x\":1}"},
+		{"align numbers", &Formatter{AlignNumbers: true}, src,
+			"id     name     tags\n" +
+				"  1    \"alpha\"  [\"a\"]\n" +
+				"200    \"b\"      []\n" +
+				" -3.5  null     {\"x\":1}"},
+		{"align numbers mixed", &Formatter{AlignNumbers: true}, `[{"a":1,"b":2.5},{"a":"x","b":10}]`,
+			"a    b\n" +
+				"1     2.5\n" +
+				"\"x\"  10"},
+		{"column widths", &Formatter{TableColumnWidths: map[string]int{"name": 4, "tags": 3}}, src,
+			"id    name  ta…\n" +
+				"1     \"al…  [\"…\n" +
+				"200   \"b\"   []\n" +
+				"-3.5  null  {\"…"},
+		{"column widths ellipsis", &Formatter{TableColumnWidths: map[string]int{"name": 4, "id": 0}, Ellipsis: "~"}, `[{"id":12345,"name":"αβγδε"}]`,
+			"id     name\n" +
+				"12345  \"αβ~"},
+		{"prefix", &Formatter{Prefix: "| "}, `[{"a":1},{"a":22}]`,
+			"| a\n| 1\n| 22"},
+		{"output escape", &Formatter{OutputEscape: html.EscapeString}, `[{"<a>":"&"}]`,
+			"&lt;a&gt;\n&#34;&amp;&#34;"},
+		{"margin and prefix", &Formatter{LeftMargin: 2, Prefix: "| "}, `[{"a":1},{"a":22}]`,
+			"  | a\n  | 1\n  | 22"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := tt.f.FormatTable(buf, []byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := uncolored(buf.String()); got != tt.want {
+				t.Errorf("FormatTable(%s) = %q, want %q", tt.src, got, tt.want)
+			}
+			if _, active := render(buf.String()); active != "" {
+				t.Errorf("FormatTable(%s) leaves %q in effect", tt.src, active)
+			}
+		})
+	}
+}
+
+func TestFormatTableColors(t *testing.T) {
+	f := &Formatter{
+		FieldColor:  color.New(color.FgBlue),
+		NumberColor: color.New(color.FgRed),
+	}
+	buf := &bytes.Buffer{}
+	err := f.FormatTable(buf, []byte(`[{"id":1}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\x1b[34mid", "\x1b[31m1"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("FormatTable = %q, want it to contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestFormatTableErrors(t *testing.T) {
+	for _, src := range []string{`{}`, `[1]`, `[{"a":1},{"b":1}]`, `[{"a":1},{"a":1,"b":2}]`, `[{"a":1}`} {
+		err := (&Formatter{}).FormatTable(&bytes.Buffer{}, []byte(src))
+		if err == nil {
+			t.Errorf("FormatTable(%s) succeeded", src)
+		}
+	}
+}
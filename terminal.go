@@ -0,0 +1,59 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"unicode/utf8"
+)
+
+// DefaultTerminalWidth is the width in columns assumed by
+// FormatForTerminal if the width of the terminal cannot be
+// determined.
+const DefaultTerminalWidth = 80
+
+// FormatForTerminal is like Format but returns the colorized form of
+// the JSON-encoded src laid out to fit the width of the controlling
+// terminal.  Objects and arrays whose compact form fits on a single
+// line at their indentation are displayed inline, and the others
+// are expanded across multiple lines.  If f's ShouldExpand field is
+// non-nil it is used instead.  If f's Prefix and Indent fields are
+// both empty, an Indent of two spaces is used.  If the width of the
+// terminal cannot be determined, for example because the process
+// has no controlling terminal, the width given by the COLUMNS
+// environment variable is used, or DefaultTerminalWidth if it is
+// unset.
+func (f *Formatter) FormatForTerminal(src []byte) ([]byte, error) {
+	width, ok := terminalWidth()
+	if !ok {
+		width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+		if width <= 0 {
+			width = DefaultTerminalWidth
+		}
+	}
+
+	g := f.clone()
+	if len(g.Prefix) == 0 && len(g.Indent) == 0 {
+		g.Indent = "  "
+	}
+	if g.ShouldExpand == nil {
+		margin := g.LeftMargin + utf8.RuneCountInString(g.Prefix)
+		indent := utf8.RuneCountInString(g.Indent)
+		g.ShouldExpand = func(path []string, kind byte, childCount int, compactWidth int) bool {
+			w := margin + len(path)*indent + compactWidth + len(",")
+			if len(path) > 0 {
+				// the field name, which may instead be
+				// an array index
+				w += utf8.RuneCountInString(path[len(path)-1]) + len(`"": `)
+			}
+			return w > width
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err := g.Format(buf, src)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,10 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package jsoncolor
+
+// terminalWidth returns the width in columns of the controlling
+// terminal, which cannot be determined on this platform.
+func terminalWidth() (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,42 @@
+package jsoncolor
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFormatForTerminal(t *testing.T) {
+	if _, ok := terminalWidth(); ok {
+		t.Skip("the width of the controlling terminal is used")
+	}
+	columns, set := os.LookupEnv("COLUMNS")
+	defer func() {
+		if set {
+			os.Setenv("COLUMNS", columns)
+		} else {
+			os.Unsetenv("COLUMNS")
+		}
+	}()
+
+	src := `{"short":[1,2,3],"long":["aaaaaaaaaa","bbbbbbbbbb","cccccccccc"],"obj":{"x":{"y":1}}}`
+	tests := []struct {
+		columns string
+		want    string
+	}{
+		{"200", src},
+		{"30", "{\n  \"short\": [1,2,3],\n  \"long\": [\n    \"aaaaaaaaaa\",\n    \"bbbbbbbbbb\",\n    \"cccccccccc\"\n  ],\n  \"obj\": {\"x\":{\"y\":1}}\n}"},
+		// DefaultTerminalWidth
+		{"", "{\n  \"short\": [1,2,3],\n  \"long\": [\"aaaaaaaaaa\",\"bbbbbbbbbb\",\"cccccccccc\"],\n  \"obj\": {\"x\":{\"y\":1}}\n}"},
+	}
+	for _, tt := range tests {
+		os.Setenv("COLUMNS", tt.columns)
+		got, err := (&Formatter{}).FormatForTerminal([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uncolored(string(got)) != tt.want {
+			t.Errorf("FormatForTerminal with COLUMNS=%s = %q, want %q", tt.columns, uncolored(string(got)), tt.want)
+		}
+	}
+
+}
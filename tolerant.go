@@ -0,0 +1,283 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// tokenReader is implemented by any value that can produce a stream
+// of JSON tokens in the manner of json.Decoder's Token and More
+// methods.
+type tokenReader interface {
+	Token() (json.Token, error)
+	More() bool
+}
+
+// inputOffsetter is implemented by tokenReaders such as json.Decoder
+// that report their current offset in the input.  json.Decoder's
+// InputOffset method was added in Go 1.14.
+type inputOffsetter interface {
+	InputOffset() int64
+}
+
+// tolerantDecoder is a tokenReader like json.Decoder that also
+// accepts the non-standard number literals NaN, Infinity and
+// -Infinity, returning them as json.Number values.  If recover is
+// true, invalid values and field names are returned as unparseable
+// tokens rather than causing an error.
+type tolerantDecoder struct {
+	data    []byte
+	pos     int
+	state   int
+	stack   []int
+	recover bool
+}
+
+// unparseable is a token containing the raw bytes of an invalid
+// value or field name.
+type unparseable []byte
+
+// The states of a tolerantDecoder, mirroring those of json.Decoder.
+const (
+	tokenTopValue = iota
+	tokenArrayStart
+	tokenArrayValue
+	tokenArrayComma
+	tokenObjectStart
+	tokenObjectKey
+	tokenObjectColon
+	tokenObjectValue
+	tokenObjectComma
+)
+
+// nonStandardNumbers are the non-standard number literals accepted
+// by a tolerantDecoder.
+var nonStandardNumbers = []string{"NaN", "Infinity", "-Infinity"}
+
+func newTolerantDecoder(data []byte, recover bool) *tolerantDecoder {
+	return &tolerantDecoder{data: data, recover: recover}
+}
+
+func (d *tolerantDecoder) peek() (byte, error) {
+	for ; d.pos < len(d.data); d.pos++ {
+		switch c := d.data[d.pos]; c {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return c, nil
+		}
+	}
+	if d.state != tokenTopValue || len(d.stack) > 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return 0, io.EOF
+}
+
+func (d *tolerantDecoder) valueAllowed() bool {
+	switch d.state {
+	case tokenTopValue, tokenArrayStart, tokenArrayValue, tokenObjectValue:
+		return true
+	}
+	return false
+}
+
+func (d *tolerantDecoder) valueEnd() {
+	switch d.state {
+	case tokenArrayStart, tokenArrayValue:
+		d.state = tokenArrayComma
+	case tokenObjectValue:
+		d.state = tokenObjectComma
+	}
+}
+
+func (d *tolerantDecoder) push(state int) {
+	d.stack = append(d.stack, d.state)
+	d.state = state
+}
+
+func (d *tolerantDecoder) pop() {
+	d.state = d.stack[len(d.stack)-1]
+	d.stack = d.stack[:len(d.stack)-1]
+	d.valueEnd()
+}
+
+func (d *tolerantDecoder) syntaxError(c byte) error {
+	return fmt.Errorf("jsoncolor: invalid character %q at offset %d", c, d.pos)
+}
+
+// Token is like json.Decoder's Token.
+func (d *tolerantDecoder) Token() (json.Token, error) {
+	for {
+		c, err := d.peek()
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case '[', '{':
+			if !d.valueAllowed() {
+				return nil, d.syntaxError(c)
+			}
+			d.pos++
+			if c == '[' {
+				d.push(tokenArrayStart)
+			} else {
+				d.push(tokenObjectStart)
+			}
+			return json.Delim(c), nil
+		case ']':
+			if d.state != tokenArrayStart && d.state != tokenArrayComma {
+				return nil, d.syntaxError(c)
+			}
+			d.pos++
+			d.pop()
+			return json.Delim(c), nil
+		case '}':
+			if d.state != tokenObjectStart && d.state != tokenObjectComma {
+				return nil, d.syntaxError(c)
+			}
+			d.pos++
+			d.pop()
+			return json.Delim(c), nil
+		case ':':
+			if d.state != tokenObjectColon {
+				return nil, d.syntaxError(c)
+			}
+			d.pos++
+			d.state = tokenObjectValue
+		case ',':
+			switch d.state {
+			case tokenArrayComma:
+				d.state = tokenArrayValue
+			case tokenObjectComma:
+				d.state = tokenObjectKey
+			default:
+				return nil, d.syntaxError(c)
+			}
+			d.pos++
+		case '"':
+			key := d.state == tokenObjectStart || d.state == tokenObjectKey
+			if !key && !d.valueAllowed() {
+				return nil, d.syntaxError(c)
+			}
+			start := d.pos
+			var t json.Token
+			t, err := d.readString()
+			if _, ok := err.(*json.SyntaxError); ok && d.recover {
+				t, err = unparseable(d.data[start:d.pos]), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if key {
+				d.state = tokenObjectColon
+			} else {
+				d.valueEnd()
+			}
+			return t, nil
+		default:
+			if d.recover && (d.state == tokenObjectStart || d.state == tokenObjectKey) {
+				d.state = tokenObjectColon
+				return d.readUnparseable(d.pos), nil
+			}
+			if !d.valueAllowed() {
+				return nil, d.syntaxError(c)
+			}
+			t, err := d.readLiteral()
+			if err != nil {
+				return nil, err
+			}
+			d.valueEnd()
+			return t, nil
+		}
+	}
+}
+
+// More is like json.Decoder's More.
+func (d *tolerantDecoder) More() bool {
+	c, err := d.peek()
+	return err == nil && c != ']' && c != '}'
+}
+
+// InputOffset is like json.Decoder's InputOffset.
+func (d *tolerantDecoder) InputOffset() int64 {
+	return int64(d.pos)
+}
+
+func (d *tolerantDecoder) readString() (string, error) {
+	start := d.pos
+	for i := start + 1; i < len(d.data); i++ {
+		switch d.data[i] {
+		case '\\':
+			i++
+		case '"':
+			var s string
+			d.pos = i + 1
+			err := json.Unmarshal(d.data[start:i+1], &s)
+			if err != nil {
+				return "", err
+			}
+			return s, nil
+		}
+	}
+	d.pos = len(d.data)
+	return "", io.ErrUnexpectedEOF
+}
+
+func isLiteralByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '+' || c == '.'
+}
+
+func (d *tolerantDecoder) readLiteral() (json.Token, error) {
+	start := d.pos
+	end := start
+	for end < len(d.data) && isLiteralByte(d.data[end]) {
+		end++
+	}
+	lit := string(d.data[start:end])
+	if len(lit) == 0 {
+		if d.recover {
+			return d.readUnparseable(start), nil
+		}
+		return nil, d.syntaxError(d.data[start])
+	}
+	d.pos = end
+	switch lit {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	for _, n := range nonStandardNumbers {
+		if lit == n {
+			return json.Number(lit), nil
+		}
+	}
+	if c := lit[0]; (c == '-' || c >= '0' && c <= '9') && json.Valid(d.data[start:end]) {
+		return json.Number(lit), nil
+	}
+	if d.recover {
+		return d.readUnparseable(start), nil
+	}
+	d.pos = start
+	return nil, fmt.Errorf("jsoncolor: invalid literal %q at offset %d", lit, start)
+}
+
+// readUnparseable returns an unparseable token containing the bytes
+// from start up to the next whitespace, comma, colon or closing
+// delimiter.
+func (d *tolerantDecoder) readUnparseable(start int) unparseable {
+	end := start + 1
+	for end < len(d.data) {
+		switch d.data[end] {
+		case ' ', '\t', '\r', '\n', ',', ':', ']', '}':
+			d.pos = end
+			return unparseable(d.data[start:end])
+		}
+		end++
+	}
+	d.pos = end
+	return unparseable(d.data[start:end])
+}
@@ -0,0 +1,91 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestTolerant(t *testing.T) {
+	f := &Formatter{Tolerant: true, NumberColor: color.New(color.FgRed)}
+	for _, n := range nonStandardNumbers {
+		for _, src := range []string{n, "[" + n + "]", `{"a":` + n + `}`, `[1,` + n + `,"x"]`} {
+			got := formatString(t, f, src)
+			if s := uncolored(got); s != src {
+				t.Errorf("Format(%s) with Tolerant = %q", src, s)
+			}
+			if !strings.Contains(got, "\x1b[31m"+n) {
+				t.Errorf("Format(%s) with Tolerant = %q, want %s colored as a number", src, got, n)
+			}
+		}
+
+		buf := &bytes.Buffer{}
+		if err := (&Formatter{}).Format(buf, []byte("["+n+"]")); err == nil {
+			t.Errorf("Format([%s]) without Tolerant succeeded", n)
+		}
+	}
+
+	// standard input is formatted as usual
+	src := `{"a":[1,-2.5e3,"NaN",true,null],"b":{}}`
+	if got, want := formatString(t, f, src), formatString(t, &Formatter{NumberColor: f.NumberColor}, src); got != want {
+		t.Errorf("Format(%s) with Tolerant = %q, want %q", src, got, want)
+	}
+
+	for _, src := range []string{`[Nan]`, `[-NaN]`, `[Infinit]`, `{"a" 1}`, `[1,]`, `[1`} {
+		buf := &bytes.Buffer{}
+		if err := f.Format(buf, []byte(src)); err == nil {
+			t.Errorf("Format(%s) with Tolerant succeeded", src)
+		}
+	}
+}
+
+func TestOnUnparseable(t *testing.T) {
+	var raws []string
+	f := &Formatter{
+		UnparseableColor: color.New(color.FgMagenta),
+		OnUnparseable: func(raw []byte) string {
+			raws = append(raws, string(raw))
+			return "‹?›"
+		},
+	}
+	src := `{foo:1,"a":undefined,"b":[1,@x,NaN]}`
+	buf := &bytes.Buffer{}
+	n, err := f.FormatTolerant(buf, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if want := `{‹?›:1,"a":‹?›,"b":[1,‹?›,NaN]}`; uncolored(got) != want {
+		t.Errorf("FormatTolerant(%s) = %q, want %q", src, uncolored(got), want)
+	}
+	if s, want := colored(got, "35"), "‹?›‹?›‹?›"; s != want {
+		t.Errorf("FormatTolerant(%s) colors %q as placeholders, want %q", src, s, want)
+	}
+	if n != 3 {
+		t.Errorf("FormatTolerant(%s) = %d, want 3", src, n)
+	}
+	if want := []string{"foo", "undefined", "@x"}; strings.Join(raws, " ") != strings.Join(want, " ") {
+		t.Errorf("OnUnparseable called with %q, want %q", raws, want)
+	}
+
+	g := *f
+	g.Indent = "  "
+	buf.Reset()
+	if _, err := g.FormatTolerant(buf, []byte(`{foo:1,"b":[@x]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := uncolored(buf.String()), "{\n  ‹?›: 1,\n  \"b\": [\n    ‹?›\n  ]\n}"; got != want {
+		t.Errorf("indented FormatTolerant = %q, want %q", got, want)
+	}
+
+	// without Tolerant, or without OnUnparseable, invalid tokens
+	// are errors
+	if err := f.Format(&bytes.Buffer{}, []byte(src)); err == nil {
+		t.Errorf("Format(%s) succeeded", src)
+	}
+	if _, err := (&Formatter{}).FormatTolerant(&bytes.Buffer{}, []byte(src)); err == nil {
+		t.Errorf("FormatTolerant(%s) without OnUnparseable succeeded", src)
+	}
+}
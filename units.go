@@ -0,0 +1,81 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultUnitFormatters are the unit formatters used when
+// Formatter.InferUnits is true and Formatter.UnitFormatters is nil.
+// Numbers stored under field names ending in:
+//
+//	_bytes are displayed in IEC units such as KiB and MiB
+//	_ms are displayed as durations such as 1.5s
+//	_seconds are displayed as durations such as 2h30m0s
+//	_percent are displayed followed by a percent sign
+//
+// Numbers small enough that the human-readable form adds nothing,
+// such as 512 bytes or 250 ms, are not decorated.
+var DefaultUnitFormatters = map[string]func(v float64) string{
+	"_bytes": formatBytes,
+	"_ms": func(v float64) string {
+		if v < 1000 && v > -1000 {
+			return ""
+		}
+		return time.Duration(v * float64(time.Millisecond)).String()
+	},
+	"_seconds": func(v float64) string {
+		if v < 60 && v > -60 {
+			return ""
+		}
+		return time.Duration(v * float64(time.Second)).String()
+	},
+	"_percent": func(v float64) string {
+		return fmt.Sprintf("%g%%", v)
+	},
+}
+
+// formatBytes returns the number of bytes v in IEC units, such as
+// 1.5 KiB, or "" if v is less than 1 KiB.
+func formatBytes(v float64) string {
+	const units = "KMGTPE"
+	if v < 1024 && v > -1024 {
+		return ""
+	}
+	i := -1
+	for ; i < len(units)-1 && (v >= 1024 || v <= -1024); i++ {
+		v /= 1024
+	}
+	return fmt.Sprintf("%.1f %ciB", v, units[i])
+}
+
+// unitText returns the human-readable form of the number value n
+// according to the unit inferred from the field name it is stored
+// under, or "" if there is none.
+func (fs *formatterState) unitText(n json.Number) string {
+	frame := fs.frame()
+	if !frame.inObject() || frame.inField() {
+		return ""
+	}
+	formatters := fs.f.UnitFormatters
+	if formatters == nil {
+		formatters = DefaultUnitFormatters
+	}
+	// use the formatter of the longest matching suffix
+	suffix := ""
+	for s := range formatters {
+		if strings.HasSuffix(frame.key, s) && len(s) > len(suffix) {
+			suffix = s
+		}
+	}
+	if len(suffix) == 0 {
+		return ""
+	}
+	v, err := n.Float64()
+	if err != nil {
+		return ""
+	}
+	return formatters[suffix](v)
+}
@@ -0,0 +1,51 @@
+package jsoncolor
+
+import (
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestInferUnits(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{`{"size_bytes":1536}`, `{"size_bytes":1536 (1.5 KiB)}`},
+		{`{"size_bytes":512}`, `{"size_bytes":512}`},
+		{`{"size_bytes":3221225472}`, `{"size_bytes":3221225472 (3.0 GiB)}`},
+		{`{"latency_ms":1500}`, `{"latency_ms":1500 (1.5s)}`},
+		{`{"latency_ms":250}`, `{"latency_ms":250}`},
+		{`{"uptime_seconds":9000}`, `{"uptime_seconds":9000 (2h30m0s)}`},
+		{`{"cpu_percent":12.5}`, `{"cpu_percent":12.5 (12.5%)}`},
+		{`{"size":1536,"name_bytes":"x"}`, `{"size":1536,"name_bytes":"x"}`},
+		{`[1536]`, `[1536]`},
+		{`{"a_bytes":[1536]}`, `{"a_bytes":[1536]}`},
+	}
+	for _, tt := range tests {
+		got := uncolored(formatString(t, &Formatter{InferUnits: true}, tt.src))
+		if got != tt.want {
+			t.Errorf("Format(%s) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+
+	src := `{"size_bytes":1536}`
+	if got := uncolored(formatString(t, &Formatter{}, src)); got != src {
+		t.Errorf("Format(%s) without InferUnits = %q, want %q", src, got, src)
+	}
+
+	f := &Formatter{
+		InferUnits: true,
+		UnitColor:  color.New(color.FgMagenta),
+		UnitFormatters: map[string]func(v float64) string{
+			"_x":  func(v float64) string { return "short" },
+			"b_x": func(v float64) string { return "long" },
+		},
+	}
+	got := formatString(t, f, `{"a_x":1,"ab_x":2,"size_bytes":1536}`)
+	if want := `{"a_x":1 (short),"ab_x":2 (long),"size_bytes":1536}`; uncolored(got) != want {
+		t.Errorf("Format with UnitFormatters = %q, want %q", uncolored(got), want)
+	}
+	if s := colored(got, "35"); s != " (short) (long)" {
+		t.Errorf("UnitColor colored %q, want %q", s, " (short) (long)")
+	}
+}
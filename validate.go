@@ -0,0 +1,71 @@
+package jsoncolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ValidationError is an error reported by a validator, such as a
+// JSON Schema validator, for the value identified by a JSON Pointer.
+type ValidationError struct {
+	// Pointer is the JSON Pointer (see RFC 6901) identifying the
+	// invalid value, such as "/items/3".
+	Pointer string
+	// Message describes the error.
+	Message string
+}
+
+// FormatWithErrors is like Format but highlights the values of src
+// identified by errs using ErrorColor and displays the messages of
+// errs as comments at the end of the lines containing the values, as
+// with Annotations, which is otherwise ignored.  Scalar values and
+// the names of fields holding invalid values are highlighted.
+// Messages are not displayed in compact output.  If any of the
+// pointers of errs do not identify a value of src, the output is
+// written but an error listing them is returned.
+func (f *Formatter) FormatWithErrors(dst io.Writer, src []byte, errs []ValidationError) error {
+	values, err := leafValues(src)
+	if err != nil {
+		return err
+	}
+
+	g := f.clone()
+	g.Annotations = map[string]string{}
+	g.CommentColor = f.errorColor()
+
+	var unmatched []string
+	for _, e := range errs {
+		if _, ok := values[e.Pointer]; !ok {
+			unmatched = append(unmatched, fmt.Sprintf("%q", e.Pointer))
+			continue
+		}
+		if m, ok := g.Annotations[e.Pointer]; ok {
+			g.Annotations[e.Pointer] = m + "; " + e.Message
+		} else {
+			g.Annotations[e.Pointer] = e.Message
+		}
+	}
+
+	invalid := f.errorColor()
+
+	fs := newFormatterState(g, dst)
+	fs.colorValue = func(path []string, t json.Token, field bool) SprintfFuncer {
+		if _, ok := g.Annotations[formatPointer(path)]; ok {
+			return invalid
+		}
+		return nil
+	}
+
+	err = fs.format(dst, src, false)
+	if err != nil {
+		return err
+	}
+
+	if len(unmatched) > 0 {
+		return fmt.Errorf("jsoncolor: validation error pointers not found: %s", strings.Join(unmatched, ", "))
+	}
+
+	return nil
+}
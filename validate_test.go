@@ -0,0 +1,47 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestFormatWithErrors(t *testing.T) {
+	src := `{"name":"","items":[1,"two"],"ok":true}`
+	errs := []ValidationError{
+		{"/name", "must not be empty"},
+		{"/items/1", "must be a number"},
+		{"/items/1", "must be positive"},
+	}
+	f := &Formatter{Indent: "  ", ErrorColor: color.New(color.FgMagenta)}
+	buf := &bytes.Buffer{}
+	err := f.FormatWithErrors(buf, []byte(src), errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n" +
+		"  \"name\": \"\",  // must not be empty\n" +
+		"  \"items\": [\n" +
+		"    1,\n" +
+		"    \"two\"  // must be a number; must be positive\n" +
+		"  ],\n" +
+		"  \"ok\": true\n" +
+		"}"
+	if got := uncolored(buf.String()); got != want {
+		t.Errorf("FormatWithErrors = %q, want %q", got, want)
+	}
+	if s, want := colored(buf.String(), "35"), `"name"""// must not be empty"two"// must be a number; must be positive`; s != want {
+		t.Errorf("FormatWithErrors highlights %q, want %q", s, want)
+	}
+
+	buf.Reset()
+	err = f.FormatWithErrors(buf, []byte(src), []ValidationError{{"/ok", "x"}, {"/missing", "y"}, {"/items/2", "z"}})
+	if err == nil || !strings.Contains(err.Error(), `"/missing", "/items/2"`) {
+		t.Errorf("FormatWithErrors with unmatched pointers returned %v", err)
+	}
+	if !strings.Contains(uncolored(buf.String()), `"ok": true  // x`) {
+		t.Errorf("FormatWithErrors with unmatched pointers = %q", uncolored(buf.String()))
+	}
+}
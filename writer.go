@@ -0,0 +1,78 @@
+package jsoncolor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ColorWriter is an io.WriteCloser that colorizes the JSON written to
+// it using a Formatter, for use with code such as a json.Encoder that
+// writes JSON to an io.Writer.  Written bytes are buffered in memory
+// and nothing is written to the underlying writer until Close is
+// called, so callers must call Close once they have finished writing.
+type ColorWriter struct {
+	w      io.Writer
+	f      *Formatter
+	buf    bytes.Buffer
+	closed bool
+}
+
+// NewColorWriter returns a ColorWriter that writes colorized output
+// to w using DefaultFormatter.
+func NewColorWriter(w io.Writer) *ColorWriter {
+	return NewColorWriterWithFormatter(w, DefaultFormatter)
+}
+
+// NewColorWriterWithFormatter is like NewColorWriter but using the
+// Formatter f.
+func NewColorWriterWithFormatter(w io.Writer, f *Formatter) *ColorWriter {
+	if f == nil {
+		panic("jsoncolor: nil formatter")
+	}
+	return &ColorWriter{
+		w: w,
+		f: f.clone(),
+	}
+}
+
+// Write buffers p, returning an error if cw has been closed.
+func (cw *ColorWriter) Write(p []byte) (int, error) {
+	if cw.closed {
+		return 0, fmt.Errorf("jsoncolor: write to closed ColorWriter")
+	}
+	return cw.buf.Write(p)
+}
+
+// Close colorizes the buffered JSON and writes it to the underlying
+// writer, which is not closed.  The buffered JSON may be a sequence
+// of values, such as those written by successive calls to a
+// json.Encoder's Encode method, in which case each value is
+// colorized and followed by a newline.  If the Formatter's Tolerant
+// field is true, the buffered JSON is colorized as by Format instead.
+func (cw *ColorWriter) Close() error {
+	if cw.closed {
+		return nil
+	}
+	cw.closed = true
+	src := cw.buf.Bytes()
+	if cw.f.Tolerant {
+		return cw.f.Format(cw.w, src)
+	}
+	dec := json.NewDecoder(bytes.NewReader(src))
+	for {
+		var v json.RawMessage
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = cw.f.format(cw.w, v, true)
+		if err != nil {
+			return err
+		}
+	}
+}